@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/adapters/out/llm"
+	"github.com/arslanovdi/Gist/core/internal/adapters/out/storage"
+	"github.com/arslanovdi/Gist/core/internal/adapters/out/tgclient"
+	"github.com/arslanovdi/Gist/core/internal/domain/core"
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"github.com/arslanovdi/Gist/core/internal/infra/logger"
+)
+
+// client зависимости команд: Telegram клиент и слой бизнес-логики, без бота.
+type client struct {
+	cfg     *config.Config
+	session *tgclient.Session
+	core    *core.Gist
+}
+
+// connect загружает конфигурацию и подключает Telegram клиент владельца (client.user_id).
+// interactive - при недействительной сессии запросить код подтверждения в консоли, иначе ошибка.
+// withLLM - инициализировать LLM, нужен для пересказов.
+func connect(ctx context.Context, sessionPath string, interactive, withLLM bool) (*client, error) {
+	cfg, errC := config.LoadConfig()
+	if errC != nil {
+		return nil, fmt.Errorf("load config: %w", errC)
+	}
+	logger.AddSecrets(cfg.Secrets()...)
+
+	session := tgclient.NewSession(cfg, tgclient.SessionParams{
+		UserID:      cfg.Client.UserID,
+		Phone:       cfg.Client.Phone,
+		SessionPath: sessionPath,
+		NoAuth:      !interactive,
+	})
+
+	var llmClient core.LLMClient
+	if withLLM {
+		genkit, errL := llm.NewGenkitService(ctx, cfg)
+		if errL != nil {
+			return nil, fmt.Errorf("llm initialization: %w", errL)
+		}
+		llmClient = genkit
+	}
+
+	store, errS := storage.New(cfg.Project.DataPath)
+	if errS != nil {
+		return nil, fmt.Errorf("storage initialization: %w", errS)
+	}
+
+	gist := core.NewGist(session, llmClient, store, cfg)
+	if errI := gist.InitSettings(ctx); errI != nil {
+		return nil, fmt.Errorf("settings initialization: %w", errI)
+	}
+
+	c := &client{
+		cfg:     cfg,
+		session: session,
+		core:    gist,
+	}
+
+	errs := make(chan error, 1)
+	session.Run(context.WithoutCancel(ctx), errs)
+
+	select {
+	case <-session.Ready():
+		return c, nil
+	case err := <-errs:
+		c.close()
+		return nil, fmt.Errorf("telegram client: %w (выполните gistctl auth)", err)
+	case <-ctx.Done():
+		c.close()
+		return nil, ctx.Err()
+	}
+}
+
+// close останавливает фоновые задачи и Telegram клиент.
+func (c *client) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Project.ShutdownTimeout)
+	defer cancel()
+
+	c.core.Close(ctx)
+	c.session.Close(ctx)
+}
+
+// findChat ищет чат по ID или части названия без учета регистра.
+func (c *client) findChat(ctx context.Context, query string) (model.Chat, error) {
+	chats, errA := c.core.GetAllChats(ctx)
+	if errA != nil {
+		return model.Chat{}, fmt.Errorf("get chats: %w", errA)
+	}
+
+	if id, errP := strconv.ParseInt(query, 10, 64); errP == nil {
+		for i := range chats {
+			if chats[i].ID == id {
+				return chats[i], nil
+			}
+		}
+	}
+
+	needle := strings.ToLower(query)
+	found := make([]model.Chat, 0)
+	for i := range chats {
+		if strings.Contains(strings.ToLower(chats[i].Title), needle) {
+			found = append(found, chats[i])
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return model.Chat{}, fmt.Errorf("чат %q не найден", query)
+	case 1:
+		return found[0], nil
+	default:
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "название %q подходит к нескольким чатам, укажите ID:", query)
+		for i := range found {
+			fmt.Fprintf(&sb, "\n  %d\t%s", found[i].ID, found[i].Title)
+		}
+		return model.Chat{}, fmt.Errorf("%s", sb.String())
+	}
+}
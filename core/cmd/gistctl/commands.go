@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/export"
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// authCommand вход в аккаунт Telegram, сессия сохраняется в файл.
+func authCommand(ctx context.Context, sessionPath string) error {
+	c, err := connect(ctx, sessionPath, true, false)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	fmt.Println("Аккаунт Telegram подключен, сессия сохранена")
+	return nil
+}
+
+// chatsCommand выводит список чатов.
+func chatsCommand(ctx context.Context, sessionPath string, args []string) error {
+	flags := flag.NewFlagSet("chats", flag.ContinueOnError)
+	unread := flags.Bool("unread", false, "только чаты с непрочитанными сообщениями")
+	importance := flags.Bool("importance", false, "с -unread: сортировка по оценке важности")
+	asJSON := flags.Bool("json", false, "вывод в формате JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	c, err := connect(ctx, sessionPath, false, *importance)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	var chats []model.Chat
+	if *unread {
+		order := model.OrderByUnread
+		if *importance {
+			order = model.OrderByImportance
+		}
+		chats, err = c.core.GetChatsWithUnreadMessages(ctx, order)
+	} else {
+		chats, err = c.core.GetAllChats(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		type chatJSON struct {
+			ID          int64   `json:"id"`
+			Title       string  `json:"title"`
+			UnreadCount int     `json:"unread_count"`
+			Mentions    int     `json:"unread_mentions"`
+			IsFavorite  bool    `json:"is_favorite"`
+			Importance  float64 `json:"importance,omitempty"`
+		}
+		result := make([]chatJSON, 0, len(chats))
+		for i := range chats {
+			result = append(result, chatJSON{chats[i].ID, chats[i].Title, chats[i].UnreadCount, chats[i].UnreadMentions, chats[i].IsFavorite, chats[i].Importance})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	for i := range chats {
+		fmt.Printf("%d\t%d\t%s\n", chats[i].ID, chats[i].UnreadCount, chats[i].Title)
+	}
+	return nil
+}
+
+// gistCommand выводит краткий пересказ чата в stdout, ход выполнения в stderr.
+func gistCommand(ctx context.Context, sessionPath string, args []string) error {
+	flags := flag.NewFlagSet("gist", flag.ContinueOnError)
+	rangeFlags := addRangeFlags(flags)
+	mentions := flags.Bool("mentions", false, "вынести упоминания и ответы вам в блок \"Касается вас\"")
+	formatName := flags.String("format", "", "документ md или html вместо текста")
+	modelName := flags.String("model", "", "модель LLM провайдер/модель, например Ollama/qwen3:8b, по умолчанию из настроек")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("укажите чат")
+	}
+
+	opts, errR := rangeFlags.options()
+	if errR != nil {
+		return errR
+	}
+	opts.MentionsBlock = *mentions
+	if *modelName != "" {
+		m, errM := model.ParseLLMModel(*modelName)
+		if errM != nil {
+			return errM
+		}
+		opts.Model = m
+	}
+
+	var format export.Format
+	if *formatName != "" {
+		f, errF := export.ParseFormat(*formatName)
+		if errF != nil {
+			return errF
+		}
+		format = f
+	}
+
+	c, err := connect(ctx, sessionPath, false, true)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	chat, batches, errG := c.gist(ctx, flags.Arg(0), opts)
+	if errG != nil {
+		return errG
+	}
+
+	if format != 0 && len(batches) > 0 {
+		chat.Gist = batches
+		return export.Write(os.Stdout, &chat, format)
+	}
+
+	return printGist(os.Stdout, chat, batches)
+}
+
+// ttsCommand генерирует пересказ и аудиопересказ чата, выводит пути к mp3 файлам.
+func ttsCommand(ctx context.Context, sessionPath string, args []string) error {
+	flags := flag.NewFlagSet("tts", flag.ContinueOnError)
+	rangeFlags := addRangeFlags(flags)
+	page := flags.Int("page", 0, "номер страницы пересказа, 0 - весь чат")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("укажите чат")
+	}
+
+	opts, errR := rangeFlags.options()
+	if errR != nil {
+		return errR
+	}
+
+	c, err := connect(ctx, sessionPath, false, true)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	chat, _, errG := c.gist(ctx, flags.Arg(0), opts)
+	if errG != nil {
+		return errG
+	}
+
+	fmt.Fprintln(os.Stderr, "Генерация аудиопересказа...")
+	audio, errA := c.core.GetAudioGist(ctx, chat.ID, *page)
+	if errA != nil {
+		return errA
+	}
+
+	for _, a := range audio {
+		fmt.Println(a.AudioFile)
+	}
+	return nil
+}
+
+// readCommand отмечает все сообщения чата прочитанными.
+func readCommand(ctx context.Context, sessionPath string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("укажите чат")
+	}
+
+	c, err := connect(ctx, sessionPath, false, false)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	chat, errF := c.findChat(ctx, args[0])
+	if errF != nil {
+		return errF
+	}
+
+	if _, errM := c.core.MarkAsRead(ctx, chat.ID, 0); errM != nil {
+		return errM
+	}
+
+	fmt.Fprintf(os.Stderr, "Чат «%s» отмечен прочитанным\n", chat.Title)
+	return nil
+}
+
+// modelsCommand выводит модели включенных провайдеров LLM, провайдер/модель на строку, для флага gist -model.
+func modelsCommand(ctx context.Context, sessionPath string, args []string) error {
+	flags := flag.NewFlagSet("models", flag.ContinueOnError)
+	provider := flags.String("provider", "", "только модели провайдера: Ollama, OpenRouter, Gemini, OpenAI")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	c, err := connect(ctx, sessionPath, false, true)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	providers := c.core.GetProviders(ctx)
+	if *provider != "" {
+		providers = []string{*provider}
+	}
+
+	for _, p := range providers {
+		models, errM := c.core.GetModels(ctx, p)
+		if errM != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", p, errM)
+			continue
+		}
+		for _, name := range models {
+			fmt.Printf("%s\n", model.LLMModel{Provider: p, Name: name})
+		}
+	}
+	return nil
+}
+
+// gist генерирует краткий пересказ чата, ход выполнения выводится в stderr.
+func (c *client) gist(ctx context.Context, query string, opts model.GistOptions) (model.Chat, []model.BatchGist, error) {
+	chat, errF := c.findChat(ctx, query)
+	if errF != nil {
+		return chat, nil, errF
+	}
+
+	batches, errG := c.core.GetChatGist(ctx, chat.ID, opts, func(message string, part int, llm bool) {
+		if llm {
+			fmt.Fprintf(os.Stderr, "\r%s %d%%   ", message, part)
+		} else {
+			fmt.Fprintf(os.Stderr, "\r%s %d   ", message, part)
+		}
+	})
+	fmt.Fprintln(os.Stderr)
+	if errG != nil {
+		return chat, nil, errG
+	}
+
+	detail, errD := c.core.GetChatDetail(ctx, chat.ID)
+	if errD == nil {
+		chat = *detail
+	}
+
+	return chat, batches, nil
+}
+
+// printGist выводит пересказ в текстовом виде.
+func printGist(w io.Writer, chat model.Chat, batches []model.BatchGist) error {
+	if len(batches) == 0 {
+		_, err := fmt.Fprintf(w, "%s: нет сообщений для пересказа (%s)\n", chat.Title, chat.Range)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "# %s (%s)\n", chat.Title, chat.Range); err != nil {
+		return err
+	}
+
+	if len(chat.AboutMe) > 0 {
+		fmt.Fprintln(w, "\n## Касается вас")
+		for _, msg := range chat.AboutMe {
+			fmt.Fprintf(w, "- %s: %s\n", msg.Timestamp.UTC().Format("02.01.2006 15:04"), msg.Text)
+		}
+	}
+
+	for i, b := range batches {
+		if _, err := fmt.Fprintf(w, "\n## %d/%d: %s — %s, сообщений: %d (UTC+0)\n\n%s\n",
+			i+1, len(batches),
+			b.FirstMessageData.UTC().Format("02.01.2006 15:04"),
+			b.LastMessageData.UTC().Format("02.01.2006 15:04"),
+			b.MessageCount, b.Gist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rangeFlags флаги выбора диапазона сообщений.
+type rangeFlags struct {
+	since *string
+	last  *int
+}
+
+func addRangeFlags(flags *flag.FlagSet) rangeFlags {
+	return rangeFlags{
+		since: flags.String("since", "", "сообщения начиная с момента времени RFC3339, например 2025-01-31T09:00:00+03:00"),
+		last:  flags.Int("last", 0, "последние N сообщений"),
+	}
+}
+
+// options параметры пересказа по флагам, по умолчанию непрочитанные сообщения.
+func (f rangeFlags) options() (model.GistOptions, error) {
+	var opts model.GistOptions
+
+	switch {
+	case *f.since != "" && *f.last != 0:
+		return opts, errors.New("флаги -since и -last несовместимы")
+	case *f.since != "":
+		since, err := time.Parse(time.RFC3339, *f.since)
+		if err != nil {
+			return opts, fmt.Errorf("-since: %w", err)
+		}
+		opts.Range = model.MessageRange{Kind: model.RangeSince, Since: since}
+	case *f.last != 0:
+		opts.Range = model.MessageRange{Kind: model.RangeLast, Last: *f.last}
+	}
+
+	return opts, opts.Range.Validate()
+}
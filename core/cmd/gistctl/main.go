@@ -0,0 +1,100 @@
+// Package main консольный клиент gistctl: пересказы чатов без Telegram бота, для cron и shell скриптов
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/arslanovdi/Gist/core/internal/infra/logger"
+	"github.com/joho/godotenv"
+)
+
+const (
+	serviceName = "gistctl"
+	envFileName = ".env"
+)
+
+const usage = `Использование: gistctl [-v] [-session файл] команда [флаги] [аргументы]
+
+Команды:
+  auth                         вход в аккаунт Telegram, код подтверждения вводится в консоли
+  chats [-unread] [-importance] [-json]
+                               список чатов: ID, количество непрочитанных, название
+  gist [-since RFC3339 | -last N] [-mentions] [-format md|html] [-model провайдер/модель] <чат>
+                               краткий пересказ чата в stdout, по умолчанию непрочитанных сообщений
+  tts [-since RFC3339 | -last N] [-page N] <чат>
+                               аудиопересказ чата, в stdout пути к mp3 файлам
+  read <чат>                   отметить все сообщения чата прочитанными
+  models [-provider имя]       модели включенных провайдеров LLM для флага gist -model
+
+<чат> - ID чата или часть названия.
+Конфигурация как у сервера: CONFIG_FILE, переменные окружения, файл .env.
+`
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	flags := flag.NewFlagSet(serviceName, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	verbose := flags.Bool("v", false, "подробный лог в stderr")
+	sessionPath := flags.String("session", "", "файл сессии Telegram клиента, по умолчанию как у сервера")
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	level := slog.LevelWarn // stdout используется для результата, лог только в stderr
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	logger.InitializeLogger(level, serviceName)
+
+	_ = godotenv.Load(envFileName) // Файла может не быть, параметры передаются через ENV
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	command, args := flags.Arg(0), flags.Args()[1:]
+
+	var err error
+	switch command {
+	case "auth":
+		err = authCommand(ctx, *sessionPath)
+	case "chats":
+		err = chatsCommand(ctx, *sessionPath, args)
+	case "gist":
+		err = gistCommand(ctx, *sessionPath, args)
+	case "tts":
+		err = ttsCommand(ctx, *sessionPath, args)
+	case "read":
+		err = readCommand(ctx, *sessionPath, args)
+	case "models":
+		err = modelsCommand(ctx, *sessionPath, args)
+	default:
+		fmt.Fprintf(os.Stderr, "неизвестная команда %q\n\n", command)
+		flags.Usage()
+		return 2
+	}
+
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "gistctl %s: %v\n", command, err)
+		return 1
+	}
+
+	return 0
+}
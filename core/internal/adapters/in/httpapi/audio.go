@@ -0,0 +1,140 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// listAudio GET /api/v1/chats/{id}/audio?page=N - аудиопересказ страницы N, без page - всего чата.
+// Отдает только сгенерированный аудиопересказ: 404 - нет пересказа или страницы, 409 - аудио еще не сгенерировано,
+// генерация запускается запросом POST.
+func (s *Server) listAudio(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+	page, ok := queryPage(w, r)
+	if !ok {
+		return
+	}
+
+	audio, ok := chatAudio(w, r, chatID, page)
+	if !ok {
+		return
+	}
+
+	result := make([]audioDTO, 0, len(audio))
+	for i := range audio {
+		result = append(result, audioDTO{
+			Caption: audio[i].Caption,
+			URL:     fmt.Sprintf("/api/v1/chats/%d/audio/%d?page=%d", chatID, i+1, page),
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// startAudio POST /api/v1/chats/{id}/audio?page=N - ставит в очередь генерацию аудиопересказа страницы N, без page - всего чата.
+// Ответ 202 с фоновой задачей, ход выполнения - в GET /api/v1/jobs. Если задача для чата уже выполняется, ответ 409 с этой задачей.
+func (s *Server) startAudio(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+	page, ok := queryPage(w, r)
+	if !ok {
+		return
+	}
+
+	core := coreService(r)
+	chat, err := chatDetail(r.Context(), core, chatID)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	if len(chat.Gist) == 0 || page > len(chat.Gist) {
+		writeError(w, http.StatusNotFound, "gist page not found")
+		return
+	}
+
+	job, errJ := core.StartAudioJob(r.Context(), chatID, page, nil)
+	if errors.Is(errJ, model.ErrJobAlreadyRunning) {
+		writeJSON(w, http.StatusConflict, newJobDTO(job))
+		return
+	}
+	if errJ != nil {
+		writeCoreError(w, errJ)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, newJobDTO(job))
+}
+
+// getAudioFile GET /api/v1/chats/{id}/audio/{file}?page=N - mp3 файл аудиопересказа, file - номер файла из listAudio, с 1.
+func (s *Server) getAudioFile(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+	page, ok := queryPage(w, r)
+	if !ok {
+		return
+	}
+	file, errF := strconv.Atoi(r.PathValue("file"))
+	if errF != nil || file < 1 {
+		writeError(w, http.StatusBadRequest, "invalid file number")
+		return
+	}
+
+	audio, ok := chatAudio(w, r, chatID, page)
+	if !ok {
+		return
+	}
+	if file > len(audio) {
+		writeError(w, http.StatusNotFound, "audio file not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/mpeg")
+	http.ServeFile(w, r, audio[file-1].AudioFile)
+}
+
+// chatAudio возвращает сгенерированный аудиопересказ страницы page, 0 - всего чата. Аудио не генерирует.
+// При ошибке отправляет ответ: 404 - нет пересказа или страницы, 409 - аудио еще не сгенерировано.
+func chatAudio(w http.ResponseWriter, r *http.Request, chatID int64, page int) ([]model.AudioGist, bool) {
+	chat, err := chatDetail(r.Context(), coreService(r), chatID)
+	if err != nil {
+		writeCoreError(w, err)
+		return nil, false
+	}
+	if len(chat.Gist) == 0 || page > len(chat.Gist) {
+		writeError(w, http.StatusNotFound, "gist page not found")
+		return nil, false
+	}
+
+	audio := chat.Audio
+	if page > 0 {
+		audio = chat.Gist[page-1].Audio
+	}
+	if len(audio) == 0 {
+		writeError(w, http.StatusConflict, "audio not generated, start it with POST")
+		return nil, false
+	}
+	return audio, true
+}
+
+// queryPage разбирает номер страницы пересказа из параметров запроса, 0 - весь чат. При ошибке отправляет ответ 400.
+func queryPage(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if !r.URL.Query().Has("page") {
+		return 0, true
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 0 {
+		writeError(w, http.StatusBadRequest, "invalid page")
+		return 0, false
+	}
+	return page, true
+}
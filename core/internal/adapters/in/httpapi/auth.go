@@ -0,0 +1,54 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// coreServiceKey ключ слоя бизнес-логики пользователя в контексте запроса.
+type coreServiceKey struct{}
+
+// auth проверяет Bearer токен и добавляет в контекст запроса слой бизнес-логики пользователя токена.
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		userID, found := s.lookupToken(token)
+		if !found {
+			slog.With("func", "httpapi.auth").Debug("invalid token", slog.String("remote_addr", r.RemoteAddr))
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		coreService, linked := s.users(userID)
+		if !linked {
+			writeError(w, http.StatusForbidden, "telegram account of user is not connected")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), coreServiceKey{}, coreService)))
+	}
+}
+
+// lookupToken ищет пользователя токена. Сравнение за постоянное время, чтобы не раскрывать токены по времени ответа.
+func (s *Server) lookupToken(token string) (int64, bool) {
+	userID, found := int64(0), false
+	for t, id := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			userID, found = id, true
+		}
+	}
+	return userID, found
+}
+
+// coreService возвращает слой бизнес-логики пользователя запроса.
+func coreService(r *http.Request) CoreService {
+	return r.Context().Value(coreServiceKey{}).(CoreService)
+}
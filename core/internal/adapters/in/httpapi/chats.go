@@ -0,0 +1,163 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// listChats GET /api/v1/chats - все чаты пользователя.
+func (s *Server) listChats(w http.ResponseWriter, r *http.Request) {
+	chats, err := coreService(r).GetAllChats(r.Context())
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatsDTO(chats))
+}
+
+// listUnreadChats GET /api/v1/chats/unread?order=importance - чаты с непрочитанными сообщениями.
+// По умолчанию отсортированы по количеству непрочитанных, order=importance - по оценке важности.
+func (s *Server) listUnreadChats(w http.ResponseWriter, r *http.Request) {
+	order := model.OrderByUnread
+	switch r.URL.Query().Get("order") {
+	case "", "unread":
+	case "importance":
+		order = model.OrderByImportance
+	default:
+		writeError(w, http.StatusBadRequest, "order must be unread or importance")
+		return
+	}
+
+	chats, err := coreService(r).GetChatsWithUnreadMessages(r.Context(), order)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatsDTO(chats))
+}
+
+// listFavoriteChats GET /api/v1/chats/favorites - избранные чаты.
+func (s *Server) listFavoriteChats(w http.ResponseWriter, r *http.Request) {
+	chats, err := coreService(r).GetFavoriteChats(r.Context())
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatsDTO(chats))
+}
+
+// getChat GET /api/v1/chats/{id} - информация о чате и его краткий пересказ, если он сделан.
+func (s *Server) getChat(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+
+	chat, err := chatDetail(r.Context(), coreService(r), chatID)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		chatDTO
+		Gist []batchDTO `json:"gist"`
+	}{newChatDTO(chat), newBatchesDTO(chat.Gist)})
+}
+
+// toggleFavorite POST /api/v1/chats/{id}/favorite - добавляет чат в избранное или убирает из него.
+func (s *Server) toggleFavorite(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+
+	core := coreService(r)
+	if _, err := chatDetail(r.Context(), core, chatID); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	if err := core.ChangeFavorites(r.Context(), chatID); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	chat, err := core.GetChatDetail(r.Context(), chatID)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatDTO(chat))
+}
+
+// markAsRead POST /api/v1/chats/{id}/read - отмечает сообщения чата прочитанными.
+// Тело запроса {"page": N} - до конца страницы пересказа N включительно, без тела или page=0 - все сообщения чата.
+func (s *Server) markAsRead(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Page int `json:"page"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+			return
+		}
+	}
+
+	core := coreService(r)
+	if _, err := chatDetail(r.Context(), core, chatID); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	chat, err := core.MarkAsRead(r.Context(), chatID, body.Page)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatDTO(chat))
+}
+
+// listJobs GET /api/v1/jobs - фоновые задачи пользователя, новые первыми.
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	jobs := coreService(r).GetJobs(r.Context())
+
+	result := make([]jobDTO, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, newJobDTO(job))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// pathChatID разбирает ID чата из пути запроса. При ошибке отправляет ответ 400.
+func pathChatID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid chat id")
+		return 0, false
+	}
+	return chatID, true
+}
+
+// chatDetail возвращает чат из кэша. Если кэш еще не заполнен, загружает список чатов.
+func chatDetail(ctx context.Context, core CoreService, chatID int64) (*model.Chat, error) {
+	chat, err := core.GetChatDetail(ctx, chatID)
+	if !errors.Is(err, model.ErrChatNotFoundInCache) {
+		return chat, err
+	}
+
+	if _, errA := core.GetAllChats(ctx); errA != nil {
+		return nil, errA
+	}
+	return core.GetChatDetail(ctx, chatID)
+}
@@ -0,0 +1,227 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// chatDTO чат в ответах API.
+type chatDTO struct {
+	ID              int64   `json:"id"`
+	Title           string  `json:"title"`
+	Type            string  `json:"type,omitempty"`
+	UnreadCount     int     `json:"unread_count"`
+	UnreadMentions  int     `json:"unread_mentions"`
+	UnreadReactions int     `json:"unread_reactions"`
+	IsFavorite      bool    `json:"is_favorite"`
+	IsHidden        bool    `json:"is_hidden"`
+	Importance      float64 `json:"importance,omitempty"`
+	Urgency         int     `json:"urgency,omitempty"`
+	Range           string  `json:"range"`
+	GistPartial     bool    `json:"gist_partial"`
+	GistPages       int     `json:"gist_pages"`
+}
+
+// batchDTO батч краткого пересказа в ответах API.
+type batchDTO struct {
+	Page           int       `json:"page"` // Номер страницы, с 1. Используется в mark as read и audio
+	FirstMessageID int       `json:"first_message_id"`
+	FirstMessageAt time.Time `json:"first_message_at"`
+	LastMessageID  int       `json:"last_message_id"`
+	LastMessageAt  time.Time `json:"last_message_at"`
+	MessageCount   int       `json:"message_count"`
+	Gist           string    `json:"gist"`
+}
+
+// audioDTO файл аудиопересказа в ответах API.
+type audioDTO struct {
+	Caption string `json:"caption"`
+	URL     string `json:"url"`
+}
+
+// jobDTO фоновая задача в ответах API.
+type jobDTO struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	ChatID    int64      `json:"chat_id"`
+	ChatTitle string     `json:"chat_title"`
+	Status    string     `json:"status"`
+	Progress  string     `json:"progress"`
+	Error     string     `json:"error,omitempty"`
+	Created   time.Time  `json:"created"`
+	Finished  *time.Time `json:"finished,omitempty"`
+}
+
+// progressDTO событие хода генерации пересказа (SSE).
+type progressDTO struct {
+	Message string `json:"message"`
+	Part    int    `json:"part"` // Количество загруженных сообщений, либо процент выполнения на этапе генерации LLM
+	LLM     bool   `json:"llm"`
+}
+
+// modelsDTO модели провайдера LLM.
+type modelsDTO struct {
+	Provider string   `json:"provider"`
+	Models   []string `json:"models"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// modelsListDTO ответ со списком моделей.
+type modelsListDTO struct {
+	Current   string      `json:"current"` // Модель пользователя из настроек, провайдер/модель
+	Providers []modelsDTO `json:"providers"`
+}
+
+// errorDTO ответ с ошибкой.
+type errorDTO struct {
+	Error string `json:"error"`
+}
+
+func newChatDTO(chat *model.Chat) chatDTO {
+	return chatDTO{
+		ID:              chat.ID,
+		Title:           chat.Title,
+		Type:            chatType(chat.Type),
+		UnreadCount:     chat.UnreadCount,
+		UnreadMentions:  chat.UnreadMentions,
+		UnreadReactions: chat.UnreadReactions,
+		IsFavorite:      chat.IsFavorite,
+		IsHidden:        chat.IsHidden,
+		Importance:      chat.Importance,
+		Urgency:         chat.Urgency,
+		Range:           chat.Range.String(),
+		GistPartial:     chat.GistPartial,
+		GistPages:       len(chat.Gist),
+	}
+}
+
+func newChatsDTO(chats []model.Chat) []chatDTO {
+	result := make([]chatDTO, 0, len(chats))
+	for i := range chats {
+		result = append(result, newChatDTO(&chats[i]))
+	}
+	return result
+}
+
+func newBatchesDTO(batches []model.BatchGist) []batchDTO {
+	result := make([]batchDTO, 0, len(batches))
+	for i, b := range batches {
+		result = append(result, batchDTO{
+			Page:           i + 1,
+			FirstMessageID: b.FirstMessageID,
+			FirstMessageAt: b.FirstMessageData,
+			LastMessageID:  b.LastMessageID,
+			LastMessageAt:  b.LastMessageData,
+			MessageCount:   b.MessageCount,
+			Gist:           b.Gist,
+		})
+	}
+	return result
+}
+
+func newJobDTO(job model.Job) jobDTO {
+	dto := jobDTO{
+		ID:        job.ID,
+		ChatID:    job.ChatID,
+		ChatTitle: job.ChatTitle,
+		Progress:  job.Progress,
+		Created:   job.Created,
+	}
+
+	switch job.Kind {
+	case model.JobGist:
+		dto.Kind = "gist"
+	case model.JobAudio:
+		dto.Kind = "audio"
+	case model.JobBriefing:
+		dto.Kind = "briefing"
+	case model.JobQuestion:
+		dto.Kind = "question"
+	}
+
+	switch job.Status {
+	case model.JobPending:
+		dto.Status = "pending"
+	case model.JobRunning:
+		dto.Status = "running"
+	case model.JobDone:
+		dto.Status = "done"
+	case model.JobFailed:
+		dto.Status = "failed"
+	}
+
+	if job.Err != nil {
+		dto.Error = publicError(job.Err)
+	}
+	if !job.Finished.IsZero() {
+		dto.Finished = &job.Finished
+	}
+
+	return dto
+}
+
+func chatType(t model.ChatType) string {
+	switch t {
+	case model.ChatPrivate:
+		return "private"
+	case model.ChatGroup:
+		return "group"
+	case model.ChatChannel:
+		return "channel"
+	default:
+		return ""
+	}
+}
+
+// writeJSON отправляет ответ в формате JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.With("func", "httpapi.writeJSON").Error("encode response error", slog.Any("error", err))
+	}
+}
+
+// writeError отправляет ответ с ошибкой.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorDTO{Error: message})
+}
+
+// errInternal текст ответа на внутренние ошибки. Подробности пишутся только в лог.
+const errInternal = "internal error"
+
+// coreErrorStatus возвращает код ответа по типу ошибки слоя бизнес-логики.
+func coreErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrChatNotFoundInCache), errors.Is(err, model.ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrInvalidRange), errors.Is(err, model.ErrUnknownModel):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrGistChanged):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrNotReady):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// publicError возвращает текст ошибки для клиента. Внутренние ошибки логируются и заменяются общим текстом,
+// чтобы не раскрывать пути к файлам, адреса провайдеров и ответы внешних сервисов.
+func publicError(err error) string {
+	if coreErrorStatus(err) != http.StatusInternalServerError {
+		return err.Error()
+	}
+	slog.With("func", "httpapi.publicError").Error("core error", slog.Any("error", err))
+	return errInternal
+}
+
+// writeCoreError отправляет ответ с ошибкой слоя бизнес-логики, код ответа по типу ошибки.
+func writeCoreError(w http.ResponseWriter, err error) {
+	writeError(w, coreErrorStatus(err), publicError(err))
+}
@@ -0,0 +1,48 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/export"
+)
+
+// exportGist GET /api/v1/chats/{id}/export?format=md|html - сделанный краткий пересказ чата документом Markdown или HTML, по умолчанию Markdown.
+func (s *Server) exportGist(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+
+	format := export.Markdown
+	if r.URL.Query().Has("format") {
+		f, errF := export.ParseFormat(r.URL.Query().Get("format"))
+		if errF != nil {
+			writeError(w, http.StatusBadRequest, "format must be md or html")
+			return
+		}
+		format = f
+	}
+
+	chat, err := chatDetail(r.Context(), coreService(r), chatID)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	if len(chat.Gist) == 0 {
+		writeError(w, http.StatusNotFound, "chat has no gist, generate it first")
+		return
+	}
+
+	data, errR := export.Render(chat, format)
+	if errR != nil {
+		writeError(w, http.StatusInternalServerError, errR.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(export.FileName(chat, format))))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
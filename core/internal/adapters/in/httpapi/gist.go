@@ -0,0 +1,209 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// getGist GET /api/v1/chats/{id}/gist - сгенерированный краткий пересказ чата из кэша.
+// Параметры диапазона те же, что у startGist, пересказ отдается, только если он получен для этого диапазона.
+// Пересказ не генерирует: 404 - пересказа нет, генерация запускается запросом POST.
+func (s *Server) getGist(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+
+	rng, errR := parseRange(r.URL.Query())
+	if errR != nil {
+		writeError(w, http.StatusBadRequest, errR.Error())
+		return
+	}
+
+	chat, err := chatDetail(r.Context(), coreService(r), chatID)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	if len(chat.Gist) == 0 || !chat.Range.Equal(rng) {
+		writeError(w, http.StatusNotFound, "gist not found, start it with POST")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newBatchesDTO(chat.Gist))
+}
+
+// startGist POST /api/v1/chats/{id}/gist - генерация краткого пересказа чата.
+//
+// Диапазон сообщений задается параметрами запроса, по умолчанию непрочитанные сообщения:
+//   - since=RFC3339 - сообщения начиная с момента времени
+//   - last=N - последние N сообщений
+//   - from=ID&to=ID - сообщения с ID from по ID to включительно, без to - до последнего сообщения
+//
+// mentions=true выносит упоминания и ответы пользователю в отдельный блок.
+// model=провайдер/модель - модель LLM для этого запроса, например OpenRouter/meta-llama/llama-3.3-70b-instruct, без модели - модель из конфигурации провайдера.
+//
+// Пересказ генерируется фоновой задачей из общей очереди, отключение клиента задачу не отменяет, результат остается в кэше чата.
+// Если для чата уже выполняется задача пересказа, ответ 409 с этой задачей.
+// С заголовком Accept: text/event-stream ход выполнения задачи передается событиями SSE "progress", результат - событием "gist", ошибка - событием "error".
+func (s *Server) startGist(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := pathChatID(w, r)
+	if !ok {
+		return
+	}
+
+	rng, errR := parseRange(r.URL.Query())
+	if errR != nil {
+		writeError(w, http.StatusBadRequest, errR.Error())
+		return
+	}
+	opts := model.GistOptions{Range: rng, MentionsBlock: r.URL.Query().Get("mentions") == "true"}
+	if r.URL.Query().Has("model") {
+		m, errM := model.ParseLLMModel(r.URL.Query().Get("model"))
+		if errM != nil {
+			writeError(w, http.StatusBadRequest, errM.Error())
+			return
+		}
+		opts.Model = m
+	}
+
+	core := coreService(r)
+	if _, err := chatDetail(r.Context(), core, chatID); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	job, errJ := core.StartGistJob(r.Context(), chatID, opts, nil)
+	if errors.Is(errJ, model.ErrJobAlreadyRunning) {
+		writeJSON(w, http.StatusConflict, newJobDTO(job))
+		return
+	}
+	if errJ != nil {
+		writeCoreError(w, errJ)
+		return
+	}
+
+	updates, errW := core.WatchJob(r.Context(), job.ID)
+	if errW != nil {
+		writeCoreError(w, errW)
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		for snapshot := range updates {
+			job = snapshot
+		}
+		if r.Context().Err() != nil {
+			return // Клиент отключился, задача продолжает выполняться
+		}
+		if job.Err != nil {
+			writeCoreError(w, job.Err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newBatchesDTO(job.Gist))
+		return
+	}
+
+	sse, errS := newEventStream(w)
+	if errS != nil {
+		writeCoreError(w, errS)
+		return
+	}
+
+	for job = range updates {
+		if job.Active() {
+			sse.send("progress", progressDTO{Message: job.Progress, Part: job.Part, LLM: job.LLM})
+		}
+	}
+	if r.Context().Err() != nil {
+		return
+	}
+	if job.Err != nil {
+		sse.send("error", errorDTO{Error: publicError(job.Err)})
+		return
+	}
+	sse.send("gist", newBatchesDTO(job.Gist))
+}
+
+// parseRange разбирает диапазон сообщений из параметров запроса.
+func parseRange(query url.Values) (model.MessageRange, error) {
+	var rng model.MessageRange
+
+	switch {
+	case query.Has("since"):
+		since, err := time.Parse(time.RFC3339, query.Get("since"))
+		if err != nil {
+			return rng, fmt.Errorf("since must be RFC3339 time: %w", err)
+		}
+		rng = model.MessageRange{Kind: model.RangeSince, Since: since}
+	case query.Has("last"):
+		last, err := strconv.Atoi(query.Get("last"))
+		if err != nil {
+			return rng, fmt.Errorf("last must be number: %w", err)
+		}
+		rng = model.MessageRange{Kind: model.RangeLast, Last: last}
+	case query.Has("from"):
+		from, err := strconv.Atoi(query.Get("from"))
+		if err != nil {
+			return rng, fmt.Errorf("from must be message id: %w", err)
+		}
+		to := 0
+		if query.Has("to") {
+			if to, err = strconv.Atoi(query.Get("to")); err != nil {
+				return rng, fmt.Errorf("to must be message id: %w", err)
+			}
+		}
+		rng = model.MessageRange{Kind: model.RangeBetween, FromID: from, ToID: to}
+	}
+
+	return rng, rng.Validate()
+}
+
+// eventStream поток событий Server-Sent Events.
+type eventStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newEventStream(w http.ResponseWriter) (*eventStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &eventStream{w: w, flusher: flusher}, nil
+}
+
+// send отправляет событие с данными в формате JSON.
+func (e *eventStream) send(event string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.With("func", "httpapi.eventStream.send").Error("marshal event error", slog.Any("error", err))
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, errW := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, data); errW != nil {
+		return // Клиент отключился, подписка на задачу отменится вместе с контекстом запроса
+	}
+	e.flusher.Flush()
+}
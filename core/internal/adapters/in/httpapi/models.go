@@ -0,0 +1,38 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// listModels GET /api/v1/models - модели включенных провайдеров LLM, provider=Gemini - только модели провайдера.
+// Модель передается в запрос пересказа параметром model=провайдер/модель.
+func (s *Server) listModels(w http.ResponseWriter, r *http.Request) {
+	core := coreService(r)
+
+	providers := core.GetProviders(r.Context())
+	if r.URL.Query().Has("provider") {
+		providers = []string{r.URL.Query().Get("provider")}
+	}
+
+	result := make([]modelsDTO, 0, len(providers))
+	for _, p := range providers {
+		models, err := core.GetModels(r.Context(), p)
+		if err != nil && len(providers) == 1 {
+			writeCoreError(w, err)
+			return
+		}
+		dto := modelsDTO{Provider: p, Models: models}
+		if err != nil { // Недоступный провайдер не мешает получить модели остальных
+			dto.Error = err.Error()
+		}
+		result = append(result, dto)
+	}
+
+	settings := core.GetSettings(r.Context())
+	writeJSON(w, http.StatusOK, modelsListDTO{
+		Current:   model.LLMModel{Provider: settings.Provider, Name: settings.Model}.String(),
+		Providers: result,
+	})
+}
@@ -0,0 +1,127 @@
+// Package httpapi реализует HTTP JSON API v1 над слоем бизнес-логики
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+)
+
+// CoreService определяет интерфейс для взаимодействия с бизнес-логикой.
+type CoreService interface {
+	GetAllChats(ctx context.Context) ([]model.Chat, error)
+	GetChatsWithUnreadMessages(ctx context.Context, order model.ChatOrder) ([]model.Chat, error)
+	GetFavoriteChats(ctx context.Context) ([]model.Chat, error)
+	GetChatDetail(ctx context.Context, chatID int64) (*model.Chat, error)
+	ChangeFavorites(ctx context.Context, chatID int64) error
+	MarkAsRead(ctx context.Context, chatID int64, pageID int) (*model.Chat, error)
+	GetJobs(ctx context.Context) []model.Job
+	StartGistJob(ctx context.Context, chatID int64, opts model.GistOptions, callback func(job model.Job)) (model.Job, error)
+	StartAudioJob(ctx context.Context, chatID int64, page int, callback func(job model.Job)) (model.Job, error)
+	WatchJob(ctx context.Context, jobID int64) (<-chan model.Job, error)
+	GetSettings(ctx context.Context) model.Settings
+	GetProviders(ctx context.Context) []string
+	GetModels(ctx context.Context, provider string) ([]string, error)
+}
+
+// UserLookup возвращает слой бизнес-логики пользователя бота. false - аккаунт Telegram пользователя не подключен.
+type UserLookup func(userID int64) (CoreService, bool)
+
+// Server HTTP сервер API.
+type Server struct {
+	srv    *http.Server
+	users  UserLookup
+	tokens map[string]int64 // ID пользователя по токену
+	addr   string
+
+	wg *sync.WaitGroup
+}
+
+// New создает HTTP сервер API. Маршруты версии v1 доступны по префиксу /api/v1.
+func New(cfg *config.Config, users UserLookup) (*Server, error) {
+	tokens := make(map[string]int64, len(cfg.API.Tokens))
+	for _, t := range cfg.API.Tokens {
+		if t.Token == "" {
+			continue
+		}
+		tokens[t.Token.Value()] = t.UserID
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("[httpapi.New] no api tokens configured")
+	}
+
+	s := &Server{
+		users:  users,
+		tokens: tokens,
+		addr:   cfg.API.ListenAddress,
+		wg:     &sync.WaitGroup{},
+	}
+
+	s.srv = &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: cfg.API.ReadHeaderTimeout,
+	}
+
+	return s, nil
+}
+
+// routes регистрирует маршруты API.
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/chats", s.auth(s.listChats))
+	mux.HandleFunc("GET /api/v1/chats/unread", s.auth(s.listUnreadChats))
+	mux.HandleFunc("GET /api/v1/chats/favorites", s.auth(s.listFavoriteChats))
+	mux.HandleFunc("GET /api/v1/chats/{id}", s.auth(s.getChat))
+	mux.HandleFunc("POST /api/v1/chats/{id}/favorite", s.auth(s.toggleFavorite))
+	mux.HandleFunc("GET /api/v1/chats/{id}/gist", s.auth(s.getGist))
+	mux.HandleFunc("POST /api/v1/chats/{id}/gist", s.auth(s.startGist))
+	mux.HandleFunc("POST /api/v1/chats/{id}/read", s.auth(s.markAsRead))
+	mux.HandleFunc("GET /api/v1/chats/{id}/export", s.auth(s.exportGist))
+	mux.HandleFunc("GET /api/v1/chats/{id}/audio", s.auth(s.listAudio))
+	mux.HandleFunc("POST /api/v1/chats/{id}/audio", s.auth(s.startAudio))
+	mux.HandleFunc("GET /api/v1/chats/{id}/audio/{file}", s.auth(s.getAudioFile))
+	mux.HandleFunc("GET /api/v1/jobs", s.auth(s.listJobs))
+	mux.HandleFunc("GET /api/v1/models", s.auth(s.listModels))
+
+	return mux
+}
+
+// Run запускает HTTP сервер в отдельной горутине. Ошибку запуска отправляет в serverErr.
+func (s *Server) Run(_ context.Context, serverErr chan error) {
+	log := slog.With("func", "httpapi.Run")
+
+	listener, errL := net.Listen("tcp", s.addr)
+	if errL != nil {
+		serverErr <- fmt.Errorf("[httpapi.Run] listen failed: %w", errL)
+		return
+	}
+
+	s.wg.Go(func() {
+		errS := s.srv.Serve(listener)
+		if errS != nil && !errors.Is(errS, http.ErrServerClosed) {
+			serverErr <- fmt.Errorf("[httpapi.Run] serve failed: %w", errS)
+		}
+	})
+
+	log.Info("api server started", slog.String("address", s.addr))
+}
+
+// Close останавливает HTTP сервер, ожидая завершения активных запросов.
+func (s *Server) Close(ctx context.Context) {
+	log := slog.With("func", "httpapi.Close")
+
+	if errS := s.srv.Shutdown(ctx); errS != nil {
+		log.Error("Error shutting down api server", slog.Any("error", errS))
+	}
+
+	s.wg.Wait()
+	log.Info("api server stopped")
+}
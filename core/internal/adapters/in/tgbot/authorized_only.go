@@ -5,34 +5,61 @@ import (
 
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
 )
 
 // authorizedOnlyMiddleware middleware для проверки прав доступа пользователя.
+// Добавляет в контекст сессию пользователя, если его аккаунт Telegram подключен.
 func (b *Bot) authorizedOnlyMiddleware() th.Handler {
 	return func(ctx *th.Context, update telego.Update) (err error) {
-		var from telego.User
-		log := slog.With("func", "tgbot.authorizedOnlyMiddleware")
-
-		// Определяем отправителя из возможных источников
-		switch {
-		case update.Message != nil:
-			from = *update.Message.From
-		case update.CallbackQuery != nil:
-			from = update.CallbackQuery.From
-		case update.InlineQuery != nil:
-			from = update.InlineQuery.From
-		default:
+		from, ok := updateSender(update)
+		if !ok {
 			// Неизвестный или неподдерживаемый тип обновления
 			return nil
 		}
 
-		// Если пользователь не определен или ID не совпадает - игнорируем запрос
-		if from.ID != b.allowedUserID {
-			log.Debug("Unauthorized access attempt", slog.Int64("user_id", from.ID))
+		// Если пользователю не разрешено работать с ботом - игнорируем запрос
+		if !b.users.Allowed(from.ID) {
+			slog.With("func", "tgbot.authorizedOnlyMiddleware").Debug("Unauthorized access attempt", slog.Int64("user_id", from.ID))
 			return nil
 		}
 
+		if s, linked := b.userSession(from.ID); linked {
+			ctx = ctx.WithValue(userSessionKey{}, s)
+		}
+
 		// Разрешаем обработку
 		return ctx.Next(update)
 	}
 }
+
+// linkedOnlyMiddleware пропускает дальше только запросы пользователей с подключенным аккаунтом Telegram.
+func (b *Bot) linkedOnlyMiddleware() th.Handler {
+	return func(ctx *th.Context, update telego.Update) error {
+		if b.user(ctx) != nil {
+			return ctx.Next(update)
+		}
+
+		from, _ := updateSender(update)
+		if update.CallbackQuery != nil {
+			_ = b.bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(update.CallbackQuery.ID))
+		}
+
+		_, err := b.bot.SendMessage(ctx, tu.Message(tu.ID(from.ID), loginUsage))
+		return err
+	}
+}
+
+// updateSender определяет отправителя из возможных источников.
+func updateSender(update telego.Update) (telego.User, bool) {
+	switch {
+	case update.Message != nil && update.Message.From != nil:
+		return *update.Message.From, true
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From, true
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From, true
+	default:
+		return telego.User{}, false
+	}
+}
@@ -29,7 +29,7 @@ func (b *Bot) HandleForwardedMessage(ctx *th.Context, message telego.Message) er
 	case *telego.MessageOriginHiddenUser:
 	}
 
-	errF := b.coreService.ChangeFavorites(ctx, forwardID)
+	errF := b.user(ctx).base.CoreService.ChangeFavorites(ctx, forwardID)
 	if errF != nil {
 		return fmt.Errorf("add chat to favorites error: %w", errF)
 	}
@@ -0,0 +1,61 @@
+package tgbot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/arslanovdi/Gist/core/internal/adapters/in/tgbot/router"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+)
+
+// HandleQuestion ставит в очередь ответ на текстовый вопрос пользователя по открытому в боте чату.
+// Поиск и ответ LLM выполняются фоновой задачей, обработчик обновлений не блокируется.
+func (b *Bot) HandleQuestion(ctx *th.Context, message telego.Message) error {
+	base := b.user(ctx).base
+	chatID := base.Session.OpenChatID()
+	slog.With("func", "tgbot.HandleQuestion").Debug("question about chat", slog.Int64("chat_id", chatID))
+
+	if errQ := base.StartQuestion(ctx, chatID, message.Text); errQ != nil {
+		return fmt.Errorf("start question job error: %w", errQ)
+	}
+	return nil
+}
+
+// chatOpened предикат: в боте открыто описание чата, текстовое сообщение - вопрос по нему. Пересланные сообщения обрабатываются отдельно.
+// Другие экраны чата (выбор периода, выбор модели) вопросы не принимают.
+func (b *Bot) chatOpened() th.Predicate {
+	return func(ctx context.Context, update telego.Update) bool {
+		s := b.user(ctx)
+		if s == nil || update.Message == nil || update.Message.ForwardOrigin != nil {
+			return false
+		}
+		screen := s.base.Session.OpenScreen()
+		return screen.Menu == router.MenuChat && screen.ChatID != 0
+	}
+}
+
+// HandleRangeInput пересказ сообщений открытого чата по номерам, отправленным в меню выбора периода.
+func (b *Bot) HandleRangeInput(ctx *th.Context, message telego.Message) error {
+	base := b.user(ctx).base
+	chatID := base.Session.OpenChatID()
+
+	rng, _ := router.ParseMessageRange(message.Text)
+	slog.With("func", "tgbot.HandleRangeInput").Debug("range input", slog.Int64("chat_id", chatID), slog.Any("range", rng))
+
+	return base.StartRangeGist(ctx, chatID, rng)
+}
+
+// rangeInput предикат: открыто меню выбора периода, текстовое сообщение - диапазон номеров сообщений.
+func (b *Bot) rangeInput() th.Predicate {
+	return func(ctx context.Context, update telego.Update) bool {
+		s := b.user(ctx)
+		if s == nil || update.Message == nil || update.Message.ForwardOrigin != nil {
+			return false
+		}
+		screen := s.base.Session.OpenScreen()
+		_, ok := router.ParseMessageRange(update.Message.Text)
+		return screen.Menu == router.MenuRange && screen.ChatID != 0 && ok
+	}
+}
@@ -0,0 +1,48 @@
+package tgbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arslanovdi/Gist/core/internal/adapters/in/tgbot/router"
+	"github.com/mymmrac/telego"
+)
+
+func TestChatOpened(t *testing.T) {
+	text := &telego.Message{Text: "что решили по релизу?"}
+	forwarded := &telego.Message{Text: "что решили по релизу?", ForwardOrigin: &telego.MessageOriginUser{}}
+
+	tests := []struct {
+		name    string
+		screen  *router.Screen // nil - пользователь без сессии
+		message *telego.Message
+		want    bool
+	}{
+		{name: "chat detail", screen: &router.Screen{Menu: router.MenuChat, ChatID: 5}, message: text, want: true},
+		{name: "range menu", screen: &router.Screen{Menu: router.MenuRange, ChatID: 5}, message: text},
+		{name: "models menu of chat", screen: &router.Screen{Menu: router.MenuModels, ChatID: 5}, message: text},
+		{name: "main menu", screen: &router.Screen{Menu: router.MenuMain}, message: text},
+		{name: "chat detail without chat", screen: &router.Screen{Menu: router.MenuChat}, message: text},
+		{name: "forwarded message", screen: &router.Screen{Menu: router.MenuChat, ChatID: 5}, message: forwarded},
+		{name: "no message", screen: &router.Screen{Menu: router.MenuChat, ChatID: 5}},
+		{name: "no user session", message: text},
+	}
+
+	b := &Bot{}
+	predicate := b.chatOpened()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.screen != nil {
+				session := router.NewUISession()
+				session.Show(1, *tt.screen)
+				ctx = context.WithValue(ctx, userSessionKey{}, &userSession{base: &router.BaseHandler{Session: session}})
+			}
+
+			if got := predicate(ctx, telego.Update{Message: tt.message}); got != tt.want {
+				t.Errorf("chatOpened() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
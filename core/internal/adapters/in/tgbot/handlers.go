@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/arslanovdi/Gist/core/internal/adapters/in/tgbot/router"
+	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
 )
 
@@ -14,24 +15,6 @@ func (b *Bot) RegisterHandlers(_ context.Context, serverErr chan error) {
 	log := slog.With("func", "tgbot.RegisterHandlers")
 	log.Info("Register handlers start")
 
-	base := &router.BaseHandler{
-		Bot:         b.bot,
-		CoreService: b.coreService,
-		UserID:      b.allowedUserID,
-	}
-
-	// menu
-	b.router.RegisterHandler(router.NewMainMenuHandler(base))
-	b.router.RegisterHandler(router.NewUnreadMenuHandler(base))
-	b.router.RegisterHandler(router.NewFavoritesMenuHandler(base))
-	b.router.RegisterHandler(router.NewChatMenuHandler(base))
-	b.router.RegisterHandler(router.NewSettingsMenuHandler(base))
-	// actions
-	b.router.RegisterHandler(router.NewAddToFavoritesHandler(base))
-	b.router.RegisterHandler(router.NewTTSHandler(base))
-	b.router.RegisterHandler(router.NewMarkAsReadHandler(base))
-	b.router.RegisterHandler(router.NewGistHandler(base))
-
 	var errH error
 	b.bh, errH = th.NewBotHandler(b.bot, b.updates)
 	if errH != nil {
@@ -44,17 +27,28 @@ func (b *Bot) RegisterHandlers(_ context.Context, serverErr chan error) {
 
 	// middlewares
 	b.bh.Use(th.PanicRecovery())           // Обертка PanicRecovery, вызывается первым.
-	b.bh.Use(b.authorizedOnlyMiddleware()) // Обертка отсеивает запросы всех пользователей, кроме ID указанного в env.
+	b.bh.Use(b.authorizedOnlyMiddleware()) // Обертка отсеивает запросы всех пользователей, кроме указанных в конфигурации.
+
+	// подключение аккаунта Telegram, доступно без подключенного аккаунта
+	b.bh.HandleMessage(b.LoginCommand, th.CommandEqual("login"))
+	b.bh.HandleMessage(b.LoginInput, th.AnyMessageWithText(), th.Not(th.AnyCommand()), b.loginPending())
+
+	b.bh.Use(b.linkedOnlyMiddleware()) // Остальные обработчики только для пользователей с подключенным аккаунтом.
 
 	// commands
 	b.bh.Handle(b.StartCommand, th.CommandEqual("start"))
+	b.bh.HandleMessage(b.WatchCommand, th.CommandEqual("watch"))
+	b.bh.HandleMessage(b.UnwatchCommand, th.CommandEqual("unwatch"))
+	b.bh.HandleMessage(b.WatchlistCommand, th.CommandEqual("watchlist"))
 	b.bh.Handle(b.AnyCommand, th.AnyCommand())
 
 	// messages
+	b.bh.HandleMessage(b.HandleRangeInput, th.AnyMessageWithText(), th.Not(th.AnyCommand()), b.rangeInput())
+	b.bh.HandleMessage(b.HandleQuestion, th.AnyMessageWithText(), th.Not(th.AnyCommand()), b.chatOpened())
 	b.bh.HandleMessage(b.HandleForwardedMessage, th.AnyMessage())
 
 	// callback-запросы (инлайн-кнопки), вызываем обработчик роутера
-	b.bh.HandleCallbackQuery(b.router.Handle, th.AnyCallbackQuery())
+	b.bh.HandleCallbackQuery(b.HandleCallbackQuery, th.AnyCallbackQuery())
 
 	go func() {
 		errS := b.bh.Start()
@@ -66,3 +60,36 @@ func (b *Bot) RegisterHandlers(_ context.Context, serverErr chan error) {
 
 	log.Info("Register handlers successfully")
 }
+
+// newUserRouter создает роутер меню пользователя.
+func newUserRouter(base *router.BaseHandler) *router.CallbackRouter {
+	r := router.NewCallbackRouter()
+
+	// menu
+	r.RegisterHandler(router.NewMainMenuHandler(base))
+	r.RegisterHandler(router.NewUnreadMenuHandler(base))
+	r.RegisterHandler(router.NewFavoritesMenuHandler(base))
+	r.RegisterHandler(router.NewChatMenuHandler(base))
+	r.RegisterHandler(router.NewSettingsMenuHandler(base))
+	r.RegisterHandler(router.NewJobsMenuHandler(base))
+	r.RegisterHandler(router.NewRangeMenuHandler(base))
+	r.RegisterHandler(router.NewMentionsMenuHandler(base))
+	r.RegisterHandler(router.NewModelsMenuHandler(base))
+	r.RegisterHandler(router.NewUsageMenuHandler(base))
+	r.RegisterHandler(router.NewHiddenMenuHandler(base))
+	// actions
+	r.RegisterHandler(router.NewAddToFavoritesHandler(base))
+	r.RegisterHandler(router.NewTTSHandler(base))
+	r.RegisterHandler(router.NewMarkAsReadHandler(base))
+	r.RegisterHandler(router.NewGistHandler(base))
+	r.RegisterHandler(router.NewExportHandler(base))
+	r.RegisterHandler(router.NewBriefingHandler(base))
+	r.RegisterHandler(router.NewToggleHiddenHandler(base))
+
+	return r
+}
+
+// HandleCallbackQuery передает колбэк в роутер меню пользователя.
+func (b *Bot) HandleCallbackQuery(ctx *th.Context, query telego.CallbackQuery) error {
+	return b.user(ctx).router.Handle(ctx, query)
+}
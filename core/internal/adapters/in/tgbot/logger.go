@@ -0,0 +1,27 @@
+package tgbot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// botLogger логгер telego поверх slog. Запросы к Bot API логируются на уровне debug,
+// токен бота и секрет вебхука маскируются в логе, см. logger.AddSecrets.
+type botLogger struct {
+	log *slog.Logger
+}
+
+func newBotLogger() botLogger {
+	return botLogger{log: slog.With("func", "telego")}
+}
+
+// Debugf реализация интерфейса telego.Logger
+func (l botLogger) Debugf(format string, args ...any) {
+	l.log.Debug(strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+// Errorf реализация интерфейса telego.Logger
+func (l botLogger) Errorf(format string, args ...any) {
+	l.log.Error(strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
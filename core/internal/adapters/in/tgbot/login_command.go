@@ -0,0 +1,164 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const loginTimeout = 5 * time.Minute // Время на ввод кода подтверждения и пароля
+
+const loginUsage = `🔗 Аккаунт Telegram не подключен.
+Подключите его командой /login +79991234567 — номер телефона вашего аккаунта.
+Бот будет читать ваши чаты от вашего имени, чтобы делать пересказы.`
+
+// login подключение аккаунта Telegram пользователя бота. Реализация model.Authenticator:
+// код подтверждения и пароль пользователь отправляет сообщениями боту.
+type login struct {
+	bot    *telego.Bot
+	userID int64
+	input  chan telego.Message
+}
+
+// LoginCommand обрабатывает команду /login - подключение аккаунта Telegram пользователя бота.
+func (b *Bot) LoginCommand(ctx *th.Context, message telego.Message) error {
+	_, _, args := tu.ParseCommand(message.Text)
+	if len(args) != 1 {
+		return b.reply(ctx, message, loginUsage)
+	}
+
+	l := &login{
+		bot:    b.bot,
+		userID: message.From.ID,
+		input:  make(chan telego.Message, 1),
+	}
+
+	b.loginsMu.Lock()
+	if _, ok := b.logins[l.userID]; ok {
+		b.loginsMu.Unlock()
+		return b.reply(ctx, message, "⏳ Подключение уже выполняется, отправьте код подтверждения")
+	}
+	b.logins[l.userID] = l
+	b.loginsMu.Unlock()
+
+	// Подключение ждет ввода кода следующими сообщениями, поэтому выполняется вне обработчика
+	ctxLogin, cancel := context.WithTimeout(context.WithoutCancel(ctx), loginTimeout)
+	go func() {
+		defer cancel()
+		defer func() {
+			b.loginsMu.Lock()
+			delete(b.logins, l.userID)
+			b.loginsMu.Unlock()
+		}()
+
+		text := "✅ Аккаунт подключен, откройте меню командой /start"
+		if errL := b.users.Login(ctxLogin, l.userID, args[0], l); errL != nil {
+			slog.With("func", "tgbot.LoginCommand").Error("login error", slog.Int64("user_id", l.userID), slog.Any("error", errL))
+			text = loginError(errL)
+		}
+
+		if _, errS := b.bot.SendMessage(ctxLogin, tu.Message(tu.ID(l.userID), text)); errS != nil {
+			slog.With("func", "tgbot.LoginCommand").Error("send login result error", slog.Any("error", errS))
+		}
+	}()
+
+	return nil
+}
+
+// LoginInput передает сообщение пользователя в незавершенное подключение аккаунта.
+func (b *Bot) LoginInput(_ *th.Context, message telego.Message) error {
+	b.loginsMu.Lock()
+	l, ok := b.logins[message.From.ID]
+	b.loginsMu.Unlock()
+
+	if ok {
+		select {
+		case l.input <- message:
+		default: // Предыдущее сообщение еще не обработано
+		}
+	}
+
+	return nil
+}
+
+// loginPending предикат: для отправителя выполняется подключение аккаунта, сообщение - код подтверждения или пароль.
+func (b *Bot) loginPending() th.Predicate {
+	return func(_ context.Context, update telego.Update) bool {
+		if update.Message == nil || update.Message.From == nil {
+			return false
+		}
+
+		b.loginsMu.Lock()
+		defer b.loginsMu.Unlock()
+
+		_, ok := b.logins[update.Message.From.ID]
+		return ok
+	}
+}
+
+// Code запрашивает у пользователя код подтверждения.
+func (l *login) Code(ctx context.Context) (string, error) {
+	// Telegram отменяет код, если его переслать в сообщении в неизменном виде
+	msg, errW := l.ask(ctx, "📨 Telegram отправил код подтверждения. Отправьте его, разделив цифры пробелами, например: 1 2 3 4 5")
+	if errW != nil {
+		return "", errW
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, msg.Text), nil
+}
+
+// Password запрашивает у пользователя пароль двухэтапной аутентификации. Сообщение с паролем удаляется.
+func (l *login) Password(ctx context.Context) (string, error) {
+	msg, errW := l.ask(ctx, "🔐 Отправьте пароль двухэтапной аутентификации. Сообщение с паролем будет удалено.")
+	if errW != nil {
+		return "", errW
+	}
+
+	if errD := l.bot.DeleteMessage(ctx, tu.Delete(tu.ID(l.userID), msg.MessageID)); errD != nil {
+		slog.With("func", "tgbot.login.Password").Error("delete password message error", slog.Any("error", errD))
+	}
+
+	return strings.TrimSpace(msg.Text), nil
+}
+
+// ask отправляет пользователю запрос и ждет ответное сообщение.
+func (l *login) ask(ctx context.Context, text string) (telego.Message, error) {
+	if _, errS := l.bot.SendMessage(ctx, tu.Message(tu.ID(l.userID), text)); errS != nil {
+		return telego.Message{}, fmt.Errorf("send login prompt error: %w", errS)
+	}
+
+	select {
+	case msg := <-l.input:
+		return msg, nil
+	case <-ctx.Done():
+		return telego.Message{}, ctx.Err()
+	}
+}
+
+// loginError описание ошибки подключения для пользователя.
+func loginError(err error) string {
+	switch {
+	case errors.Is(err, model.ErrUserNotAllowed):
+		return "⚠️ Этот аккаунт подключается в консоли сервера"
+	case errors.Is(err, model.ErrLoginInProgress):
+		return "⏳ Подключение уже выполняется"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "⌛ Время подключения истекло, повторите команду /login"
+	default:
+		return fmt.Sprintf("❌ Не удалось подключить аккаунт: %v\nПовторите команду /login", err)
+	}
+}
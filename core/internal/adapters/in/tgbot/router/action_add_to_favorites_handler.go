@@ -43,5 +43,5 @@ func (h *AddToFavoritesHandler) Handle(ctx *th.Context, query telego.CallbackQue
 		log.Error("GetChatDetail", slog.Any("error", errD))
 	}
 
-	return h.showChatDetail(ctx, chatDetail, payload.Src, payload.Page)
+	return h.showChatDetail(ctx, chatDetail, payload.Src, payload.Page, int(payload.Part))
 }
@@ -0,0 +1,204 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const (
+	maxBriefingSnippet = 400 // Максимальная длина абзаца чата в сводке, символов
+	minBriefingSnippet = 80  // Абзац не короче, даже если чатов в сводке много
+)
+
+// BriefingHandler сводка по всем непрочитанным чатам и отметка чатов сводки прочитанными.
+type BriefingHandler struct {
+	*BaseHandler
+}
+
+// NewBriefingHandler конструктор обработчика кнопок сводки по непрочитанным чатам.
+func NewBriefingHandler(base *BaseHandler) *BriefingHandler {
+	return &BriefingHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *BriefingHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Action == ActionBriefing || payload.Action == ActionBriefingRead
+}
+
+// Handle Реализация интерфейса CallbackHandler
+// Сводка генерируется фоновой задачей, о завершении бот оповещает сообщением со сводкой.
+func (h *BriefingHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
+	log := slog.With("func", "router.BriefingHandler")
+	log.Debug("handling briefing callback")
+
+	if payload.Action == ActionBriefingRead {
+		return h.markAsRead(ctx, query, int64(payload.Item))
+	}
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	return h.startJob(ctx, func(callback func(job model.Job)) (model.Job, error) {
+		return h.CoreService.StartBriefingJob(ctx, callback)
+	})
+}
+
+// markAsRead отмечает прочитанными все чаты сводки и убирает кнопку "Прочитать все" из сообщения со сводкой.
+func (h *BriefingHandler) markAsRead(ctx *th.Context, query telego.CallbackQuery, jobID int64) error {
+	log := slog.With("func", "router.BriefingHandler.markAsRead")
+
+	marked, errM := h.CoreService.MarkBriefingAsRead(ctx, jobID)
+	if errors.Is(errM, model.ErrBriefingNotFound) {
+		_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID).WithText("⚠️ Сводка устарела, сформируйте новую").WithShowAlert())
+		return nil
+	}
+
+	text := fmt.Sprintf("✅ Отмечено прочитанными чатов: %d", marked)
+	if errM != nil {
+		log.Error("mark briefing as read error", slog.Any("error", errM))
+		text += "\n❌ Часть чатов не удалось отметить, подробности в логе"
+	}
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID).WithText(text).WithShowAlert())
+
+	msg, ok := query.Message.(*telego.Message)
+	if !ok || msg.ReplyMarkup == nil {
+		return nil
+	}
+
+	rows := make([][]telego.InlineKeyboardButton, 0, len(msg.ReplyMarkup.InlineKeyboard))
+	for _, row := range msg.ReplyMarkup.InlineKeyboard {
+		buttons := make([]telego.InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			if button.CallbackData != query.Data {
+				buttons = append(buttons, button)
+			}
+		}
+		if len(buttons) > 0 {
+			rows = append(rows, buttons)
+		}
+	}
+
+	_, errE := h.Bot.EditMessageReplyMarkup(ctx, tu.EditMessageReplyMarkup(tu.ID(h.UserID), msg.MessageID, tu.InlineKeyboard(rows...)))
+	if errE != nil && !isMessageNotModified(errE) {
+		log.Error("edit briefing keyboard error", slog.Any("error", errE))
+	}
+	return nil
+}
+
+// sendBriefing отправляет сводку одним сообщением: по абзацу на чат, кнопки перехода к полному пересказу каждого чата
+// и кнопка "Прочитать все". silent - без звука.
+func (b *BaseHandler) sendBriefing(ctx context.Context, job model.Job, silent bool) error {
+	briefing := job.Briefing
+	if briefing == nil || len(briefing.Chats) == 0 {
+		message := tu.Message(tu.ID(b.UserID), "📰 Непрочитанных чатов выше порога нет")
+		message.DisableNotification = silent
+		_, errS := b.Bot.SendMessage(ctx, message)
+		return errS
+	}
+
+	// Абзацы сокращаются, пока сводка не поместится в одно сообщение
+	limit := min(maxBriefingSnippet, maxMessageLength/len(briefing.Chats))
+	text := formatBriefing(briefing, limit)
+	for utf16Len(text) > maxMessageLength && limit > minBriefingSnippet {
+		limit = max(limit*3/4, minBriefingSnippet)
+		text = formatBriefing(briefing, limit)
+	}
+
+	message := tu.Message(tu.ID(b.UserID), text).
+		WithParseMode(telego.ModeHTML).
+		WithReplyMarkup(buildBriefingMenu(b.UserID, job))
+	message.DisableNotification = silent
+
+	_, errS := b.Bot.SendMessage(ctx, message)
+	if errS != nil {
+		return fmt.Errorf("router.sendBriefing: %w", errS)
+	}
+	return nil
+}
+
+// formatBriefing текст сводки в Telegram HTML, абзац каждого чата не длиннее limit символов.
+func formatBriefing(briefing *model.Briefing, limit int) string {
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "📰 <b>Сводка: %d чатов</b>", len(briefing.Chats))
+	if briefing.Skipped > 0 {
+		fmt.Fprintf(&text, "\nЕще %d чатов в списке \"📬 Непрочитанные чаты\"", briefing.Skipped)
+	}
+
+	for i, chat := range briefing.Chats {
+		fmt.Fprintf(&text, "\n\n<b>%d. %s</b> (💬 %d)\n", i+1, html.EscapeString(chat.Title), chat.UnreadCount)
+		if errors.Is(chat.Err, model.ErrCustomRangeGist) {
+			text.WriteString("📅 Открыт пересказ за выбранный период, сводка его не сбрасывает")
+			continue
+		}
+		if errors.Is(chat.Err, model.ErrJobAlreadyRunning) {
+			text.WriteString("⏳ Пересказ чата уже выполняется")
+			continue
+		}
+		if chat.Err != nil {
+			text.WriteString("❌ Не удалось пересказать: " + html.EscapeString(chat.Err.Error()))
+			continue
+		}
+		text.WriteString(html.EscapeString(briefingSnippet(chat.Summary, limit)))
+	}
+
+	return text.String()
+}
+
+// briefingSnippet абзац сводки без разметки, не длиннее limit символов, с обрезкой по слову.
+// Модель пишет абзац нужной длины, обрезка нужна, только если она превысила лимит или чатов в сводке много.
+func briefingSnippet(gist string, limit int) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(gist, "\n") {
+		line = strings.TrimSpace(line)
+		line = headerPrefix.ReplaceAllString(line, "")
+		line = listMarker.ReplaceAllString(line, "")
+		line = strings.ReplaceAll(line, "**", "")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	snippet := strings.Join(lines, " ")
+	if utf8.RuneCountInString(snippet) <= limit {
+		return snippet
+	}
+
+	cut := string([]rune(snippet)[:limit])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:—-") + "…"
+}
+
+// Меню сводки: переход к полному пересказу каждого чата, отметка всех чатов прочитанными.
+func buildBriefingMenu(userID int64, job model.Job) *telego.InlineKeyboardMarkup {
+	var rows [][]telego.InlineKeyboardButton
+
+	for i, chat := range job.Briefing.Chats {
+		if chat.Err != nil || chat.Pages == 0 {
+			continue
+		}
+		cb := callbackData(userID, CallbackPayload{Menu: MenuChat, ChatID: chat.ChatID, Src: MenuUnread, Page: 1})
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(fmt.Sprintf("📩 %d. %s", i+1, chat.Title)).WithCallbackData(cb),
+		))
+	}
+
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("Домой").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMain})),
+		tu.InlineKeyboardButton("✅ Прочитать все").WithCallbackData(callbackData(userID, CallbackPayload{Action: ActionBriefingRead, Item: int(job.ID)})),
+	))
+
+	return tu.InlineKeyboard(rows...)
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/export"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// ExportHandler выгрузить краткий пересказ чата в документ Markdown или HTML.
+type ExportHandler struct {
+	*BaseHandler
+}
+
+// NewExportHandler конструктор обработчика экспорта пересказа.
+func NewExportHandler(base *BaseHandler) *ExportHandler {
+	return &ExportHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *ExportHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Action == ActionExport
+}
+
+// Handle Реализация интерфейса CallbackHandler
+// Все страницы пересказа отправляются одним документом, сообщение с меню чата не меняется.
+func (h *ExportHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
+	log := slog.With("func", "router.ExportHandler")
+	log.Debug("handling export callback")
+
+	chat, errC := h.CoreService.GetChatDetail(ctx, payload.ChatID)
+	if errC != nil {
+		_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+		return fmt.Errorf("ExportHandler: %w", errC)
+	}
+
+	if len(chat.Gist) == 0 {
+		return h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID).WithText("Пересказ еще не сделан"))
+	}
+
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	format := export.Format(payload.Format)
+	data, errR := export.Render(chat, format)
+	if errR != nil {
+		return fmt.Errorf("ExportHandler: %w", errR)
+	}
+
+	_, errS := h.Bot.SendDocument(ctx, tu.Document(
+		tu.ID(h.UserID),
+		tu.FileFromReader(bytes.NewReader(data), export.FileName(chat, format)),
+	).WithCaption(fmt.Sprintf("📄 %s\n%s", chat.Title, chat.Range.String())))
+	if errS != nil {
+		log.Error("send document error", slog.Any("error", errS))
+		return fmt.Errorf("ExportHandler: %w", errS)
+	}
+
+	return nil
+}
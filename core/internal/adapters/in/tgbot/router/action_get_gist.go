@@ -1,9 +1,8 @@
 package router
 
 import (
-	"fmt"
+	"context"
 	"log/slog"
-	"strings"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/mymmrac/telego"
@@ -27,35 +26,22 @@ func (h *GistHandler) CanHandle(payload *CallbackPayload) bool {
 }
 
 // Handle Реализация интерфейса CallbackHandler
+// Генерация выполняется фоновой задачей, о завершении бот оповещает отдельным сообщением.
 func (h *GistHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
 	log := slog.With("func", "GistHandler")
 	log.Debug("handling get gist callback")
 
-	processing := func(message string, part int, llm bool) { // callback функция для оповещения о прогрессе выполнения.
-		if llm {
-			bar := strings.Repeat("█", part/10) + strings.Repeat("░", 10-part/10)
-			_ = h.editMessage(ctx,
-				fmt.Sprintf("%s\n\n [%s] %d%%", message, bar, part))
-		} else {
-			_ = h.editMessage(ctx,
-				fmt.Sprintf("%s\n\n %d сообщений загружено", message, part))
-		}
-	}
-
 	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
 	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID)) //.WithText("⏳ Генерируем пересказ..."))
 
-	_, errG := h.CoreService.GetChatGist(ctx, payload.ChatID, processing) // Получаем краткий пересказ, сохраняем его в кэш.
-	if errG != nil {
-		log.Error("GetChatGist", slog.Any("error", errG))
-	}
-
-	chatDetail, errD := h.CoreService.GetChatDetail(ctx, payload.ChatID) // Получаем информацию о чате из кэша
-	if errD != nil {
-		chatDetail = &model.Chat{}
-		log.Error("GetChatDetail", slog.Any("error", errD))
-	}
-
-	return h.showChatDetail(ctx, chatDetail, payload.Src, 1) // gistPage после генерации = 1
+	return h.startJob(ctx, func(callback func(job model.Job)) (model.Job, error) {
+		opts := h.gistOptions(ctx, rangePreset(payload.Range))
+		return h.CoreService.StartGistJob(ctx, payload.ChatID, opts, callback) // Краткий пересказ сохраняется в кэш.
+	})
+}
 
+// gistOptions параметры пересказа диапазона rng с учетом настроек пользователя.
+func (b *BaseHandler) gistOptions(ctx context.Context, rng model.MessageRange) model.GistOptions {
+	settings := b.CoreService.GetSettings(ctx)
+	return model.GistOptions{Range: rng, MentionsBlock: !settings.SkipMentionsBlock}
 }
@@ -42,5 +42,5 @@ func (h *MarkAsReadHandler) Handle(ctx *th.Context, query telego.CallbackQuery,
 		page = 0
 	}
 
-	return h.showChatDetail(ctx, chatDetail, payload.Src, page)
+	return h.showChatDetail(ctx, chatDetail, payload.Src, page, 0)
 }
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"log/slog"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// ToggleHiddenHandler обработчик скрытия чата и возврата скрытого чата в списки.
+type ToggleHiddenHandler struct {
+	*BaseHandler
+}
+
+// NewToggleHiddenHandler конструктор обработчика скрытия чата.
+func NewToggleHiddenHandler(base *BaseHandler) *ToggleHiddenHandler {
+	return &ToggleHiddenHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *ToggleHiddenHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Action == ActionToggleHidden
+}
+
+// Handle Реализация интерфейса CallbackHandler
+func (h *ToggleHiddenHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
+	log := slog.With("func", "router.ToggleHiddenHandler")
+	log.Debug("handling toggle hidden callback")
+
+	hide := payload.Add != nil && *payload.Add
+
+	var errT error
+	text := "🙈 Чат скрыт: он не попадет в непрочитанные, сводку и упоминания"
+	if hide {
+		errT = h.CoreService.HideChat(ctx, payload.ChatID)
+	} else {
+		errT = h.CoreService.UnhideChat(ctx, payload.ChatID)
+		text = "👁 Чат снова показывается в списках"
+	}
+	if errT != nil {
+		log.Error("toggle hidden error", slog.Bool("hide", hide), slog.Any("error", errT))
+		text = "❌ Не удалось изменить список скрытых чатов"
+	}
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID).WithText(text))
+
+	chatDetail, errD := h.CoreService.GetChatDetail(ctx, payload.ChatID)
+	if errD != nil {
+		chatDetail = &model.Chat{}
+		log.Error("GetChatDetail", slog.Any("error", errD))
+	}
+
+	return h.showChatDetail(ctx, chatDetail, payload.Src, payload.Page, int(payload.Part))
+}
@@ -1,10 +1,9 @@
 package router
 
 import (
-	"fmt"
 	"log/slog"
-	"os"
 
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
 	tu "github.com/mymmrac/telego/telegoutil"
@@ -26,6 +25,7 @@ func (h *TTSHandler) CanHandle(payload *CallbackPayload) bool {
 }
 
 // Handle Реализация интерфейса CallbackHandler
+// Генерация выполняется фоновой задачей, по завершении бот отправляет голосовые сообщения.
 func (h *TTSHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
 	log := slog.With("func", "TTSHandler")
 	log.Debug("handling TTS callback")
@@ -33,40 +33,7 @@ func (h *TTSHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload
 	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
 	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
 
-	audioGist, errA := h.CoreService.GetAudioGist(ctx, payload.ChatID, payload.Page) // получаем имя файла с нужным аудиопересказом
-	if errA != nil {
-		return fmt.Errorf("tgbot.router.TTSHandler Ошибка получения аудиофайла: %w", errA)
-	}
-
-	for i := range audioGist { // Отправляем файлы, по очереди
-		audioFile, errO := os.Open(audioGist[i].AudioFile)
-		if errO != nil {
-			return fmt.Errorf("open audio file error: %w", errO)
-		}
-		defer func() {
-			errC := audioFile.Close()
-			if errC != nil {
-				log.Error("audio file close error:", errC)
-			}
-		}()
-
-		// Отправляем аудио
-		_, errS := h.Bot.SendVoice(ctx, tu.Voice( // Отправка голосового сообщения.
-			tu.ID(h.UserID),
-			tu.File(audioFile),
-		).WithCaption(audioGist[i].Caption))
-		if errS != nil {
-			return errS
-		}
-	}
-
-	/*_, errS := h.Bot.SendAudio(ctx, tu.Audio(	// отправка mp3 файла
-		tu.ID(h.UserID),
-		tu.File(audioFile),
-	))
-	if errS != nil {
-		return errS
-	}*/
-
-	return nil
+	return h.startJob(ctx, func(callback func(job model.Job)) (model.Job, error) {
+		return h.CoreService.StartAudioJob(ctx, payload.ChatID, payload.Page, callback) // получаем файлы с нужным аудиопересказом
+	})
 }
@@ -3,10 +3,12 @@ package router
 import (
 	"context"
 	"fmt"
+	"html"
 	"log/slog"
-	"strconv"
+	"sync"
 	"time"
 
+	"github.com/arslanovdi/Gist/core/internal/domain/export"
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/arslanovdi/Gist/core/internal/infra/utils"
 	"github.com/mymmrac/telego"
@@ -14,8 +16,11 @@ import (
 )
 
 const (
-	chatsPerPage  = 8    // Количество чатов выводимых пользователю за раз (пагинация)
-	maxGistLength = 3900 // Телеграм ограничивает сообщение длинной в 4096 символа. Обрезаем пересказ батча до значения константы
+	chatsPerPage      = 8  // Количество чатов выводимых пользователю за раз (пагинация)
+	maxPartMarkLength = 32 // Запас длины сообщения под номер части страницы пересказа
+
+	dateTimeLayout = "02.01.2006 15:04 (UTC-07:00)" // Время в часовом поясе пользователя с указанием смещения
+	clockLayout    = "15:04:05 (UTC-07:00)"
 )
 
 // BaseHandler содержит общие зависимости и методы для всех обработчиков
@@ -23,115 +28,175 @@ type BaseHandler struct {
 	Bot         *telego.Bot
 	CoreService CoreService
 
-	LastMessageID int   // Id редактируемого сообщения. В боте всегда одно сообщение, которое мы редактируем.
-	UserID        int64 // Id пользователя = id чата с ним, используется для вывода сообщений ботом.
-}
-
-func (b *BaseHandler) showChatDetail(ctx context.Context, chat *model.Chat, menu Menu, gistPage int) error {
-	log := slog.With("func", "router.showChatDetail")
+	Session *UISession // Экраны в сообщениях бота, кнопка редактирует свое сообщение
+	UserID  int64      // Id пользователя = id чата с ним, используется для вывода сообщений ботом.
 
-	inlineKeyboard := b.buildChatDetailMenu(chat, menu, gistPage)
-
-	text := "" // Текст сообщения. Краткий пересказ выводится только если он сделан.
-	if len(chat.Gist) > 0 {
-		gist := chat.Gist[gistPage-1].Gist
-		// Ограничиваем длину сообщения.
-		if len(gist) > maxGistLength {
-			log.Warn("gist is too long, crop it", slog.Int("length", len(gist))) // TODO исправить логику на работу с unicode
-			gist = gist[:maxGistLength] + "\n\ncropped " + strconv.Itoa(len(gist)-maxGistLength) + "!"
-		}
+	jobsMu      sync.Mutex
+	jobMessages map[int64]int // ID сообщений с ходом выполнения фоновых задач, по ID задачи
+}
 
-		startMessageID := 0 // С какого сообщения начинается батч
-		for i := 0; i < gistPage-1; i++ {
-			startMessageID += chat.Gist[i].MessageCount
-		}
+// location часовой пояс пользователя из настроек.
+func (b *BaseHandler) location(ctx context.Context) *time.Location {
+	settings := b.CoreService.GetSettings(ctx)
+	return settings.Location()
+}
 
-		text = fmt.Sprintf("📩 %s\n🔍 Краткий пересказ %d сообщений (%s) c %s\n\n %s\n",
-			chat.Title,
-			chat.Gist[gistPage-1].MessageCount,
-			utils.FormatDurationShort(chat.Gist[gistPage-1].LastMessageData.Sub(chat.Gist[gistPage-1].FirstMessageData)),
-			utils.FormatDateShort(chat.Gist[gistPage-1].FirstMessageData),
-			gist,
-		)
-	} else {
-		text = fmt.Sprintf("📩 %s\n\n 📌 Непрочитано: %d сообщений",
-			chat.Title,
-			chat.UnreadCount,
-		)
-	}
+// showScreen выводит экран screen: редактирует сообщение, на кнопке которого нажали, иначе отправляет новое сообщение.
+// name - название экрана для логов и ошибок, parseMode - режим разметки текста, пусто - без разметки.
+func (b *BaseHandler) showScreen(ctx context.Context, screen Screen, name, text, parseMode string, keyboard *telego.InlineKeyboardMarkup) error {
+	log := slog.With("func", "router.showScreen", slog.String("screen", name))
 
-	if b.LastMessageID != 0 {
+	messageID := b.Session.Target(ctx)
+	if messageID != 0 {
 		// Пытаемся отредактировать
 		message := tu.EditMessageText(
 			tu.ID(b.UserID),
-			b.LastMessageID,
+			messageID,
 			text,
-		).WithReplyMarkup(inlineKeyboard)
+		).WithParseMode(parseMode).WithReplyMarkup(keyboard)
 
 		_, errE := b.Bot.EditMessageText(ctx, message)
-		if errE == nil {
+		if errE == nil || isMessageNotModified(errE) {
+			b.Session.Show(messageID, screen)
 			return nil // Успешно отредактировали
 		}
-		log.Error("edit message with chat detail menu error", slog.Any("error", errE))
+		log.Error("edit message error", slog.Any("error", errE))
 		// Иначе — отправим новое
+		b.Session.Forget(messageID)
 	}
 
 	// Отправляем новое
 	message := tu.Message(
 		tu.ID(b.UserID),
 		text,
-	).WithReplyMarkup(inlineKeyboard)
+	).WithParseMode(parseMode).WithReplyMarkup(keyboard)
 
 	msg, errS := b.Bot.SendMessage(ctx, message)
 	if errS != nil {
-		log.Error("send message with chat detail menu error", slog.Any("error", errS))
-		return fmt.Errorf("send message with chat detail menu error: %w", errS)
+		log.Error("send message error", slog.Any("error", errS))
+		return fmt.Errorf("send message with %s error: %w", name, errS)
 	}
 
-	b.LastMessageID = msg.MessageID // Сохраняем номер сообщения
+	b.Session.Show(msg.MessageID, screen) // Запоминаем экран нового сообщения
 	return nil
 }
 
+// showChatDetail выводит меню чата и страницу краткого пересказа gistPage, нумерация с 1.
+// Страница, не помещающаяся в сообщение, выводится частями, part - номер части с 0, -1 - последняя часть.
+func (b *BaseHandler) showChatDetail(ctx context.Context, chat *model.Chat, menu Menu, gistPage, part int) error {
+	log := slog.With("func", "router.showChatDetail")
+
+	screen := Screen{Menu: MenuChat, ChatID: chat.ID}
+	loc := b.location(ctx)
+
+	text := "" // Текст сообщения в Telegram HTML. Краткий пересказ выводится только если он сделан.
+	parts := 0 // Количество частей страницы пересказа
+	if len(chat.Gist) > 0 {
+		batch := chat.Gist[gistPage-1]
+
+		period := "" // Пересказ построен не по непрочитанным сообщениям
+		if !chat.Range.IsUnread() {
+			period = "\n📅 Период: " + html.EscapeString(chat.Range.Format(loc))
+		}
+
+		partial := "" // Генерация пересказа прервана или еще выполняется
+		if chat.GistPartial {
+			partial = fmt.Sprintf("\n⏸ Пересказ неполный: готово %d батчей", len(chat.Gist))
+		}
+
+		cost := "" // Расход LLM на пересказ батча, у пересказов до учета расхода его нет
+		if !batch.Usage.IsZero() {
+			cost = "\n💰 " + formatUsage(batch.Usage)
+		}
+
+		header := fmt.Sprintf("📩 <b>%s</b>\n🔍 Краткий пересказ %d сообщений (%s) c %s%s%s%s\n\n",
+			html.EscapeString(chat.Title),
+			batch.MessageCount,
+			utils.FormatDurationShort(batch.LastMessageData.Sub(batch.FirstMessageData)),
+			utils.FormatDateShort(batch.FirstMessageData.In(loc)),
+			period,
+			cost,
+			partial,
+		)
+
+		aboutMe := "" // Блок "Касается вас" выводится в начале пересказа
+		if gistPage == 1 {
+			aboutMe = html.EscapeString(formatAboutMe(chat.AboutMe, loc))
+		}
+
+		limit := maxMessageLength - utf16Len(header) - maxPartMarkLength
+		gistParts := splitGist(batch.Gist, limit-utf16Len(aboutMe), limit)
+		parts = len(gistParts)
+		if part < 0 || part >= parts {
+			part = parts - 1
+		}
+		if part > 0 {
+			aboutMe = ""
+		}
+
+		partMark := "" // Номер части страницы
+		if parts > 1 {
+			partMark = fmt.Sprintf("📄 Часть %d/%d\n", part+1, parts)
+			log.Debug("gist page split into parts", slog.Int("page", gistPage), slog.Int("parts", parts))
+		}
+
+		text = header + partMark + aboutMe + gistParts[part]
+	} else {
+		mentions := ""
+		if chat.UnreadMentions > 0 || chat.UnreadReactions > 0 {
+			mentions = fmt.Sprintf("\n 📣 Упоминаний и ответов вам: %d, ❤️ реакций: %d", chat.UnreadMentions, chat.UnreadReactions)
+		}
+
+		text = fmt.Sprintf("📩 <b>%s</b>\n\n 📌 Непрочитано: %d сообщений%s\n\n💬 Задайте вопрос по сообщениям чата, отправив его текстом",
+			html.EscapeString(chat.Title),
+			chat.UnreadCount,
+			mentions,
+		)
+	}
+
+	inlineKeyboard := b.buildChatDetailMenu(chat, menu, gistPage, part, parts)
+
+	return b.showScreen(ctx, screen, "chat detail menu", text, telego.ModeHTML, inlineKeyboard)
+}
+
 // Создание меню для выбранного чата.
-// gistPage нумерация с 1.
-func (b *BaseHandler) buildChatDetailMenu(chat *model.Chat, menu Menu, gistPage int) *telego.InlineKeyboardMarkup {
+// gistPage нумерация с 1, part - номер части страницы с 0, parts - количество частей страницы.
+func (b *BaseHandler) buildChatDetailMenu(chat *model.Chat, menu Menu, gistPage, part, parts int) *telego.InlineKeyboardMarkup {
 	var rows [][]telego.InlineKeyboardButton
 
-	// Кнопки Назад, Далее для перелистывания страниц с кратким пересказом.
-	// Кнопка Назад, активна когда gistPage > 1.
-	// Кнопка Вперед активна когда gistPage < len(chat.Gist) // меньше количества страниц кратких пересказов.
-	if len(chat.Gist) > 1 {
-		backwardGistCb := mustCallback(CallbackPayload{
+	// Кнопки Назад, Далее для перелистывания страниц с кратким пересказом и частей страницы.
+	// Назад с первой части страницы ведет на последнюю часть предыдущей страницы (Part: -1).
+	var navButtons []telego.InlineKeyboardButton
+	if part > 0 || gistPage > 1 {
+		backward := CallbackPayload{
 			ChatID: chat.ID,
 			Menu:   MenuChat, // По этому параметру будет выбран обработчик кнопки.
 			Src:    menu,     // Меню, из которого вызвано описание чата. Нужна для корректной отработки кнопки "Назад к чатам"
-			Page:   gistPage - 1})
-
-		forwardGistCb := mustCallback(CallbackPayload{
+			Page:   gistPage,
+			Part:   int8(part - 1)}
+		if part == 0 {
+			backward.Page = gistPage - 1
+		}
+		navButtons = append(navButtons, tu.InlineKeyboardButton("← Назад").WithCallbackData(callbackData(b.UserID, backward)))
+	}
+	if part < parts-1 || gistPage < len(chat.Gist) {
+		forward := CallbackPayload{
 			ChatID: chat.ID,
 			Menu:   MenuChat,
 			Src:    menu,
-			Page:   gistPage + 1})
-
-		switch gistPage {
-		case 1: // Есть только кнопка Вперед.
-			rows = append(rows, tu.InlineKeyboardRow(
-				tu.InlineKeyboardButton("→ Вперед").WithCallbackData(forwardGistCb),
-			))
-		case len(chat.Gist): // Есть только кнопка Назад
-			rows = append(rows, tu.InlineKeyboardRow(
-				tu.InlineKeyboardButton("← Назад").WithCallbackData(backwardGistCb),
-			))
-		default: // Есть обе кнопки
-			rows = append(rows, tu.InlineKeyboardRow(
-				tu.InlineKeyboardButton("← Назад").WithCallbackData(backwardGistCb),
-				tu.InlineKeyboardButton("→ Вперед").WithCallbackData(forwardGistCb),
-			))
+			Page:   gistPage,
+			Part:   int8(part + 1)}
+		if part >= parts-1 {
+			forward.Page, forward.Part = gistPage+1, 0
 		}
+		navButtons = append(navButtons, tu.InlineKeyboardButton("→ Вперед").WithCallbackData(callbackData(b.UserID, forward)))
+	}
+	if len(navButtons) > 0 {
+		rows = append(rows, navButtons)
 	}
 
 	// Кнопка Пометить прочитанным
-	markReadCb := mustCallback(CallbackPayload{
+	markReadCb := callbackData(b.UserID, CallbackPayload{
 		Action: ActionMarkRead,
 		Src:    menu,
 		ChatID: chat.ID,
@@ -141,23 +206,40 @@ func (b *BaseHandler) buildChatDetailMenu(chat *model.Chat, menu Menu, gistPage
 	))
 
 	// Кнопка Сгенерировать пересказ
-	getGistCb := mustCallback(CallbackPayload{
+	getGistCb := callbackData(b.UserID, CallbackPayload{
 		Action: ActionGetGist,
 		ChatID: chat.ID,
 		Src:    menu,
 	})
+	getGistLabel := "✨ Сгенерировать пересказ"
+	if chat.GistPartial && chat.Range.IsUnread() {
+		getGistLabel = "▶️ Продолжить пересказ" // Генерация продолжится с первого несделанного батча
+	}
+	rangeCb := callbackData(b.UserID, CallbackPayload{
+		Menu:   MenuRange,
+		ChatID: chat.ID,
+		Src:    menu,
+	})
+	modelCb := callbackData(b.UserID, CallbackPayload{
+		Menu:   MenuModels,
+		ChatID: chat.ID,
+		Src:    menu,
+	})
 	rows = append(rows, tu.InlineKeyboardRow(
-		tu.InlineKeyboardButton("✨ Сгенерировать пересказ").WithCallbackData(getGistCb),
+		tu.InlineKeyboardButton(getGistLabel).WithCallbackData(getGistCb),
+	), tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("📅 Период").WithCallbackData(rangeCb),
+		tu.InlineKeyboardButton("🧠 Модель").WithCallbackData(modelCb),
 	))
 
 	// Кнопка Озвучить
-	ttsCb := mustCallback(CallbackPayload{
+	ttsCb := callbackData(b.UserID, CallbackPayload{
 		Action: ActionTTS,
 		ChatID: chat.ID,
 		Page:   gistPage,
 	})
 
-	ttsAllCb := mustCallback(CallbackPayload{
+	ttsAllCb := callbackData(b.UserID, CallbackPayload{
 		Action: ActionTTS,
 		ChatID: chat.ID,
 		Page:   0,
@@ -176,6 +258,24 @@ func (b *BaseHandler) buildChatDetailMenu(chat *model.Chat, menu Menu, gistPage
 		))
 	}
 
+	// Кнопки Экспорт, весь пересказ одним документом
+	if len(chat.Gist) > 0 {
+		exportMdCb := callbackData(b.UserID, CallbackPayload{
+			Action: ActionExport,
+			ChatID: chat.ID,
+			Format: int8(export.Markdown),
+		})
+		exportHTMLCb := callbackData(b.UserID, CallbackPayload{
+			Action: ActionExport,
+			ChatID: chat.ID,
+			Format: int8(export.HTML),
+		})
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("📄 Экспорт .md").WithCallbackData(exportMdCb),
+			tu.InlineKeyboardButton("📄 Экспорт .html").WithCallbackData(exportHTMLCb),
+		))
+	}
+
 	// Кнопка "в избранное" / "убрать из избранного"
 	favLabel := "⭐ В избранное"
 	add := true
@@ -183,20 +283,38 @@ func (b *BaseHandler) buildChatDetailMenu(chat *model.Chat, menu Menu, gistPage
 		favLabel = "🗑 Убрать из избранного"
 		add = false
 	}
-	toggleFavCb := mustCallback(CallbackPayload{
+	toggleFavCb := callbackData(b.UserID, CallbackPayload{
 		Action: ActionToggleFav,
 		Src:    menu,
 		ChatID: chat.ID,
 		Add:    &add,
 		Page:   gistPage,
+		Part:   int8(part),
+	})
+
+	// Кнопка "скрыть" / "показать": скрытый чат не попадает в список непрочитанных, сводку и упоминания
+	hideLabel := "🙈 Скрыть"
+	hide := true
+	if chat.IsHidden {
+		hideLabel = "👁 Показать"
+		hide = false
+	}
+	toggleHiddenCb := callbackData(b.UserID, CallbackPayload{
+		Action: ActionToggleHidden,
+		Src:    menu,
+		ChatID: chat.ID,
+		Add:    &hide,
+		Page:   gistPage,
+		Part:   int8(part),
 	})
 	rows = append(rows, tu.InlineKeyboardRow(
 		tu.InlineKeyboardButton(favLabel).WithCallbackData(toggleFavCb),
+		tu.InlineKeyboardButton(hideLabel).WithCallbackData(toggleHiddenCb),
 	))
 
 	// Назад
-	backMainCb := mustCallback(CallbackPayload{Menu: MenuMain})
-	backCb := mustCallback(CallbackPayload{Menu: menu})
+	backMainCb := callbackData(b.UserID, CallbackPayload{Menu: MenuMain})
+	backCb := callbackData(b.UserID, CallbackPayload{Menu: menu})
 	rows = append(rows, tu.InlineKeyboardRow(
 		tu.InlineKeyboardButton("Домой").WithCallbackData(backMainCb),
 		tu.InlineKeyboardButton("← Назад к чатам").WithCallbackData(backCb),
@@ -219,7 +337,10 @@ func (b *BaseHandler) buildChatsMenu(chats []model.Chat, page int, menu Menu) *t
 	for i := start; i < end; i++ {
 		chat := chats[i]
 		label := fmt.Sprintf("📩 %s (%d)", chat.Title, chat.UnreadCount)
-		cb := mustCallback(CallbackPayload{Menu: MenuChat, ChatID: chat.ID, Src: menu})
+		if menu == MenuUnread && b.Session.UnreadOrder() == model.OrderByImportance {
+			label = fmt.Sprintf("%s %s (%d)", importanceMark(chat), chat.Title, chat.UnreadCount)
+		}
+		cb := callbackData(b.UserID, CallbackPayload{Menu: MenuChat, ChatID: chat.ID, Src: menu})
 		rows = append(rows, tu.InlineKeyboardRow(
 			tu.InlineKeyboardButton(label).WithCallbackData(cb),
 		))
@@ -228,11 +349,11 @@ func (b *BaseHandler) buildChatsMenu(chats []model.Chat, page int, menu Menu) *t
 	// Кнопки навигации
 	var navButtons []telego.InlineKeyboardButton
 	if page > 0 {
-		cb := mustCallback(CallbackPayload{Menu: menu, Page: page - 1})
+		cb := callbackData(b.UserID, CallbackPayload{Menu: menu, Page: page - 1})
 		navButtons = append(navButtons, tu.InlineKeyboardButton("◀️").WithCallbackData(cb))
 	}
 	if end < len(chats) {
-		cb := mustCallback(CallbackPayload{Menu: menu, Page: page + 1})
+		cb := callbackData(b.UserID, CallbackPayload{Menu: menu, Page: page + 1})
 		navButtons = append(navButtons, tu.InlineKeyboardButton("▶️").WithCallbackData(cb))
 	}
 
@@ -240,8 +361,20 @@ func (b *BaseHandler) buildChatsMenu(chats []model.Chat, page int, menu Menu) *t
 		rows = append(rows, navButtons)
 	}
 
+	// Переключение сортировки
+	if menu == MenuUnread {
+		orderLabel := "🔥 По важности"
+		if b.Session.UnreadOrder() == model.OrderByImportance {
+			orderLabel = "🔢 По количеству"
+		}
+		orderCb := callbackData(b.UserID, CallbackPayload{Menu: MenuUnread, Action: ActionToggleOrder})
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(orderLabel).WithCallbackData(orderCb),
+		))
+	}
+
 	// Кнопка назад
-	backCb := mustCallback(CallbackPayload{Menu: MenuMain})
+	backCb := callbackData(b.UserID, CallbackPayload{Menu: MenuMain})
 	rows = append(rows, tu.InlineKeyboardRow(
 		tu.InlineKeyboardButton("← Назад").WithCallbackData(backCb),
 	))
@@ -249,40 +382,14 @@ func (b *BaseHandler) buildChatsMenu(chats []model.Chat, page int, menu Menu) *t
 	return tu.InlineKeyboard(rows...)
 }
 
-// Редактирование сообщения с меню.
-func (b *BaseHandler) editMessage(ctx context.Context, text string) error {
-
-	log := slog.With("func", "router.editMessage")
-
-	if b.LastMessageID != 0 {
-		// Пытаемся отредактировать
-		message := tu.EditMessageText(
-			tu.ID(b.UserID),
-			b.LastMessageID,
-			fmt.Sprintf("%s\n\n⏰ %s (UTC+0)", text, time.Now().UTC().Format("15:04:05")), // Выводим метку времени, чтобы было видно когда в последний раз изменилось сообщение. Телеграм отображает только метку создания.
-		)
-
-		_, errE := b.Bot.EditMessageText(ctx, message)
-		if errE != nil {
-			return fmt.Errorf("router.editMessage: %w", errE)
-		}
-
-		return nil
-	}
-
-	// Отправляем новое
-	message := tu.Message(
-		tu.ID(b.UserID),
-		text,
-	)
-
-	msg, errS := b.Bot.SendMessage(ctx, message)
-	if errS != nil {
-		log.Error("send message on editMessage error", slog.Any("error", errS))
-		return fmt.Errorf("send message when editMessage error: %w", errS)
+// importanceMark отметка важности чата в списке: срочные по оценке LLM, с упоминаниями пользователя, остальные.
+func importanceMark(chat model.Chat) string {
+	switch {
+	case chat.Urgency >= 8:
+		return "🔥"
+	case chat.UnreadMentions > 0:
+		return "📣"
+	default:
+		return "📩"
 	}
-
-	b.LastMessageID = msg.MessageID
-
-	return nil
 }
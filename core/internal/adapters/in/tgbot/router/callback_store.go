@@ -0,0 +1,164 @@
+package router
+
+import (
+	"container/list"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	callbackTTL         = 7 * 24 * time.Hour // Время жизни сохраненного payload, после него кнопка ведет в главное меню
+	callbackSweepPeriod = time.Hour          // Как часто удаляются просроченные payload
+	callbackStoreLimit  = 10000              // Максимум сохраненных payload, при переполнении вытесняются давно не использованные
+	callbackTokenPrefix = "~"                // Признак короткого токена в callback_data, JSON начинается с "{"
+	callbackTokenBytes  = 9                  // 12 символов base64, угадать токен чужой кнопки невозможно
+)
+
+var (
+	// errCallbackExpired payload кнопки не найден: истек срок хранения, вытеснен или бот перезапускался.
+	errCallbackExpired = errors.New("callback payload expired")
+	// errCallbackForeign токен кнопки выдан другому пользователю бота.
+	errCallbackForeign = errors.New("callback payload belongs to another user")
+)
+
+// callbackKey ключ payload пользователя: одна и та же кнопка при повторном выводе меню получает тот же токен.
+type callbackKey struct {
+	owner int64
+	hash  [sha256.Size]byte
+}
+
+// callbackEntry сохраненный payload кнопки.
+type callbackEntry struct {
+	token   string
+	key     callbackKey
+	payload CallbackPayload
+	expires time.Time
+}
+
+// callbackStore хранит payload кнопок, не помещающиеся в 64 байта callback_data. В callback_data передается короткий токен.
+// Токен принадлежит пользователю, которому выведена кнопка. Количество payload ограничено, при переполнении
+// вытесняются давно не использованные.
+type callbackStore struct {
+	mu        sync.Mutex
+	tokens    map[string]*list.Element      // Элементы lru по токену
+	keys      map[callbackKey]*list.Element // Элементы lru по пользователю и хэшу payload
+	lru       *list.List                    // *callbackEntry, в начале - последние использованные
+	ttl       time.Duration
+	limit     int
+	lastSweep time.Time
+}
+
+// callbacks общее хранилище payload для всех пользователей бота, токены случайные и привязаны к пользователю.
+var callbacks = newCallbackStore(callbackTTL, callbackStoreLimit)
+
+func newCallbackStore(ttl time.Duration, limit int) *callbackStore {
+	return &callbackStore{
+		tokens:    make(map[string]*list.Element),
+		keys:      make(map[callbackKey]*list.Element),
+		lru:       list.New(),
+		ttl:       ttl,
+		limit:     limit,
+		lastSweep: time.Now(),
+	}
+}
+
+// put сохраняет payload пользователя owner и возвращает токен для callback_data.
+// Повторный вывод той же кнопки возвращает прежний токен, срок хранения отсчитывается заново.
+func (s *callbackStore) put(owner int64, cp CallbackPayload) string {
+	data, _ := json.Marshal(cp) // Payload из чисел и строк, ошибки сериализации нет
+	key := callbackKey{owner: owner, hash: sha256.Sum256(data)}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	if el, ok := s.keys[key]; ok {
+		e := el.Value.(*callbackEntry)
+		e.expires = now.Add(s.ttl)
+		s.lru.MoveToFront(el)
+		return e.token
+	}
+
+	b := make([]byte, callbackTokenBytes)
+	_, _ = rand.Read(b) // crypto/rand.Read не возвращает ошибок
+	token := callbackTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	el := s.lru.PushFront(&callbackEntry{token: token, key: key, payload: cp, expires: now.Add(s.ttl)})
+	s.tokens[token] = el
+	s.keys[key] = el
+
+	for s.lru.Len() > s.limit {
+		s.remove(s.lru.Back())
+	}
+
+	return token
+}
+
+// get возвращает payload по токену пользователя owner. Срок хранения не продлевается.
+func (s *callbackStore) get(owner int64, token string) (*CallbackPayload, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	el, ok := s.tokens[token]
+	if !ok {
+		return nil, errCallbackExpired
+	}
+
+	e := el.Value.(*callbackEntry)
+	if now.After(e.expires) {
+		s.remove(el)
+		return nil, errCallbackExpired
+	}
+	if e.key.owner != owner {
+		return nil, errCallbackForeign
+	}
+
+	s.lru.MoveToFront(el)
+
+	cp := e.payload
+	return &cp, nil
+}
+
+// sweep удаляет просроченные payload не чаще раза в callbackSweepPeriod. Вызывается под блокировкой.
+func (s *callbackStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < callbackSweepPeriod {
+		return
+	}
+
+	for el := s.lru.Front(); el != nil; {
+		next := el.Next()
+		if now.After(el.Value.(*callbackEntry).expires) {
+			s.remove(el)
+		}
+		el = next
+	}
+	s.lastSweep = now
+
+	slog.With("func", "router.callbackStore.sweep").Debug("callback store swept", slog.Int("entries", s.lru.Len()))
+}
+
+// remove удаляет payload из хранилища. Вызывается под блокировкой.
+func (s *callbackStore) remove(el *list.Element) {
+	e := s.lru.Remove(el).(*callbackEntry)
+	delete(s.tokens, e.token)
+	delete(s.keys, e.key)
+}
+
+// isCallbackToken callback_data содержит токен сохраненного payload.
+func isCallbackToken(data string) bool {
+	return strings.HasPrefix(data, callbackTokenPrefix)
+}
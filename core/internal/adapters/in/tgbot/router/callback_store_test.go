@@ -0,0 +1,184 @@
+package router
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testOwner = 100
+	testOther = 200
+)
+
+func TestCallbackStore(t *testing.T) {
+	s := newCallbackStore(time.Hour, 10)
+	cp := CallbackPayload{Menu: MenuModels, ChatID: -1001234567890, Prov: 2, Item: 1, Model: strings.Repeat("model-", 20)}
+
+	token := s.put(testOwner, cp)
+	if !isCallbackToken(token) {
+		t.Fatalf("token %q has no prefix %q", token, callbackTokenPrefix)
+	}
+	if len(token) > maxDataSize {
+		t.Fatalf("token %q longer than %d bytes", token, maxDataSize)
+	}
+
+	// Повторный вывод кнопки не создает новый payload
+	if again := s.put(testOwner, cp); again != token {
+		t.Errorf("put of the same payload returned new token %q, want %q", again, token)
+	}
+	if other := s.put(testOther, cp); other == token {
+		t.Errorf("payload of another user got the same token %q", token)
+	}
+	changed := cp
+	changed.Prov = 3
+	if other := s.put(testOwner, changed); other == token {
+		t.Errorf("another payload got the same token %q", token)
+	}
+	if n := s.lru.Len(); n != 3 {
+		t.Errorf("store holds %d payloads, want 3", n)
+	}
+
+	got, err := s.get(testOwner, token)
+	if err != nil {
+		t.Fatalf("get: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(*got, cp) {
+		t.Errorf("get = %+v, want %+v", *got, cp)
+	}
+
+	got.Model = "changed" // Изменение копии не затрагивает хранилище
+	if again, _ := s.get(testOwner, token); again == nil || again.Model != cp.Model {
+		t.Errorf("stored payload modified through returned copy")
+	}
+
+	if _, err := s.get(testOther, token); !errors.Is(err, errCallbackForeign) {
+		t.Errorf("get by another user: error = %v, want %v", err, errCallbackForeign)
+	}
+	if _, err := s.get(testOwner, callbackTokenPrefix+"unknown"); !errors.Is(err, errCallbackExpired) {
+		t.Errorf("get unknown token: error = %v, want %v", err, errCallbackExpired)
+	}
+}
+
+func TestCallbackStoreExpired(t *testing.T) {
+	s := newCallbackStore(time.Hour, 10)
+	token := s.put(testOwner, CallbackPayload{Menu: MenuMain})
+
+	expires := s.tokens[token].Value.(*callbackEntry).expires
+	if _, err := s.get(testOwner, token); err != nil {
+		t.Fatalf("get: unexpected error: %v", err)
+	}
+	if got := s.tokens[token].Value.(*callbackEntry).expires; !got.Equal(expires) {
+		t.Errorf("get extended expiry from %v to %v", expires, got)
+	}
+
+	s.tokens[token].Value.(*callbackEntry).expires = time.Now().Add(-time.Second)
+
+	if _, err := s.get(testOwner, token); !errors.Is(err, errCallbackExpired) {
+		t.Fatalf("get expired token: error = %v, want %v", err, errCallbackExpired)
+	}
+	if _, ok := s.tokens[token]; ok || s.lru.Len() != 0 || len(s.keys) != 0 {
+		t.Errorf("expired entry is not removed")
+	}
+}
+
+func TestCallbackStoreSweep(t *testing.T) {
+	s := newCallbackStore(time.Hour, 10)
+	expired := s.put(testOwner, CallbackPayload{Menu: MenuMain})
+	live := s.put(testOwner, CallbackPayload{Menu: MenuJobs})
+
+	s.tokens[expired].Value.(*callbackEntry).expires = time.Now().Add(-time.Second)
+	s.lastSweep = time.Now().Add(-2 * callbackSweepPeriod)
+
+	if _, err := s.get(testOwner, live); err != nil { // Просроченные удаляются и при чтении
+		t.Fatalf("get: unexpected error: %v", err)
+	}
+	if _, ok := s.tokens[expired]; ok {
+		t.Errorf("expired entry is not swept")
+	}
+	if s.lru.Len() != 1 {
+		t.Errorf("store holds %d payloads, want 1", s.lru.Len())
+	}
+}
+
+func TestCallbackStoreEviction(t *testing.T) {
+	s := newCallbackStore(time.Hour, 2)
+	first := s.put(testOwner, CallbackPayload{Menu: MenuModels, Item: 1})
+	second := s.put(testOwner, CallbackPayload{Menu: MenuModels, Item: 2})
+
+	if _, err := s.get(testOwner, first); err != nil { // first - последний использованный
+		t.Fatalf("get: unexpected error: %v", err)
+	}
+	third := s.put(testOwner, CallbackPayload{Menu: MenuModels, Item: 3})
+
+	if _, err := s.get(testOwner, second); !errors.Is(err, errCallbackExpired) {
+		t.Errorf("least recently used payload is not evicted: error = %v", err)
+	}
+	for _, token := range []string{first, third} {
+		if _, err := s.get(testOwner, token); err != nil {
+			t.Errorf("get %q: unexpected error: %v", token, err)
+		}
+	}
+	if s.lru.Len() != 2 || len(s.tokens) != 2 || len(s.keys) != 2 {
+		t.Errorf("store size = %d/%d/%d, want 2", s.lru.Len(), len(s.tokens), len(s.keys))
+	}
+}
+
+func TestCallbackData(t *testing.T) {
+	tests := []struct {
+		name      string
+		cp        CallbackPayload
+		wantToken bool // Payload не помещается в 64 байта и хранится на сервере
+	}{
+		{name: "main menu", cp: CallbackPayload{Menu: MenuMain}},
+		{name: "chat gist", cp: CallbackPayload{Menu: MenuChat, ChatID: -1001234567890, Action: ActionGetGist, Range: 3}},
+		{name: "short model", cp: CallbackPayload{Menu: MenuModels, Prov: 1, Item: 1, Model: "qwen3"}},
+		{name: "long model", cp: CallbackPayload{Menu: MenuModels, ChatID: -1001234567890, Prov: 1, Item: 1, Model: "gemini-2.5-flash-preview-09-2025"}, wantToken: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := callbackData(testOwner, tt.cp)
+
+			if len(data) > maxDataSize {
+				t.Fatalf("callback_data %q longer than %d bytes", data, maxDataSize)
+			}
+			if got := isCallbackToken(data); got != tt.wantToken {
+				t.Errorf("isCallbackToken(%q) = %v, want %v", data, got, tt.wantToken)
+			}
+
+			got, err := parseCallback(testOwner, data)
+			if err != nil {
+				t.Fatalf("parseCallback(%q): unexpected error: %v", data, err)
+			}
+			if !reflect.DeepEqual(*got, tt.cp) {
+				t.Errorf("parseCallback(callbackData(cp)) = %+v, want %+v", *got, tt.cp)
+			}
+
+			if _, err := parseCallback(testOther, data); tt.wantToken && !errors.Is(err, errCallbackForeign) {
+				t.Errorf("parseCallback by another user: error = %v, want %v", err, errCallbackForeign)
+			}
+		})
+	}
+}
+
+func TestParseCallbackInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unknown token", callbackTokenPrefix + "AAAAAAAAAAAA"},
+		{"broken json", `{"m":`},
+		{"plain text", "menu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cp, err := parseCallback(testOwner, tt.data); err == nil {
+				t.Errorf("parseCallback(%q) = %+v, want error", tt.data, cp)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	maxMessageLength = 4096 // Telegram ограничивает текст сообщения 4096 символами UTF-16 после разбора HTML разметки
+	maxHeaderLength  = 80   // Длина строки, заканчивающейся двоеточием, до которой она считается заголовком
+)
+
+var (
+	boldMarkdown = regexp.MustCompile(`\*\*([^*\n]+)\*\*`) // **жирный** в ответе LLM
+	listMarker   = regexp.MustCompile(`^(\s*)[-*•]\s+`)    // - пункт, * пункт, • пункт
+	headerPrefix = regexp.MustCompile(`^#{1,6}\s+`)        // # Заголовок
+	topicPrefix  = []string{"Тема:", "Общий итог:", "Итог:", "Основные темы:", "Участники:", "Период:"}
+)
+
+// utf16Len длина строки в единицах UTF-16, так ее считает Telegram.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// formatGistLine переводит строку пересказа в Telegram HTML: заголовки и названия тем жирным, пункты списков с маркером •.
+// Спецсимволы HTML экранируются, результат всегда содержит закрытые теги.
+func formatGistLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return ""
+	}
+
+	if header := headerPrefix.FindString(trimmed); header != "" {
+		return "<b>" + html.EscapeString(strings.Trim(trimmed[len(header):], "* ")) + "</b>"
+	}
+
+	indent := ""
+	if marker := listMarker.FindStringSubmatch(line); marker != nil {
+		indent = strings.Repeat(" ", utf8.RuneCountInString(marker[1])) + "• "
+		trimmed = strings.TrimSpace(line[len(marker[0]):])
+	}
+
+	text := html.EscapeString(trimmed)
+	text = boldMarkdown.ReplaceAllString(text, "<b>$1</b>")
+
+	switch {
+	case strings.HasSuffix(trimmed, ":") && utf8.RuneCountInString(trimmed) <= maxHeaderLength && !strings.Contains(text, "<b>"):
+		return indent + "<b>" + text + "</b>"
+	default:
+		for _, prefix := range topicPrefix {
+			if rest, found := strings.CutPrefix(text, prefix); found {
+				if prefix == "Тема:" { // Название темы целиком
+					return indent + "<b>" + html.EscapeString(strings.ReplaceAll(trimmed, "**", "")) + "</b>"
+				}
+				return indent + "<b>" + prefix + "</b>" + rest
+			}
+		}
+	}
+
+	return indent + text
+}
+
+// splitGist форматирует пересказ в Telegram HTML и разбивает на части.
+// Первая часть не длиннее first, остальные не длиннее limit символов UTF-16.
+// Разбивка по строкам, слишком длинные строки разбиваются по словам, поэтому теги разметки не разрываются.
+func splitGist(gist string, first, limit int) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		size    int
+	)
+
+	budget := func() int {
+		if len(parts) == 0 {
+			return first
+		}
+		return limit
+	}
+
+	flush := func() {
+		parts = append(parts, strings.TrimRight(current.String(), "\n"))
+		current.Reset()
+		size = 0
+	}
+
+	add := func(line string) {
+		n := utf16Len(line) + 1 // + перевод строки
+		if size > 0 && size+n > budget() {
+			flush()
+		}
+		current.WriteString(line + "\n")
+		size += n
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(gist), "\n") {
+		formatted := formatGistLine(line)
+		if utf16Len(formatted) < limit/2 {
+			add(formatted)
+			continue
+		}
+
+		// Длинный абзац разбиваем по словам на куски по оставшемуся месту в части, каждый кусок форматируется отдельно
+		var chunk []string
+		chunkSize := 0
+		for _, word := range strings.Fields(line) {
+			n := utf16Len(html.EscapeString(word)) + 1
+			if chunkSize > 0 && size+chunkSize+n > budget() {
+				add(formatGistLine(strings.Join(chunk, " ")))
+				flush()
+				chunk, chunkSize = chunk[:0], 0
+			}
+			chunk = append(chunk, word)
+			chunkSize += n
+		}
+		if len(chunk) > 0 {
+			add(formatGistLine(strings.Join(chunk, " ")))
+		}
+	}
+
+	if size > 0 || len(parts) == 0 {
+		flush()
+	}
+
+	return parts
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"html"
+	"strings"
+	"testing"
+)
+
+func TestUTF16Len(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello", 5},
+		{"cyrillic", "привет", 6},       // 2 байта UTF-8, 1 единица UTF-16
+		{"bmp symbol", "•", 1},          // 3 байта UTF-8, 1 единица UTF-16
+		{"emoji", "📩", 2},               // Суррогатная пара
+		{"emoji with text", "📩 чат", 6}, // 2 + пробел + 3
+		{"flag", "🇷🇺", 4},               // Два региональных индикатора
+		{"variation selector", "❤️", 2}, // ❤ + U+FE0F
+		{"mixed", "a👍b", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utf16Len(tt.in); got != tt.want {
+				t.Errorf("utf16Len(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatGistLine(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "   ", ""},
+		{"plain", "Обсудили релиз", "Обсудили релиз"},
+		{"escape html", "a < b && c > d", "a &lt; b &amp;&amp; c &gt; d"},
+		{"escape quotes", `он сказал "да"`, "он сказал &#34;да&#34;"},
+		{"tag in text", "<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"markdown header", "## **Итоги недели**", "<b>Итоги недели</b>"},
+		{"header escaped", "# A & B", "<b>A &amp; B</b>"},
+		{"bold", "Решили: **выпустить** в пятницу", "Решили: <b>выпустить</b> в пятницу"},
+		{"bold escaped", "**a<b**", "<b>a&lt;b</b>"},
+		{"list dash", "- пункт", "• пункт"},
+		{"list star nested", "  * пункт", "  • пункт"},
+		{"colon header", "Планы на неделю:", "<b>Планы на неделю:</b>"},
+		{"topic", "Тема: **Релиз** & баги", "<b>Тема: Релиз &amp; баги</b>"},
+		{"topic prefix", "Итог: всё хорошо", "<b>Итог:</b> всё хорошо"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatGistLine(tt.in); got != tt.want {
+				t.Errorf("formatGistLine(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitGist(t *testing.T) {
+	long := strings.Repeat("слово 📩 ", 300) // Один абзац длиннее части
+	lines := strings.Repeat("строка пересказа с эмодзи 👍\n", 100)
+
+	tests := []struct {
+		name      string
+		gist      string
+		first     int
+		limit     int
+		wantParts int // 0 - количество частей не проверяется
+	}{
+		{"short", "Короткий пересказ", 100, 100, 1},
+		{"empty", "", 100, 100, 1},
+		{"many lines", lines, 500, 1000, 0},
+		{"long paragraph", long, 300, 1000, 0},
+		{"small first part", lines, 100, 2000, 0},
+		{"html escaping counted", strings.Repeat("a & b < c\n", 200), 400, 400, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitGist(tt.gist, tt.first, tt.limit)
+
+			if tt.wantParts > 0 && len(parts) != tt.wantParts {
+				t.Fatalf("got %d parts, want %d", len(parts), tt.wantParts)
+			}
+
+			var words []string
+			for i, part := range parts {
+				budget := tt.limit
+				if i == 0 {
+					budget = tt.first
+				}
+				if n := utf16Len(part); n > budget {
+					t.Errorf("part %d: utf16 length %d exceeds %d", i, n, budget)
+				}
+				if part == "" && tt.gist != "" {
+					t.Errorf("part %d is empty", i)
+				}
+				words = append(words, strings.Fields(html.UnescapeString(part))...)
+			}
+
+			// Разбивка не теряет и не переставляет слова, маркеры списков в исходном тексте отсутствуют
+			if got, want := strings.Join(words, " "), strings.Join(strings.Fields(tt.gist), " "); got != want {
+				t.Errorf("words changed after split:\ngot  %.200q\nwant %.200q", got, want)
+			}
+		})
+	}
+}
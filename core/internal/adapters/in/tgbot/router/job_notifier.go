@@ -0,0 +1,234 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/utils"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// startJob отправляет сообщение с ходом выполнения задачи и ставит задачу в очередь.
+// Каждая задача выводит прогресс в своем сообщении, поэтому несколько задач не мешают друг другу и меню.
+func (b *BaseHandler) startJob(ctx context.Context, start func(callback func(job model.Job)) (model.Job, error)) error {
+	log := slog.With("func", "router.startJob")
+
+	b.jobsMu.Lock() // Задача может завершиться раньше, чем сохраним ID сообщения. Оповещение ждет снятия блокировки.
+	defer b.jobsMu.Unlock()
+
+	msg, errS := b.Bot.SendMessage(ctx, tu.Message(tu.ID(b.UserID), "⏳ Задача поставлена в очередь..."))
+	if errS != nil {
+		return fmt.Errorf("send job progress message error: %w", errS)
+	}
+
+	ctxJob := context.WithoutCancel(ctx) // Задача переживает обработчик колбэка
+	job, errJ := start(func(job model.Job) {
+		_ = b.editJobMessage(ctxJob, msg.MessageID, formatJobProgress(job))
+	})
+	if errJ != nil {
+		if errors.Is(errJ, model.ErrJobAlreadyRunning) {
+			return b.editJobMessage(ctx, msg.MessageID,
+				fmt.Sprintf("⚠️ %s уже выполняется, ход выполнения в меню \"⏳ Задачи\"", jobTitle(job)))
+		}
+		log.Error("start job error", slog.Any("error", errJ))
+		return b.editJobMessage(ctx, msg.MessageID, fmt.Sprintf("❌ Не удалось запустить задачу: %v", errJ))
+	}
+
+	if b.jobMessages == nil {
+		b.jobMessages = make(map[int64]int)
+	}
+	b.jobMessages[job.ID] = msg.MessageID
+
+	return b.editJobMessage(ctx, msg.MessageID, formatJobProgress(job))
+}
+
+// NotifyJobFinished оповещает пользователя о завершении фоновой задачи.
+// Сообщение с ходом выполнения удаляется, вместо него отправляется новое, чтобы Telegram показал уведомление.
+func (b *BaseHandler) NotifyJobFinished(ctx context.Context, job model.Job) {
+	log := slog.With("func", "router.NotifyJobFinished", slog.Int64("job_id", job.ID))
+
+	b.jobsMu.Lock()
+	messageID, ok := b.jobMessages[job.ID]
+	delete(b.jobMessages, job.ID)
+	b.jobsMu.Unlock()
+
+	if ok {
+		errD := b.Bot.DeleteMessage(ctx, tu.Delete(tu.ID(b.UserID), messageID))
+		if errD != nil {
+			log.Error("delete job progress message error", slog.Any("error", errD))
+		}
+	}
+
+	settings := b.CoreService.GetSettings(ctx)
+	silent := !settings.NotifyJobs // Оповещения о задачах без звука
+
+	if job.Status == model.JobFailed {
+		message := tu.Message(tu.ID(b.UserID), fmt.Sprintf("❌ %s завершилась с ошибкой:\n%v", jobTitle(job), job.Err))
+		message.DisableNotification = silent
+		_, errS := b.Bot.SendMessage(ctx, message)
+		if errS != nil {
+			log.Error("send job failed message error", slog.Any("error", errS))
+		}
+		return
+	}
+
+	if job.Kind == model.JobBriefing {
+		if errB := b.sendBriefing(ctx, job, silent); errB != nil {
+			log.Error("send briefing error", slog.Any("error", errB))
+		}
+		return
+	}
+
+	if job.Kind == model.JobQuestion {
+		message := tu.Message(tu.ID(b.UserID), b.formatAnswer(ctx, job))
+		message.DisableNotification = silent
+		if _, errS := b.Bot.SendMessage(ctx, message); errS != nil {
+			log.Error("send answer error", slog.Any("error", errS))
+		}
+		return
+	}
+
+	if job.Kind == model.JobAudio { // Голосовые сообщения сами по себе оповещение
+		if errA := b.sendAudio(ctx, job.Audio, silent); errA != nil {
+			log.Error("send audio error", slog.Any("error", errA))
+		}
+		return
+	}
+
+	openCb := callbackData(b.UserID, CallbackPayload{Menu: MenuChat, ChatID: job.ChatID, Src: MenuJobs, Page: 1})
+	message := tu.Message(tu.ID(b.UserID),
+		fmt.Sprintf("✅ %s готова (%s)", jobTitle(job), utils.FormatDurationShort(job.Finished.Sub(job.Started))),
+	).WithReplyMarkup(tu.InlineKeyboard(tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("📩 Открыть пересказ").WithCallbackData(openCb),
+	)))
+	message.DisableNotification = silent
+	_, errS := b.Bot.SendMessage(ctx, message)
+	if errS != nil {
+		log.Error("send job done message error", slog.Any("error", errS))
+	}
+}
+
+// formatAnswer текст ответа на вопрос по чату со ссылками на сообщения-источники.
+func (b *BaseHandler) formatAnswer(ctx context.Context, job model.Job) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "💬 Ответ по чату «%s»\n❓ %s\n\n", job.ChatTitle, job.Question)
+	if job.Answer == nil {
+		return text.String()
+	}
+
+	text.WriteString(job.Answer.Text)
+	if len(job.Answer.Citations) > 0 {
+		loc := b.location(ctx)
+		text.WriteString("\n\n📎 Источники:")
+		for _, c := range job.Answer.Citations {
+			fmt.Fprintf(&text, "\n• #%d — %s", c.MessageID, c.Timestamp.In(loc).Format(dateTimeLayout))
+		}
+	}
+	return text.String()
+}
+
+// StartQuestion ставит в очередь ответ на вопрос пользователя по чату chatID, ответ придет оповещением о завершении задачи.
+func (b *BaseHandler) StartQuestion(ctx context.Context, chatID int64, question string) error {
+	return b.startJob(ctx, func(callback func(job model.Job)) (model.Job, error) {
+		return b.CoreService.StartQuestionJob(ctx, chatID, question, callback)
+	})
+}
+
+// sendAudio отправляет файлы с аудиопересказом голосовыми сообщениями, по очереди. silent - без звука.
+func (b *BaseHandler) sendAudio(ctx context.Context, audioGist []model.AudioGist, silent bool) error {
+	log := slog.With("func", "router.sendAudio")
+
+	for i := range audioGist {
+		errS := func() error {
+			audioFile, errO := os.Open(audioGist[i].AudioFile)
+			if errO != nil {
+				return fmt.Errorf("open audio file error: %w", errO)
+			}
+			defer func() {
+				errC := audioFile.Close()
+				if errC != nil {
+					log.Error("audio file close error", slog.Any("error", errC))
+				}
+			}()
+
+			// Отправляем аудио
+			voice := tu.Voice( // Отправка голосового сообщения.
+				tu.ID(b.UserID),
+				tu.File(audioFile),
+			).WithCaption(audioGist[i].Caption)
+			voice.DisableNotification = silent
+			_, errV := b.Bot.SendVoice(ctx, voice)
+			return errV
+		}()
+		if errS != nil {
+			return errS
+		}
+	}
+
+	return nil
+}
+
+// editJobMessage редактирует сообщение с ходом выполнения задачи.
+func (b *BaseHandler) editJobMessage(ctx context.Context, messageID int, text string) error {
+	_, errE := b.Bot.EditMessageText(ctx, tu.EditMessageText(
+		tu.ID(b.UserID),
+		messageID,
+		fmt.Sprintf("%s\n\n⏰ %s", text, time.Now().In(b.location(ctx)).Format(clockLayout)), // Метка времени, чтобы было видно когда в последний раз изменилось сообщение.
+	))
+	if errE != nil {
+		return fmt.Errorf("router.editJobMessage: %w", errE)
+	}
+	return nil
+}
+
+// jobTitle название задачи для вывода пользователю.
+func jobTitle(job model.Job) string {
+	switch job.Kind {
+	case model.JobAudio:
+		return fmt.Sprintf("Задача #%d: аудиопересказ «%s»", job.ID, job.ChatTitle)
+	case model.JobBriefing:
+		return fmt.Sprintf("Задача #%d: сводка", job.ID)
+	case model.JobQuestion:
+		return fmt.Sprintf("Задача #%d: вопрос по чату «%s»", job.ID, job.ChatTitle)
+	default:
+		if !job.Range.IsUnread() {
+			return fmt.Sprintf("Задача #%d: пересказ «%s», %s", job.ID, job.ChatTitle, job.Range)
+		}
+		return fmt.Sprintf("Задача #%d: пересказ «%s»", job.ID, job.ChatTitle)
+	}
+}
+
+// jobStatusIcon иконка состояния задачи.
+func jobStatusIcon(status model.JobStatus) string {
+	switch status {
+	case model.JobPending:
+		return "🕓"
+	case model.JobRunning:
+		return "🔄"
+	case model.JobDone:
+		return "✅"
+	case model.JobFailed:
+		return "❌"
+	default:
+		return "❔"
+	}
+}
+
+// formatJobProgress текст сообщения с ходом выполнения задачи.
+func formatJobProgress(job model.Job) string {
+	switch {
+	case job.LLM:
+		bar := strings.Repeat("█", job.Part/10) + strings.Repeat("░", 10-job.Part/10)
+		return fmt.Sprintf("%s\n%s\n\n [%s] %d%%", jobTitle(job), job.Progress, bar, job.Part)
+	case job.Part > 0:
+		return fmt.Sprintf("%s\n%s\n\n %d сообщений загружено", jobTitle(job), job.Progress, job.Part)
+	default:
+		return fmt.Sprintf("%s\n%s", jobTitle(job), job.Progress)
+	}
+}
@@ -43,5 +43,5 @@ func (h *ChatMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, pa
 		page = 1 // Отображаем первую страницу пересказа.
 	}
 
-	return h.showChatDetail(ctx, chatDetail, payload.Src, page) // page меняется по нажатию кнопок вправо/влево
+	return h.showChatDetail(ctx, chatDetail, payload.Src, page, int(payload.Part)) // page, part меняются по нажатию кнопок вправо/влево
 }
@@ -46,35 +46,9 @@ func (h *FavoritesMenuHandler) showFavoriteChats(ctx context.Context, chats []mo
 	log := slog.With("func", "router.showFavoriteChats")
 	log.Debug("showFavoriteChats")
 
-	inlineKeyboard := h.buildChatsMenu(chats, page, MenuFavorites)
-
-	if h.LastMessageID != 0 {
-		// Пытаемся отредактировать
-		message := tu.EditMessageText(
-			tu.ID(h.UserID),
-			h.LastMessageID,
-			fmt.Sprintf("📬 Избранные чаты (%d шт.)", len(chats))).WithReplyMarkup(inlineKeyboard)
-
-		_, errE := h.Bot.EditMessageText(ctx, message)
-		if errE == nil {
-			return nil // Успешно отредактировали
-		}
-		log.Error("edit message with favorite chats menu error", slog.Any("error", errE))
-		// Иначе — отправим новое
-	}
+	screen := Screen{Menu: MenuFavorites}
 
-	// Отправляем новое
-	message := tu.Message(
-		tu.ID(h.UserID),
-		fmt.Sprintf("📬 Избранные чаты (%d шт.)", len(chats)),
-	).WithReplyMarkup(inlineKeyboard)
-
-	msg, errS := h.Bot.SendMessage(ctx, message)
-	if errS != nil {
-		log.Error("send message with favorite chats menu error", slog.Any("error", errS))
-		return fmt.Errorf("send message with favorite chats menu error: %w", errS)
-	}
+	inlineKeyboard := h.buildChatsMenu(chats, page, MenuFavorites)
 
-	h.LastMessageID = msg.MessageID // Сохраняем номер сообщения
-	return nil
+	return h.showScreen(ctx, screen, "favorite chats menu", fmt.Sprintf("📬 Избранные чаты (%d шт.)", len(chats)), "", inlineKeyboard)
 }
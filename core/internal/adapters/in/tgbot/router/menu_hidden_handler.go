@@ -0,0 +1,108 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// HiddenMenuHandler Вывод списка скрытых чатов и возврат чатов в списки.
+type HiddenMenuHandler struct {
+	*BaseHandler
+}
+
+// NewHiddenMenuHandler конструктор обработчика вывода списка скрытых чатов.
+func NewHiddenMenuHandler(base *BaseHandler) *HiddenMenuHandler {
+	return &HiddenMenuHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *HiddenMenuHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Menu == MenuHidden
+}
+
+// Handle Реализация интерфейса CallbackHandler
+// С параметром payload.ChatID убирает чат из списка скрытых и выводит обновленный список.
+func (h *HiddenMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
+	log := slog.With("func", "router.HiddenMenuHandler")
+	log.Debug("handling hidden menu callback", slog.Int64("chat_id", payload.ChatID))
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	if payload.ChatID != 0 {
+		if errU := h.CoreService.UnhideChat(ctx, payload.ChatID); errU != nil {
+			log.Error("unhide chat error", slog.Any("error", errU))
+		}
+	}
+
+	chats := h.CoreService.GetHiddenChats(ctx)
+
+	page := payload.Page
+	if page*chatsPerPage >= len(chats) { // Последняя страница опустела после возврата чата
+		page = max(0, (len(chats)-1)/chatsPerPage)
+	}
+
+	return h.showHidden(ctx, chats, page)
+}
+
+func (h *HiddenMenuHandler) showHidden(ctx context.Context, chats []model.HiddenChat, page int) error {
+	screen := Screen{Menu: MenuHidden}
+
+	var text strings.Builder
+	text.WriteString("🙈 Скрытые чаты\n\n")
+	if len(chats) == 0 {
+		text.WriteString("Скрытых чатов нет. Скрыть чат можно кнопкой \"🙈 Скрыть\" в меню чата.")
+	} else {
+		fmt.Fprintf(&text, "Всего: %d. Скрытые чаты не попадают в непрочитанные, сводку и упоминания.\n", len(chats))
+		text.WriteString("Нажмите на чат, чтобы снова показывать его в списках.")
+	}
+
+	inlineKeyboard := buildHiddenMenu(h.UserID, chats, page)
+
+	return h.showScreen(ctx, screen, "hidden menu", text.String(), "", inlineKeyboard)
+}
+
+// Меню скрытых чатов с пагинацией, кнопка чата возвращает его в списки.
+func buildHiddenMenu(userID int64, chats []model.HiddenChat, page int) *telego.InlineKeyboardMarkup {
+	var rows [][]telego.InlineKeyboardButton
+
+	start := page * chatsPerPage
+	end := min(start+chatsPerPage, len(chats))
+
+	for i := start; i < end; i++ {
+		title := chats[i].Title
+		if title == "" {
+			title = fmt.Sprintf("Чат %d", chats[i].ChatID)
+		}
+		cb := callbackData(userID, CallbackPayload{Menu: MenuHidden, ChatID: chats[i].ChatID, Page: page})
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("👁 "+title).WithCallbackData(cb),
+		))
+	}
+
+	// Кнопки навигации
+	var navButtons []telego.InlineKeyboardButton
+	if page > 0 {
+		navButtons = append(navButtons, tu.InlineKeyboardButton("◀️").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuHidden, Page: page - 1})))
+	}
+	if end < len(chats) {
+		navButtons = append(navButtons, tu.InlineKeyboardButton("▶️").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuHidden, Page: page + 1})))
+	}
+	if len(navButtons) > 0 {
+		rows = append(rows, navButtons)
+	}
+
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("Домой").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMain})),
+		tu.InlineKeyboardButton("← Настройки").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuSettings})),
+	))
+
+	return tu.InlineKeyboard(rows...)
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/utils"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// JobsMenuHandler Вывод списка фоновых задач.
+type JobsMenuHandler struct {
+	*BaseHandler
+}
+
+// NewJobsMenuHandler конструктор обработчика вывода списка фоновых задач.
+func NewJobsMenuHandler(base *BaseHandler) *JobsMenuHandler {
+	return &JobsMenuHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *JobsMenuHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Menu == MenuJobs
+}
+
+// Handle Реализация интерфейса CallbackHandler
+func (h *JobsMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, _ *CallbackPayload) error {
+	log := slog.With("func", "router.JobsMenuHandler")
+	log.Debug("handling jobs menu callback")
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	return h.showJobs(ctx, h.CoreService.GetJobs(ctx))
+}
+
+func (h *JobsMenuHandler) showJobs(ctx context.Context, jobs []model.Job) error {
+	log := slog.With("func", "router.showJobs")
+	log.Debug("showJobs")
+
+	screen := Screen{Menu: MenuJobs}
+
+	inlineKeyboard := buildJobsMenu(h.UserID, jobs)
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "⏳ Задачи (%d шт.)\n", len(jobs))
+	for i := range jobs {
+		text.WriteString("\n" + jobStatusIcon(jobs[i].Status) + " " + jobTitle(jobs[i]))
+		switch jobs[i].Status {
+		case model.JobRunning:
+			if jobs[i].LLM {
+				fmt.Fprintf(&text, " — %d%%", jobs[i].Part)
+			}
+		case model.JobDone, model.JobFailed:
+			fmt.Fprintf(&text, " — %s", utils.FormatDurationShort(jobs[i].Finished.Sub(jobs[i].Created)))
+		case model.JobPending:
+		}
+	}
+	fmt.Fprintf(&text, "\n\n⏰ %s", time.Now().In(h.location(ctx)).Format(clockLayout)) // Иначе Telegram не даст отредактировать сообщение кнопкой "Обновить"
+
+	return h.showScreen(ctx, screen, "jobs menu", text.String(), "", inlineKeyboard)
+}
+
+// Меню списка фоновых задач. Для готовых пересказов выводятся кнопки перехода к чату.
+func buildJobsMenu(userID int64, jobs []model.Job) *telego.InlineKeyboardMarkup {
+	var rows [][]telego.InlineKeyboardButton
+
+	for i := range jobs {
+		if jobs[i].Kind != model.JobGist || jobs[i].Status != model.JobDone {
+			continue
+		}
+		cb := callbackData(userID, CallbackPayload{Menu: MenuChat, ChatID: jobs[i].ChatID, Src: MenuJobs, Page: 1})
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(fmt.Sprintf("📩 #%d %s", jobs[i].ID, jobs[i].ChatTitle)).WithCallbackData(cb),
+		))
+	}
+
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("Домой").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMain})),
+		tu.InlineKeyboardButton("🔄 Обновить").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuJobs})),
+	))
+
+	return tu.InlineKeyboard(rows...)
+}
@@ -2,7 +2,6 @@ package router
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 
 	"github.com/mymmrac/telego"
@@ -40,50 +39,36 @@ func (b *BaseHandler) showMainMenu(ctx context.Context) error {
 	log := slog.With("func", "tgbot.showMainMenu")
 	log.Debug("showMainMenu")
 
-	inlineKeyboard := buildMainMenu()
+	screen := Screen{Menu: MenuMain} // Чат закрыт, текстовые сообщения больше не считаются вопросами к нему
 
-	if b.LastMessageID != 0 {
-		// Пытаемся отредактировать
-		message := tu.EditMessageText(
-			tu.ID(b.UserID),
-			b.LastMessageID,
-			"🏠 Главное меню...").WithReplyMarkup(inlineKeyboard)
+	inlineKeyboard := buildMainMenu(b.UserID)
 
-		_, errE := b.Bot.EditMessageText(ctx, message)
-		if errE == nil {
-			return nil // Успешно отредактировали
-		}
-		log.Error("edit message with main menu error", slog.Any("error", errE))
-		// Иначе — отправим новое
-	}
-
-	// Отправляем новое
-	message := tu.Message(
-		tu.ID(b.UserID),
-		"🏠 Главное меню...",
-	).WithReplyMarkup(inlineKeyboard)
-
-	msg, errS := b.Bot.SendMessage(ctx, message)
-	if errS != nil {
-		log.Error("send message with main menu error", slog.Any("error", errS))
-		return fmt.Errorf("send message with main menu error: %w", errS)
-	}
-
-	b.LastMessageID = msg.MessageID // Сохраняем номер сообщения
-	return nil
+	return b.showScreen(ctx, screen, "main menu", "🏠 Главное меню...", "", inlineKeyboard)
 }
 
 // Главное меню
-func buildMainMenu() *telego.InlineKeyboardMarkup {
+func buildMainMenu(userID int64) *telego.InlineKeyboardMarkup {
 	return tu.InlineKeyboard(
 		tu.InlineKeyboardRow(
-			tu.InlineKeyboardButton("📬 Непрочитанные чаты").WithCallbackData(mustCallback(CallbackPayload{Menu: MenuUnread})),
+			tu.InlineKeyboardButton("📬 Непрочитанные чаты").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuUnread})),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("📰 Сводка").WithCallbackData(callbackData(userID, CallbackPayload{Action: ActionBriefing})),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("⭐ Избранные чаты").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuFavorites})),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("📣 Касается вас").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMentions})),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("⏳ Задачи").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuJobs})),
 		),
 		tu.InlineKeyboardRow(
-			tu.InlineKeyboardButton("⭐ Избранные чаты").WithCallbackData(mustCallback(CallbackPayload{Menu: MenuFavorites})),
+			tu.InlineKeyboardButton("💰 Расход").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuUsage})),
 		),
 		tu.InlineKeyboardRow(
-			tu.InlineKeyboardButton("⚙️ Настройки").WithCallbackData(mustCallback(CallbackPayload{Menu: MenuSettings})),
+			tu.InlineKeyboardButton("⚙️ Настройки").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuSettings})),
 		),
 	)
 }
@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const (
+	mentionSnippetLength = 120  // Длина фрагмента сообщения в списке упоминаний, символов
+	maxMentionsLength    = 3800 // Ограничение длины списка упоминаний, Телеграм ограничивает сообщение 4096 символами
+	maxAboutMeMessages   = 5    // Количество сообщений в блоке "Касается вас"
+)
+
+// MentionsMenuHandler Вывод упоминаний и ответов пользователю по всем чатам.
+type MentionsMenuHandler struct {
+	*BaseHandler
+}
+
+// NewMentionsMenuHandler конструктор обработчика вывода упоминаний и ответов пользователю.
+func NewMentionsMenuHandler(base *BaseHandler) *MentionsMenuHandler {
+	return &MentionsMenuHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *MentionsMenuHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Menu == MenuMentions
+}
+
+// Handle Реализация интерфейса CallbackHandler
+func (h *MentionsMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, _ *CallbackPayload) error {
+	log := slog.With("func", "router.MentionsMenuHandler")
+	log.Debug("handling mentions menu callback")
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	mentions, errM := h.CoreService.GetMentions(ctx)
+	if errM != nil {
+		log.Error("GetMentions", slog.Any("error", errM))
+	}
+
+	return h.showMentions(ctx, mentions)
+}
+
+func (h *MentionsMenuHandler) showMentions(ctx context.Context, mentions []model.ChatMentions) error {
+	log := slog.With("func", "router.showMentions")
+	log.Debug("showMentions")
+
+	screen := Screen{Menu: MenuMentions}
+
+	inlineKeyboard := buildMentionsMenu(h.UserID, mentions)
+	loc := h.location(ctx)
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "📣 Касается вас (%d чатов)\n", len(mentions))
+	for i := range mentions {
+		chatText := fmt.Sprintf("\n💬 %s — 📣 %d, ❤️ %d\n", mentions[i].Chat.Title, mentions[i].Chat.UnreadMentions, mentions[i].Chat.UnreadReactions)
+		if mentions[i].Err != nil {
+			chatText += " ❌ Не удалось загрузить упоминания\n"
+		}
+		for j := range mentions[i].Messages {
+			chatText += formatMention(mentions[i].Messages[j], loc) + "\n"
+		}
+		if text.Len()+len(chatText) > maxMentionsLength {
+			text.WriteString("\n…")
+			break
+		}
+		text.WriteString(chatText)
+	}
+
+	return h.showScreen(ctx, screen, "mentions menu", text.String(), "", inlineKeyboard)
+}
+
+// Меню упоминаний: переход к каждому чату и возврат в главное меню.
+func buildMentionsMenu(userID int64, mentions []model.ChatMentions) *telego.InlineKeyboardMarkup {
+	var rows [][]telego.InlineKeyboardButton
+
+	for i := range mentions {
+		cb := callbackData(userID, CallbackPayload{Menu: MenuChat, ChatID: mentions[i].Chat.ID, Src: MenuMentions})
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("📩 "+mentions[i].Chat.Title).WithCallbackData(cb),
+		))
+	}
+
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("Домой").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMain})),
+		tu.InlineKeyboardButton("🔄 Обновить").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMentions})),
+	))
+
+	return tu.InlineKeyboard(rows...)
+}
+
+// formatMention строка с сообщением, касающимся пользователя: признак (ответ или упоминание), время и фрагмент текста.
+func formatMention(m model.Message, loc *time.Location) string {
+	icon := "📣"
+	if m.ReplyToMe {
+		icon = "↩️"
+	}
+
+	text := strings.Join(strings.Fields(m.Text), " ")
+	if utf8.RuneCountInString(text) > mentionSnippetLength {
+		text = string([]rune(text)[:mentionSnippetLength]) + "…"
+	}
+
+	return fmt.Sprintf(" %s %s #%d: %s", icon, m.Timestamp.In(loc).Format("02.01 15:04"), m.ID, text)
+}
+
+// formatAboutMe блок "Касается вас" для вывода в начале пересказа. Пустая строка, если таких сообщений нет.
+func formatAboutMe(messages []model.Message, loc *time.Location) string {
+	if len(messages) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	text.WriteString("🎯 Касается вас:\n")
+	for i := range messages {
+		if i == maxAboutMeMessages {
+			fmt.Fprintf(&text, " … и еще %d\n", len(messages)-maxAboutMeMessages)
+			break
+		}
+		text.WriteString(formatMention(messages[i], loc) + "\n")
+	}
+	text.WriteString("\n")
+
+	return text.String()
+}
@@ -0,0 +1,232 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const modelsPerPage = 8 // Количество моделей выводимых пользователю за раз (пагинация)
+
+// ModelsMenuHandler Выбор модели LLM для всех чатов или для выбранного чата.
+type ModelsMenuHandler struct {
+	*BaseHandler
+}
+
+// NewModelsMenuHandler конструктор обработчика выбора модели LLM.
+func NewModelsMenuHandler(base *BaseHandler) *ModelsMenuHandler {
+	return &ModelsMenuHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *ModelsMenuHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Menu == MenuModels
+}
+
+// modelsView состояние меню выбора модели.
+type modelsView struct {
+	chat      *model.Chat // nil - модель для всех чатов
+	src       Menu        // Меню, из которого открыт чат
+	providers []string
+	provider  int // Индекс выбранного провайдера
+	models    []string
+	current   model.LLMModel
+	override  bool // Для чата выбрана своя модель
+	page      int
+	errText   string
+}
+
+// Handle Реализация интерфейса CallbackHandler
+// payload.Item выбирает модель payload.Model провайдера payload.Prov, payload.Key = SettingReset возвращает чату общую модель.
+func (h *ModelsMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
+	log := slog.With("func", "router.ModelsMenuHandler")
+	log.Debug("handling models menu callback", slog.Int64("chat_id", payload.ChatID), slog.Int("item", payload.Item))
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	view := modelsView{src: payload.Src, page: payload.Page}
+	if payload.ChatID != 0 {
+		chatDetail, errD := h.CoreService.GetChatDetail(ctx, payload.ChatID)
+		if errD != nil {
+			chatDetail = &model.Chat{ID: payload.ChatID}
+			log.Error("GetChatDetail", slog.Any("error", errD))
+		}
+		view.chat = chatDetail
+	}
+
+	settings := h.CoreService.GetSettings(ctx)
+	view.current = settings.TextModel(payload.ChatID)
+	view.providers = h.CoreService.GetProviders(ctx)
+	if len(view.providers) == 0 {
+		view.errText = "Нет включенных провайдеров LLM"
+		return h.showModels(ctx, view)
+	}
+
+	view.provider = max(slices.Index(view.providers, view.current.Provider), 0)
+	if payload.Prov > 0 && int(payload.Prov) <= len(view.providers) {
+		view.provider = int(payload.Prov) - 1
+	}
+
+	models, errL := h.CoreService.GetModels(ctx, view.providers[view.provider])
+	if errL != nil {
+		log.Error("GetModels", slog.Any("error", errL))
+		view.errText = "Не удалось получить список моделей провайдера"
+	}
+	view.models = models
+
+	var errU error
+	switch {
+	case payload.Key == SettingReset && payload.ChatID != 0:
+		settings, errU = h.CoreService.SetChatModel(ctx, payload.ChatID, model.LLMModel{})
+	case payload.Item > 0 && errL != nil:
+		// Модель нельзя проверить по списку провайдера, не сохраняем. Текст ошибки уже задан выше
+	case payload.Item > 0 && !slices.Contains(models, payload.Model):
+		// Список моделей провайдера мог измениться с момента вывода меню, неизвестную модель не сохраняем
+		log.Warn("unknown model selected", slog.String("provider", view.providers[view.provider]), slog.String("model", payload.Model))
+		view.errText = fmt.Sprintf("Модель %q недоступна у провайдера, выберите модель из обновленного списка", payload.Model)
+	case payload.Item != 0:
+		selected := model.LLMModel{Provider: view.providers[view.provider]}
+		if payload.Item > 0 {
+			selected.Name = payload.Model
+		}
+
+		if payload.ChatID != 0 {
+			settings, errU = h.CoreService.SetChatModel(ctx, payload.ChatID, selected)
+		} else {
+			settings.Provider, settings.Model = selected.Provider, selected.Name
+			settings, errU = h.CoreService.UpdateSettings(ctx, settings)
+		}
+	}
+	if errU != nil {
+		log.Error("update model error", slog.Any("error", errU))
+		view.errText = "Не удалось сохранить выбор модели"
+	}
+
+	view.current = settings.TextModel(payload.ChatID)
+	_, view.override = settings.ChatModels[payload.ChatID]
+
+	if view.page == 0 { // Открываем страницу с текущей моделью
+		view.page = 1
+		if i := slices.Index(models, view.current.Name); i >= 0 && view.current.Provider == view.providers[view.provider] {
+			view.page = i/modelsPerPage + 1
+		}
+	}
+
+	return h.showModels(ctx, view)
+}
+
+func (h *ModelsMenuHandler) showModels(ctx context.Context, view modelsView) error {
+	screen := Screen{Menu: MenuModels}
+	if view.chat != nil {
+		screen.ChatID = view.chat.ID
+	}
+
+	currentName := view.current.String()
+	if view.current.Name == "" {
+		currentName += " (модель из конфигурации)"
+	}
+
+	var text strings.Builder
+	text.WriteString("🧠 Модель LLM\n\n")
+	if view.chat != nil {
+		fmt.Fprintf(&text, "💬 Для чата: %s\n", view.chat.Title)
+		if !view.override {
+			text.WriteString("Своя модель не выбрана, используется модель из настроек.\n")
+		}
+	} else {
+		text.WriteString("Для всех чатов, кроме чатов со своей моделью.\n")
+	}
+	fmt.Fprintf(&text, "\nСейчас: %s", currentName)
+	if len(view.providers) > 0 {
+		fmt.Fprintf(&text, "\n%s: моделей %d", view.providers[view.provider], len(view.models))
+	}
+	if view.errText != "" {
+		text.WriteString("\n\n⚠️ " + view.errText)
+	}
+
+	inlineKeyboard := buildModelsMenu(h.UserID, view)
+
+	return h.showScreen(ctx, screen, "models menu", text.String(), "", inlineKeyboard)
+}
+
+// Меню выбора модели: провайдеры в первой строке, ниже модели выбранного провайдера по одной в строке, текущая отмечена.
+func buildModelsMenu(userID int64, view modelsView) *telego.InlineKeyboardMarkup {
+	var chatID int64
+	if view.chat != nil {
+		chatID = view.chat.ID
+	}
+	callback := func(cp CallbackPayload) string {
+		cp.Menu, cp.ChatID, cp.Src = MenuModels, chatID, view.src
+		return callbackData(userID, cp)
+	}
+
+	var rows [][]telego.InlineKeyboardButton
+
+	if len(view.providers) > 0 {
+		var providerRow []telego.InlineKeyboardButton
+		for i, p := range view.providers {
+			if i == view.provider {
+				p = "• " + p
+			}
+			providerRow = append(providerRow, tu.InlineKeyboardButton(p).WithCallbackData(callback(CallbackPayload{Prov: int8(i + 1), Page: 1})))
+		}
+		rows = append(rows, providerRow)
+
+		provider := view.providers[view.provider]
+		prov := int8(view.provider + 1)
+		selected := func(name string) string {
+			if view.current.Provider == provider && view.current.Name == name {
+				return "✅ "
+			}
+			return ""
+		}
+
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(selected("")+"⚙️ Из конфигурации").WithCallbackData(callback(CallbackPayload{Prov: prov, Page: view.page, Item: -1})),
+		))
+
+		start := min((view.page-1)*modelsPerPage, len(view.models))
+		end := min(start+modelsPerPage, len(view.models))
+		for i := start; i < end; i++ {
+			rows = append(rows, tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton(selected(view.models[i])+view.models[i]).WithCallbackData(callback(CallbackPayload{Prov: prov, Page: view.page, Item: 1, Model: view.models[i]})),
+			))
+		}
+
+		// Кнопки Назад, Далее для перелистывания страниц со списком моделей
+		var navButtons []telego.InlineKeyboardButton
+		if view.page > 1 {
+			navButtons = append(navButtons, tu.InlineKeyboardButton("← Назад").WithCallbackData(callback(CallbackPayload{Prov: prov, Page: view.page - 1})))
+		}
+		if end < len(view.models) {
+			navButtons = append(navButtons, tu.InlineKeyboardButton("→ Вперед").WithCallbackData(callback(CallbackPayload{Prov: prov, Page: view.page + 1})))
+		}
+		if len(navButtons) > 0 {
+			rows = append(rows, navButtons)
+		}
+	}
+
+	if view.chat == nil {
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("← К настройкам").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuSettings})),
+		))
+		return tu.InlineKeyboard(rows...)
+	}
+
+	var bottom []telego.InlineKeyboardButton
+	bottom = append(bottom, tu.InlineKeyboardButton("← Назад к чату").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuChat, ChatID: chatID, Src: view.src})))
+	if view.override {
+		bottom = append(bottom, tu.InlineKeyboardButton("↩️ Как в настройках").WithCallbackData(callback(CallbackPayload{Key: SettingReset})))
+	}
+	rows = append(rows, bottom)
+
+	return tu.InlineKeyboard(rows...)
+}
@@ -0,0 +1,146 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// Пресеты периода для пересказа. Номер пресета передается в CallbackPayload.Range, 0 - непрочитанные сообщения.
+var rangePresets = []struct {
+	label string
+	rng   func() model.MessageRange
+}{
+	{"📌 Непрочитанные", func() model.MessageRange { return model.MessageRange{} }},
+	{"🕐 24 часа", func() model.MessageRange { return sinceRange("24h", 24*time.Hour) }},
+	{"📆 3 дня", func() model.MessageRange { return sinceRange("3d", 3*24*time.Hour) }},
+	{"🗓 Неделя", func() model.MessageRange { return sinceRange("7d", 7*24*time.Hour) }},
+	{"💬 Последние 100", func() model.MessageRange { return model.MessageRange{Kind: model.RangeLast, Last: 100} }},
+	{"💬 Последние 500", func() model.MessageRange { return model.MessageRange{Kind: model.RangeLast, Last: 500} }},
+}
+
+// sinceRange период до текущего момента. Пресет сохраняется в диапазоне, повторный выбор того же периода не сбрасывает пересказ.
+func sinceRange(preset string, period time.Duration) model.MessageRange {
+	return model.MessageRange{Kind: model.RangeSince, Since: time.Now().Add(-period), Preset: preset}
+}
+
+// messageIDsRange номера сообщений "1200-1350" или "1200-" (до последнего сообщения), знак # перед номером допускается.
+var messageIDsRange = regexp.MustCompile(`^#?(\d+)\s*[-–—]\s*(?:#?(\d+))?$`)
+
+// ParseMessageRange разбирает диапазон номеров сообщений из текста пользователя. false - текст не диапазон.
+func ParseMessageRange(text string) (model.MessageRange, bool) {
+	m := messageIDsRange.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return model.MessageRange{}, false
+	}
+
+	rng := model.MessageRange{Kind: model.RangeBetween}
+	rng.FromID, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		rng.ToID, _ = strconv.Atoi(m[2])
+	}
+	return rng, true
+}
+
+// rangePreset возвращает диапазон сообщений по номеру пресета. Неизвестный номер - непрочитанные сообщения.
+func rangePreset(preset int8) model.MessageRange {
+	if preset <= 0 || int(preset) >= len(rangePresets) {
+		return model.MessageRange{}
+	}
+	return rangePresets[preset].rng()
+}
+
+// RangeMenuHandler Выбор периода для пересказа чата.
+type RangeMenuHandler struct {
+	*BaseHandler
+}
+
+// NewRangeMenuHandler конструктор обработчика выбора периода для пересказа чата.
+func NewRangeMenuHandler(base *BaseHandler) *RangeMenuHandler {
+	return &RangeMenuHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *RangeMenuHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Menu == MenuRange
+}
+
+// Handle Реализация интерфейса CallbackHandler
+func (h *RangeMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
+	log := slog.With("func", "router.RangeMenuHandler")
+	log.Debug("handling range menu callback")
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	chatDetail, errD := h.CoreService.GetChatDetail(ctx, payload.ChatID)
+	if errD != nil {
+		chatDetail = &model.Chat{ID: payload.ChatID}
+		log.Error("GetChatDetail", slog.Any("error", errD))
+	}
+
+	return h.showRange(ctx, chatDetail, payload.Src)
+}
+
+func (h *RangeMenuHandler) showRange(ctx context.Context, chat *model.Chat, menu Menu) error {
+	text := fmt.Sprintf("📩 %s\n\n📅 Выберите период для пересказа.\n Сейчас: %s\n\n"+
+		"🔢 Или отправьте номера сообщений: 1200-1350, либо 1200- до последнего сообщения.",
+		chat.Title, chat.Range.Format(h.location(ctx)))
+	inlineKeyboard := buildRangeMenu(h.UserID, chat.ID, menu)
+
+	screen := Screen{Menu: MenuRange, ChatID: chat.ID}
+
+	return h.showScreen(ctx, screen, "range menu", text, "", inlineKeyboard)
+}
+
+// Меню выбора периода, по две кнопки в строке. Нажатие запускает генерацию пересказа за выбранный период.
+func buildRangeMenu(userID int64, chatID int64, menu Menu) *telego.InlineKeyboardMarkup {
+	var rows [][]telego.InlineKeyboardButton
+
+	var row []telego.InlineKeyboardButton
+	for i := range rangePresets {
+		cb := callbackData(userID, CallbackPayload{
+			Action: ActionGetGist,
+			ChatID: chatID,
+			Src:    menu,
+			Range:  int8(i),
+		})
+		row = append(row, tu.InlineKeyboardButton(rangePresets[i].label).WithCallbackData(cb))
+		if len(row) == 2 {
+			rows = append(rows, tu.InlineKeyboardRow(row...))
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, tu.InlineKeyboardRow(row...))
+	}
+
+	backCb := callbackData(userID, CallbackPayload{Menu: MenuChat, ChatID: chatID, Src: menu})
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("← Назад к чату").WithCallbackData(backCb),
+	))
+
+	return tu.InlineKeyboard(rows...)
+}
+
+// StartRangeGist ставит в очередь пересказ сообщений чата chatID из диапазона номеров, отправленного пользователем в меню выбора периода.
+func (b *BaseHandler) StartRangeGist(ctx context.Context, chatID int64, rng model.MessageRange) error {
+	if errV := rng.Validate(); errV != nil {
+		_, errS := b.Bot.SendMessage(ctx, tu.Message(tu.ID(b.UserID), "⚠️ Неверный диапазон: номера сообщений начинаются с 1, последний не меньше первого"))
+		return errS
+	}
+
+	return b.startJob(ctx, func(callback func(job model.Job)) (model.Job, error) {
+		opts := b.gistOptions(ctx, rng)
+		return b.CoreService.StartGistJob(ctx, chatID, opts, callback)
+	})
+}
@@ -1,13 +1,35 @@
 package router
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
 	tu "github.com/mymmrac/telego/telegoutil"
 )
 
+// Варианты значений настроек, кнопка переключает на следующее значение по кругу.
+var (
+	thresholdValues = []int{1, 3, 5, 10, 20, 50}
+	batchSizeValues = []int{50, 100, 200, 400, 800}
+	styleValues     = []model.GistStyle{model.GistStyleTopics, model.GistStyleBullets, model.GistStyleNarrative}
+	lengthValues    = []model.GistLength{model.GistLengthShort, model.GistLengthMedium, model.GistLengthLong}
+	voiceValues     = []string{"Kore", "Puck", "Charon", "Fenrir", "Aoede", "Leda", "Orus", "Zephyr"} // Голоса Gemini TTS
+	languageValues  = []string{"ru-RU", "en-US"}
+	timezoneValues  = []string{
+		"UTC", "Europe/Kaliningrad", "Europe/Moscow", "Europe/Samara", "Asia/Yekaterinburg", "Asia/Omsk",
+		"Asia/Novosibirsk", "Asia/Krasnoyarsk", "Asia/Irkutsk", "Asia/Yakutsk", "Asia/Vladivostok",
+		"Asia/Magadan", "Asia/Kamchatka", "Europe/London", "Europe/Berlin", "America/New_York",
+	}
+)
+
 // SettingsMenuHandler структура обработчика вывода меню настроек.
 type SettingsMenuHandler struct {
 	*BaseHandler
@@ -24,13 +46,167 @@ func (h *SettingsMenuHandler) CanHandle(payload *CallbackPayload) bool {
 }
 
 // Handle Реализация интерфейса CallbackHandler
-func (h *SettingsMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, _ *CallbackPayload) error {
+// Без параметра выводит меню настроек, с параметром payload.Key переключает его значение и сохраняет настройки.
+func (h *SettingsMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
 	log := slog.With("func", "router.SettingsMenuHandler")
-	log.Debug("handling settings menu callback")
+	log.Debug("handling settings menu callback", slog.Int("key", int(payload.Key)))
 
 	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
 	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
 
-	// TODO implement me
-	return nil
+	settings := h.CoreService.GetSettings(ctx)
+
+	var errU error
+	switch payload.Key {
+	case 0:
+	case SettingReset:
+		settings, errU = h.CoreService.ResetSettings(ctx)
+	default:
+		settings, errU = h.CoreService.UpdateSettings(ctx, nextSetting(settings, payload.Key, h.CoreService.GetProviders(ctx)))
+	}
+	if errU != nil {
+		log.Error("update settings error", slog.Any("error", errU))
+		if !errors.Is(errU, model.ErrInvalidSettings) {
+			return fmt.Errorf("router.SettingsMenuHandler: %w", errU)
+		}
+	}
+
+	return h.showSettings(ctx, settings)
+}
+
+// nextSetting возвращает настройки со следующим значением параметра key.
+func nextSetting(settings model.Settings, key SettingKey, providers []string) model.Settings {
+	switch key {
+	case SettingThreshold:
+		settings.UnreadThreshold = nextValue(thresholdValues, settings.UnreadThreshold)
+	case SettingProvider:
+		if len(providers) > 0 {
+			settings.Provider = nextValue(providers, settings.Provider)
+			settings.Model = "" // Модель из конфигурации нового провайдера
+		}
+	case SettingStyle:
+		settings.GistStyle = nextValue(styleValues, settings.GistStyle)
+	case SettingLength:
+		settings.GistLength = nextValue(lengthValues, settings.GistLength)
+	case SettingVoice:
+		settings.TTSVoice = nextValue(voiceValues, settings.TTSVoice)
+	case SettingLanguage:
+		settings.TTSLanguage = nextValue(languageValues, settings.TTSLanguage)
+	case SettingBatchSize:
+		settings.BatchSize = nextValue(batchSizeValues, settings.BatchSize)
+	case SettingTimezone:
+		settings.Timezone = nextValue(timezoneValues, settings.Timezone)
+	case SettingNotifyJobs:
+		settings.NotifyJobs = !settings.NotifyJobs
+	case SettingNotifyAlerts:
+		settings.NotifyAlerts = !settings.NotifyAlerts
+	case SettingMentionsBlock:
+		settings.SkipMentionsBlock = !settings.SkipMentionsBlock
+	case SettingReset:
+	}
+	return settings
+}
+
+// nextValue следующее за current значение из списка по кругу. Если current нет в списке (задан в конфигурации) - первое значение.
+func nextValue[T comparable](values []T, current T) T {
+	i := slices.Index(values, current)
+	return values[(i+1)%len(values)]
+}
+
+func (h *SettingsMenuHandler) showSettings(ctx context.Context, settings model.Settings) error {
+	screen := Screen{Menu: MenuSettings}
+
+	modelName := settings.Model
+	if modelName == "" {
+		modelName = "по умолчанию"
+	}
+
+	var text strings.Builder
+	text.WriteString("⚙️ Настройки\n\nНажмите на параметр, чтобы переключить значение.\n")
+	fmt.Fprintf(&text, "\n🤖 LLM: %s, модель %s", settings.Provider, modelName)
+	if len(settings.ChatModels) > 0 {
+		fmt.Fprintf(&text, "\n🧠 Своя модель у чатов: %d", len(settings.ChatModels))
+	}
+	fmt.Fprintf(&text, "\n🕒 Сейчас: %s", time.Now().In(settings.Location()).Format(dateTimeLayout))
+
+	inlineKeyboard := buildSettingsMenu(h.UserID, settings)
+
+	return h.showScreen(ctx, screen, "settings menu", text.String(), "", inlineKeyboard)
+}
+
+// Меню настроек, на каждой кнопке текущее значение параметра.
+func buildSettingsMenu(userID int64, settings model.Settings) *telego.InlineKeyboardMarkup {
+	button := func(label string, key SettingKey) telego.InlineKeyboardButton {
+		return tu.InlineKeyboardButton(label).WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuSettings, Key: key}))
+	}
+
+	jobsLabel := "🔔 Задачи: со звуком"
+	if !settings.NotifyJobs {
+		jobsLabel = "🔕 Задачи: без звука"
+	}
+	alertsLabel := "👁 Наблюдение: вкл"
+	if !settings.NotifyAlerts {
+		alertsLabel = "👁 Наблюдение: выкл"
+	}
+
+	mentionsLabel := "📣 Касается вас: вкл"
+	if settings.SkipMentionsBlock {
+		mentionsLabel = "📣 Касается вас: выкл"
+	}
+
+	return tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			button(fmt.Sprintf("📌 Порог: %d", settings.UnreadThreshold), SettingThreshold),
+			button(fmt.Sprintf("📦 Батч: %d", settings.BatchSize), SettingBatchSize),
+		),
+		tu.InlineKeyboardRow(
+			button("🤖 "+settings.Provider, SettingProvider),
+			tu.InlineKeyboardButton("🧠 Модель").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuModels})),
+		),
+		tu.InlineKeyboardRow(
+			button("📝 "+gistStyleTitle(settings.GistStyle), SettingStyle),
+			button("📏 "+gistLengthTitle(settings.GistLength), SettingLength),
+		),
+		tu.InlineKeyboardRow(
+			button("🗣 "+settings.TTSVoice, SettingVoice),
+			button("🌐 "+settings.TTSLanguage, SettingLanguage),
+		),
+		tu.InlineKeyboardRow(
+			button("🕒 "+settings.Timezone, SettingTimezone),
+			tu.InlineKeyboardButton("🙈 Скрытые чаты").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuHidden})),
+		),
+		tu.InlineKeyboardRow(
+			button(mentionsLabel, SettingMentionsBlock),
+		),
+		tu.InlineKeyboardRow(
+			button(jobsLabel, SettingNotifyJobs),
+			button(alertsLabel, SettingNotifyAlerts),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("← Назад").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMain})),
+			button("↩️ Сбросить", SettingReset),
+		),
+	)
+}
+
+func gistStyleTitle(style model.GistStyle) string {
+	switch style {
+	case model.GistStyleBullets:
+		return "Пунктами"
+	case model.GistStyleNarrative:
+		return "Связный текст"
+	default:
+		return "По темам"
+	}
+}
+
+func gistLengthTitle(length model.GistLength) string {
+	switch length {
+	case model.GistLengthShort:
+		return "Кратко"
+	case model.GistLengthLong:
+		return "Подробно"
+	default:
+		return "Средне"
+	}
 }
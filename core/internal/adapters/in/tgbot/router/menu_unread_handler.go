@@ -34,47 +34,35 @@ func (h *UnreadMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery,
 	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
 	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
 
-	chats, errF := h.CoreService.GetChatsWithUnreadMessages(ctx)
+	page := payload.Page
+	if payload.Action == ActionToggleOrder {
+		h.Session.ToggleUnreadOrder()
+		page = 0 // После смены сортировки показываем первую страницу
+	}
+
+	chats, errF := h.CoreService.GetChatsWithUnreadMessages(ctx, h.Session.UnreadOrder())
 	if errF != nil {
 		log.Error("GetChatsWithUnreadMessages", slog.Any("error", errF))
 	}
 
-	return h.showUnreadChats(ctx, chats, payload.Page)
+	return h.showUnreadChats(ctx, chats, page)
 }
 
 func (h *UnreadMenuHandler) showUnreadChats(ctx context.Context, chats []model.Chat, page int) error {
 	log := slog.With("func", "router.showUnreadChats")
 	log.Debug("showUnreadChats")
 
-	inlineKeyboard := h.buildChatsMenu(chats, page, MenuUnread)
-
-	if h.LastMessageID != 0 {
-		// Пытаемся отредактировать
-		message := tu.EditMessageText(
-			tu.ID(h.UserID),
-			h.LastMessageID,
-			fmt.Sprintf("📬 Непрочитанные чаты (%d шт.)", len(chats))).WithReplyMarkup(inlineKeyboard)
+	screen := Screen{Menu: MenuUnread}
 
-		_, errE := h.Bot.EditMessageText(ctx, message)
-		if errE == nil {
-			return nil // Успешно отредактировали
-		}
-		log.Error("edit message with unread chats menu error", slog.Any("error", errE))
-		// Иначе — отправим новое
-	}
+	inlineKeyboard := h.buildChatsMenu(chats, page, MenuUnread)
 
-	// Отправляем новое
-	message := tu.Message(
-		tu.ID(h.UserID),
-		fmt.Sprintf("📬 Непрочитанные чаты (%d шт.)", len(chats)),
-	).WithReplyMarkup(inlineKeyboard)
+	return h.showScreen(ctx, screen, "unread chats menu", unreadChatsTitle(len(chats), h.Session.UnreadOrder()), "", inlineKeyboard)
+}
 
-	msg, errS := h.Bot.SendMessage(ctx, message)
-	if errS != nil {
-		log.Error("send message with unread chats menu error", slog.Any("error", errS))
-		return fmt.Errorf("send message with unread chats menu error: %w", errS)
+// unreadChatsTitle заголовок списка непрочитанных чатов с указанием сортировки.
+func unreadChatsTitle(count int, order model.ChatOrder) string {
+	if order == model.OrderByImportance {
+		return fmt.Sprintf("📬 Непрочитанные чаты (%d шт.), сначала важные", count)
 	}
-
-	h.LastMessageID = msg.MessageID // Сохраняем номер сообщения
-	return nil
+	return fmt.Sprintf("📬 Непрочитанные чаты (%d шт.)", count)
 }
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const usageChatsLimit = 10 // Количество самых затратных чатов в отчете о расходе
+
+// Периоды отчета о расходе в днях, передаются в CallbackPayload.Page.
+var usagePeriods = []int{1, 7, 30}
+
+// UsageMenuHandler Вывод расхода LLM: токены и стоимость по дням и по чатам.
+type UsageMenuHandler struct {
+	*BaseHandler
+}
+
+// NewUsageMenuHandler конструктор обработчика вывода расхода LLM.
+func NewUsageMenuHandler(base *BaseHandler) *UsageMenuHandler {
+	return &UsageMenuHandler{BaseHandler: base}
+}
+
+// CanHandle Реализация интерфейса CallbackHandler
+func (h *UsageMenuHandler) CanHandle(payload *CallbackPayload) bool {
+	return payload.Menu == MenuUsage
+}
+
+// Handle Реализация интерфейса CallbackHandler
+func (h *UsageMenuHandler) Handle(ctx *th.Context, query telego.CallbackQuery, payload *CallbackPayload) error {
+	log := slog.With("func", "router.UsageMenuHandler")
+	log.Debug("handling usage menu callback", slog.Int("days", payload.Page))
+
+	// Обязательно сразу отвечаем, что обработчик работает, могут быть проблемы из-за медленных ответов > 10 секунд
+	_ = h.Bot.AnswerCallbackQuery(ctx, tu.CallbackQuery(query.ID))
+
+	days := payload.Page
+	if days <= 0 {
+		days = 7
+	}
+
+	return h.showUsage(ctx, days, h.CoreService.GetUsage(ctx, days))
+}
+
+func (h *UsageMenuHandler) showUsage(ctx context.Context, days int, report model.UsageReport) error {
+	screen := Screen{Menu: MenuUsage}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "💰 Расход LLM за %d дн. (с %s)\n\n", days, report.Since.Format("02.01.2006"))
+	if report.Total.IsZero() {
+		text.WriteString("Запросов к LLM не было.")
+	} else {
+		fmt.Fprintf(&text, "Итого: %s, запросов %d", formatUsage(report.Total), report.Total.Requests)
+
+		if len(report.Days) > 1 {
+			text.WriteString("\n\n📅 По дням:")
+			for _, d := range report.Days {
+				fmt.Fprintf(&text, "\n%s — %s", d.Day.Format("02.01"), formatUsage(d.Usage))
+			}
+		}
+
+		text.WriteString("\n\n💬 По чатам:")
+		for i, c := range report.Chats {
+			if i == usageChatsLimit {
+				fmt.Fprintf(&text, "\n… и еще %d", len(report.Chats)-usageChatsLimit)
+				break
+			}
+			title := c.Title
+			switch {
+			case c.ChatID == 0:
+				title = "Без чата"
+			case title == "":
+				title = fmt.Sprintf("Чат %d", c.ChatID)
+			}
+			fmt.Fprintf(&text, "\n%s — %s", title, formatUsage(c.Usage))
+		}
+	}
+
+	inlineKeyboard := buildUsageMenu(h.UserID, days)
+
+	return h.showScreen(ctx, screen, "usage menu", text.String(), "", inlineKeyboard)
+}
+
+// Меню расхода: выбор периода, выбранный отмечен.
+func buildUsageMenu(userID int64, days int) *telego.InlineKeyboardMarkup {
+	var periodRow []telego.InlineKeyboardButton
+	for _, d := range usagePeriods {
+		label := fmt.Sprintf("%d дн.", d)
+		if d == days {
+			label = "• " + label
+		}
+		periodRow = append(periodRow, tu.InlineKeyboardButton(label).WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuUsage, Page: d})))
+	}
+
+	return tu.InlineKeyboard(
+		periodRow,
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("Домой").WithCallbackData(callbackData(userID, CallbackPayload{Menu: MenuMain})),
+		),
+	)
+}
+
+// formatUsage стоимость, токены запросов и ответов, длительность синтезированной речи.
+func formatUsage(u model.Usage) string {
+	s := fmt.Sprintf("%s, токенов %s → %s", formatCost(u.Cost), formatTokens(u.InputTokens), formatTokens(u.OutputTokens))
+	if u.AudioSeconds > 0 {
+		s += fmt.Sprintf(", речь %.1f мин", u.AudioSeconds/60)
+	}
+	return s
+}
+
+// formatCost стоимость в USD, мелкие суммы с большей точностью.
+func formatCost(cost float64) string {
+	if cost > 0 && cost < 1 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// formatTokens количество токенов в тысячах или миллионах.
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprint(n)
+	}
+}
@@ -19,6 +19,12 @@ const (
 	MenuFavorites                 // Список избранных чатов
 	MenuSettings                  // Меню настроек
 	MenuChat                      // Меню выбранного чата
+	MenuJobs                      // Список фоновых задач
+	MenuRange                     // Выбор периода для пересказа чата
+	MenuMentions                  // Упоминания и ответы пользователю
+	MenuModels                    // Выбор модели LLM для всех чатов или для выбранного чата
+	MenuUsage                     // Расход LLM: токены и стоимость
+	MenuHidden                    // Список скрытых чатов, ChatID - вернуть чат в списки
 )
 
 // Action тип действия, которое может быть выполнено с чатом Telegram.
@@ -26,20 +32,51 @@ type Action int8
 
 // Список вариантов действий
 const (
-	ActionMarkRead  Action = iota + 1 // ✅ Пометить прочитанным
-	ActionTTS                         // 🔊 Озвучить"
-	ActionToggleFav                   // ⭐ В избранное; 🗑 Убрать из избранного
-	ActionGetGist                     // 📝 Получить краткий пересказ чата
+	ActionMarkRead     Action = iota + 1 // ✅ Пометить прочитанным
+	ActionTTS                            // 🔊 Озвучить"
+	ActionToggleFav                      // ⭐ В избранное; 🗑 Убрать из избранного
+	ActionGetGist                        // 📝 Получить краткий пересказ чата
+	ActionToggleOrder                    // 🔥 По важности; 🔢 По количеству - сортировка списка непрочитанных чатов
+	ActionExport                         // 📄 Экспорт пересказа в документ
+	ActionBriefing                       // 📰 Сводка по всем непрочитанным чатам
+	ActionBriefingRead                   // ✅ Прочитать все чаты сводки, номер задачи в Item
+	ActionToggleHidden                   // 🙈 Скрыть; 👁 Показать - список скрытых чатов
+)
+
+// SettingKey параметр настроек пользователя, изменяемый кнопкой в меню настроек.
+type SettingKey int8
+
+// Список параметров настроек
+const (
+	SettingThreshold     SettingKey = iota + 1 // Порог непрочитанных сообщений
+	SettingProvider                            // Провайдер LLM
+	SettingStyle                               // Стиль пересказа
+	SettingLength                              // Объем пересказа
+	SettingVoice                               // Голос аудиопересказа
+	SettingLanguage                            // Язык аудиопересказа
+	SettingBatchSize                           // Сообщений в батче
+	SettingTimezone                            // Часовой пояс
+	SettingNotifyJobs                          // Звук оповещений о задачах
+	SettingNotifyAlerts                        // Оповещения списка наблюдения
+	SettingReset                               // Сброс настроек
+	SettingMentionsBlock                       // Блок "Касается вас" в пересказе
 )
 
 // CallbackPayload — данные, сериализуемые в callback_data
 type CallbackPayload struct {
-	Menu   Menu   `json:"m,omitempty"`   // MenuMain, MenuUnread, MenuFavorites, MenuChat, MenuSettings	 	int8
-	Page   int    `json:"p,omitempty"`   // Номер страницы, при выводе списка чатов / Либо номер страницы при выводе краткого пересказа чата.
-	ChatID int64  `json:"c,omitempty"`   // ID чата	требуется при выводе инлайн-кнопок со списком чатов
-	Src    Menu   `json:"s,omitempty"`   // MenuUnread или MenuFavorites. тип списка чатов					int8
-	Action Action `json:"a,omitempty"`   // ActionMarkRead, ActionTTS, ActionToggleFav, и т.д.				int8
-	Add    *bool  `json:"add,omitempty"` // для ActionToggleFav												bool
+	Menu   Menu       `json:"m,omitempty"`   // MenuMain, MenuUnread, MenuFavorites, MenuChat, MenuSettings, MenuJobs	int8
+	Page   int        `json:"p,omitempty"`   // Номер страницы, при выводе списка чатов / Либо номер страницы при выводе краткого пересказа чата.
+	ChatID int64      `json:"c,omitempty"`   // ID чата	требуется при выводе инлайн-кнопок со списком чатов
+	Src    Menu       `json:"s,omitempty"`   // MenuUnread или MenuFavorites. тип списка чатов					int8
+	Action Action     `json:"a,omitempty"`   // ActionMarkRead, ActionTTS, ActionToggleFav, и т.д.				int8
+	Add    *bool      `json:"add,omitempty"` // для ActionToggleFav, ActionToggleHidden									bool
+	Range  int8       `json:"r,omitempty"`   // Номер пресета периода для ActionGetGist, 0 - непрочитанные сообщения			int8
+	Part   int8       `json:"pt,omitempty"`  // Номер части страницы краткого пересказа, не помещающейся в одно сообщение, -1 - последняя часть	int8
+	Format int8       `json:"f,omitempty"`   // Формат документа для ActionExport, export.Format						int8
+	Key    SettingKey `json:"k,omitempty"`   // Изменяемый параметр в MenuSettings, SettingReset в MenuModels - сброс модели чата	int8
+	Prov   int8       `json:"pv,omitempty"`  // Номер провайдера LLM в MenuModels с 1, 0 - провайдер текущей модели			int8
+	Item   int        `json:"i,omitempty"`   // Выбор модели в MenuModels: 1 - модель Model, -1 - модель из конфигурации провайдера
+	Model  string     `json:"md,omitempty"`  // Имя выбранной модели в MenuModels. Длинные имена не помещаются в 64 байта, payload хранится на сервере
 }
 
 // Сериализация в callback_data (до 64 байт)
@@ -54,8 +91,12 @@ func (cp CallbackPayload) String() (string, error) {
 	return string(data), nil
 }
 
-// parseCallback
-func parseCallback(data string) (*CallbackPayload, error) {
+// parseCallback разбирает callback_data кнопки, нажатой пользователем userID: JSON payload или токен payload из хранилища.
+func parseCallback(userID int64, data string) (*CallbackPayload, error) {
+	if isCallbackToken(data) {
+		return callbacks.get(userID, data)
+	}
+
 	var cp CallbackPayload
 	if err := json.Unmarshal([]byte(data), &cp); err != nil {
 		return nil, err
@@ -63,11 +104,13 @@ func parseCallback(data string) (*CallbackPayload, error) {
 	return &cp, nil
 }
 
-func mustCallback(cp CallbackPayload) string { // TODO сделать тесты на максимальный размер payload
+// callbackData возвращает callback_data кнопки пользователя userID. Payload, не помещающийся в 64 байта, сохраняется на сервере,
+// в кнопку передается токен этого пользователя.
+func callbackData(userID int64, cp CallbackPayload) string {
 	s, err := cp.String()
 	if err != nil {
-		slog.With("func", "router.mustCallback").Error("callback serialization failed:", slog.Any("error", err))
-		panic("callback too long: " + err.Error())
+		slog.With("func", "router.callbackData").Debug("callback payload stored on server", slog.Any("reason", err))
+		return callbacks.put(userID, cp)
 	}
 	return s
 }
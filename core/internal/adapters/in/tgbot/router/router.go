@@ -3,25 +3,51 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"unsafe"
+	"strings"
+	"time"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // CoreService определяет интерфейс для взаимодействия с бизнес-логикой.
 type CoreService interface {
-	GetAllChats(ctx context.Context) ([]model.Chat, error)                                                                       // Возвращает список всех чатов пользователя.
-	GetChatsWithUnreadMessages(ctx context.Context) ([]model.Chat, error)                                                        // Возвращает список чатов с непрочитанными сообщениями.
-	GetFavoriteChats(ctx context.Context) ([]model.Chat, error)                                                                  // Возвращает список избранных чатов.
-	GetChatGist(ctx context.Context, chatID int64, callback func(message string, part int, llm bool)) ([]model.BatchGist, error) // Возвращает короткий пересказ непрочитанных сообщений чата.
-	GetChatDetail(ctx context.Context, chatID int64) (*model.Chat, error)                                                        // Получение информации о чате из кэша
-	ChangeFavorites(ctx context.Context, chatID int64) error                                                                     // Добавление чата в избранное
-	MarkAsRead(ctx context.Context, chatID int64, pageID int) (*model.Chat, error)                                               // Отмечает указанный чат как прочитанный, удаляя из кэша прочитанный пересказ. Возвращает обновленный объект чата.
-	GetAudioGist(ctx context.Context, chatID int64, pageID int) ([]model.AudioGist, error)                                       // Возвращает аудиопересказ батча, если PageID=0 то всех батчей
+	GetAllChats(ctx context.Context) ([]model.Chat, error)                                                                                               // Возвращает список всех чатов пользователя.
+	GetChatsWithUnreadMessages(ctx context.Context, order model.ChatOrder) ([]model.Chat, error)                                                         // Возвращает список чатов с непрочитанными сообщениями.
+	GetFavoriteChats(ctx context.Context) ([]model.Chat, error)                                                                                          // Возвращает список избранных чатов.
+	GetChatGist(ctx context.Context, chatID int64, opts model.GistOptions, callback func(message string, part int, llm bool)) ([]model.BatchGist, error) // Возвращает короткий пересказ сообщений чата, по умолчанию непрочитанных.
+	GetChatDetail(ctx context.Context, chatID int64) (*model.Chat, error)                                                                                // Получение информации о чате из кэша
+	ChangeFavorites(ctx context.Context, chatID int64) error                                                                                             // Добавление чата в избранное
+	MarkAsRead(ctx context.Context, chatID int64, pageID int) (*model.Chat, error)                                                                       // Отмечает указанный чат как прочитанный, удаляя из кэша прочитанный пересказ. Возвращает обновленный объект чата.
+	GetAudioGist(ctx context.Context, chatID int64, pageID int) ([]model.AudioGist, error)                                                               // Возвращает аудиопересказ батча, если PageID=0 то всех батчей
+	StartGistJob(ctx context.Context, chatID int64, opts model.GistOptions, callback func(job model.Job)) (model.Job, error)                             // Ставит в очередь задачу генерации краткого пересказа чата.
+	StartAudioJob(ctx context.Context, chatID int64, pageID int, callback func(job model.Job)) (model.Job, error)                                        // Ставит в очередь задачу генерации аудиопересказа.
+	StartBriefingJob(ctx context.Context, callback func(job model.Job)) (model.Job, error)                                                               // Ставит в очередь задачу сводки по непрочитанным чатам.
+	MarkBriefingAsRead(ctx context.Context, jobID int64) (int, error)                                                                                    // Отмечает прочитанными чаты сводки, возвращает количество отмеченных чатов.
+	StartQuestionJob(ctx context.Context, chatID int64, question string, callback func(job model.Job)) (model.Job, error)                                // Ставит в очередь задачу ответа на вопрос по сообщениям чата.
+	AddWatchRule(ctx context.Context, rule model.WatchRule) (model.WatchRule, error)                                                                     // Добавляет правило в список наблюдения.
+	RemoveWatchRule(ctx context.Context, ruleID int64) error                                                                                             // Удаляет правило из списка наблюдения.
+	GetWatchRules(ctx context.Context) []model.WatchRule                                                                                                 // Возвращает список наблюдения.
+	GetMentions(ctx context.Context) ([]model.ChatMentions, error)                                                                                       // Возвращает непрочитанные упоминания и ответы пользователю по всем чатам.
+	GetJobs(ctx context.Context) []model.Job                                                                                                             // Возвращает список фоновых задач, новые первыми.
+	WatchJob(ctx context.Context, jobID int64) (<-chan model.Job, error)                                                                                 // Подписывает на ход выполнения фоновой задачи.
+	GetSettings(ctx context.Context) model.Settings                                                                                                      // Возвращает настройки пользователя.
+	UpdateSettings(ctx context.Context, settings model.Settings) (model.Settings, error)                                                                 // Проверяет и сохраняет настройки пользователя.
+	ResetSettings(ctx context.Context) (model.Settings, error)                                                                                           // Возвращает настройки к значениям по умолчанию.
+	GetProviders(ctx context.Context) []string                                                                                                           // Возвращает включенных провайдеров LLM.
+	GetModels(ctx context.Context, provider string) ([]string, error)                                                                                    // Возвращает модели, доступные у провайдера LLM.
+	SetChatModel(ctx context.Context, chatID int64, m model.LLMModel) (model.Settings, error)                                                            // Выбирает модель LLM для чата, пустая модель - общая модель пользователя.
+	GetUsage(ctx context.Context, days int) model.UsageReport                                                                                            // Возвращает расход LLM за последние дни по дням и по чатам.
+	HideChat(ctx context.Context, chatID int64) error                                                                                                    // Добавляет чат в список скрытых.
+	UnhideChat(ctx context.Context, chatID int64) error                                                                                                  // Убирает чат из списка скрытых.
+	GetHiddenChats(ctx context.Context) []model.HiddenChat                                                                                               // Возвращает список скрытых чатов.
 }
 
 // CallbackHandler определяет интерфейс для обработчиков колбэков от инлайн кнопок
@@ -56,18 +82,36 @@ func (r *CallbackRouter) Handle(ctx *th.Context, query telego.CallbackQuery) err
 		return fmt.Errorf("no callback data")
 	}
 
-	payload, err := parseCallback(query.Data)
+	payload, err := parseCallback(query.From.ID, query.Data)
+	if errors.Is(err, errCallbackForeign) { // Токен кнопки из сообщения другого пользователя
+		r.log.Warn("callback payload of another user rejected", "user_id", query.From.ID)
+		return fmt.Errorf("invalid callback data: %w", err)
+	}
+	if errors.Is(err, errCallbackExpired) { // Кнопка старого сообщения, состояние меню утеряно
+		r.log.Info("callback payload expired, show main menu", "data", query.Data)
+		payload, err = &CallbackPayload{Menu: MenuMain}, nil
+	}
 	if err != nil {
 		r.log.Error("failed to parse callback data", "error", err, "data", query.Data)
 		return fmt.Errorf("invalid callback data: %w", err)
 	}
 
-	fmt.Println(payload) // TODO delete this Выводить в виде JSON?
-	fmt.Println(unsafe.Sizeof(payload))
+	if query.Message != nil { // Экран выводится в сообщении с нажатой кнопкой, даже если это старое сообщение
+		ctx = ctx.WithValue(screenMessageKey{}, query.Message.GetMessageID())
+	}
 
 	for _, handler := range r.handlers { // Перебор зарегистрированных колбэков
 		if handler.CanHandle(payload) {
-			return handler.Handle(ctx, query, payload)
+			name := handlerName(handler)
+			spanCtx, span := tracing.Start(ctx, "bot.callback "+name,
+				attribute.String("bot.handler", name),
+				attribute.Int64("bot.chat_id", payload.ChatID))
+
+			start := time.Now()
+			errH := handler.Handle(ctx.WithContext(spanCtx), query, payload)
+			metrics.ObserveCallback(name, errH, time.Since(start))
+			tracing.End(span, errH)
+			return errH
 		}
 	}
 
@@ -75,6 +119,12 @@ func (r *CallbackRouter) Handle(ctx *th.Context, query telego.CallbackQuery) err
 	return nil
 }
 
+// handlerName имя обработчика для метрик: тип без пакета, например ChatMenuHandler.
+func handlerName(handler CallbackHandler) string {
+	name := fmt.Sprintf("%T", handler)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
 // ShowMainMenu выводит главное меню в Telegram боте.
 func (r *CallbackRouter) ShowMainMenu(ctx *th.Context) error {
 	// Создаем фейковый callback с MenuMain
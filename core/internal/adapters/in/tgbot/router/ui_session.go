@@ -0,0 +1,123 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const maxScreens = 20 // Сколько последних экранов помнит сессия, старые сообщения с кнопками продолжают работать и без записи
+
+// Screen экран, который выводит сообщение бота.
+type Screen struct {
+	Menu    Menu
+	ChatID  int64 // Чат, к которому относится экран: описание чата, выбор периода, 0 - экран без чата
+	Updated time.Time
+}
+
+// screenMessageKey ключ ID сообщения с нажатой кнопкой в контексте обработчика.
+type screenMessageKey struct{}
+
+// UISession состояние интерфейса пользователя в боте: какие экраны выводят сообщения бота.
+// Кнопка редактирует свое сообщение, поэтому одновременно живут несколько экранов (ход задачи, описание чата, старые меню).
+type UISession struct {
+	mu      sync.Mutex
+	screens map[int]Screen // Экраны по ID сообщения бота
+	last    int            // Сообщение последнего выведенного экрана
+
+	unreadOrder model.ChatOrder // Сортировка списка непрочитанных чатов, переключается в меню
+}
+
+// NewUISession конструктор сессии интерфейса пользователя.
+func NewUISession() *UISession {
+	return &UISession{screens: make(map[int]Screen)}
+}
+
+// Target ID сообщения, в котором выводится экран: сообщение с нажатой кнопкой, для команд - последний экран. 0 - отправить новое сообщение.
+func (s *UISession) Target(ctx context.Context) int {
+	if messageID, ok := ctx.Value(screenMessageKey{}).(int); ok && messageID != 0 {
+		return messageID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Show запоминает экран, выведенный в сообщении messageID. Сообщение становится последним экраном.
+func (s *UISession) Show(messageID int, screen Screen) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	screen.Updated = time.Now()
+	s.screens[messageID] = screen
+	s.last = messageID
+
+	if len(s.screens) > maxScreens {
+		oldest, oldestTime := 0, time.Time{}
+		for id, sc := range s.screens {
+			if oldest == 0 || sc.Updated.Before(oldestTime) {
+				oldest, oldestTime = id, sc.Updated
+			}
+		}
+		delete(s.screens, oldest)
+	}
+}
+
+// Forget удаляет экран сообщения, например, если сообщение не удалось отредактировать.
+func (s *UISession) Forget(messageID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.screens, messageID)
+	if s.last == messageID {
+		s.last = 0
+	}
+}
+
+// OpenChatID ID чата последнего экрана: описание чата, выбор периода или модели чата. 0 - экран без чата.
+func (s *UISession) OpenChatID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.screens[s.last].ChatID
+}
+
+// OpenScreen экран последнего выведенного сообщения.
+func (s *UISession) OpenScreen() Screen {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.screens[s.last]
+}
+
+// UnreadOrder сортировка списка непрочитанных чатов.
+func (s *UISession) UnreadOrder() model.ChatOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.unreadOrder
+}
+
+// ToggleUnreadOrder переключает сортировку списка непрочитанных чатов: по важности или по количеству сообщений.
+func (s *UISession) ToggleUnreadOrder() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unreadOrder == model.OrderByImportance {
+		s.unreadOrder = model.OrderByUnread
+	} else {
+		s.unreadOrder = model.OrderByImportance
+	}
+}
+
+// errTextMessageNotModified текст ошибки Bot API при редактировании сообщения без изменений.
+const errTextMessageNotModified = "message is not modified"
+
+// isMessageNotModified Telegram не редактирует сообщение, если текст и кнопки не изменились. Экран уже выведен, новое сообщение не нужно.
+func isMessageNotModified(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errTextMessageNotModified)
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// NotifyWatchAlert отправляет пользователю оповещение о сообщении, подходящем под правило списка наблюдения.
+func (b *BaseHandler) NotifyWatchAlert(ctx context.Context, alert model.WatchAlert) {
+	log := slog.With("func", "router.NotifyWatchAlert", slog.Int64("rule_id", alert.Rule.ID))
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "🔔 %s\n👁 Правило #%d: %s\n⏰ %s\n\n%s",
+		alert.ChatTitle,
+		alert.Rule.ID,
+		alert.Rule,
+		alert.Timestamp.In(b.location(ctx)).Format(dateTimeLayout),
+		alert.Snippet,
+	)
+	if alert.Link != "" {
+		text.WriteString("\n\n🔗 " + alert.Link)
+	}
+
+	_, errS := b.Bot.SendMessage(ctx, tu.Message(tu.ID(b.UserID), text.String()))
+	if errS != nil {
+		log.Error("send watch alert error", slog.Any("error", errS))
+	}
+}
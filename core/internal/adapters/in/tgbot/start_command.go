@@ -13,7 +13,7 @@ func (b *Bot) StartCommand(ctx *th.Context, _ telego.Update) error {
 	log := slog.With("func", "tgbot.StartCommand")
 	log.Debug("/start command")
 
-	err := b.router.ShowMainMenu(ctx)
+	err := b.user(ctx).router.ShowMainMenu(ctx)
 	if err != nil {
 		return err
 	}
@@ -3,47 +3,61 @@ package tgbot
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"github.com/arslanovdi/Gist/core/internal/adapters/in/tgbot/router"
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"github.com/arslanovdi/Gist/core/internal/infra/logger"
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
 	"golang.ngrok.com/ngrok/v2"
 )
 
+// UserService контракт управления пользователями бота и их аккаунтами Telegram.
+type UserService interface {
+	Allowed(userID int64) bool                                                                      // Пользователю разрешено работать с ботом
+	CoreService(userID int64) (router.CoreService, bool)                                            // Слой бизнес-логики пользователя, false - аккаунт не подключен
+	Login(ctx context.Context, userID int64, phone string, authenticator model.Authenticator) error // Подключение аккаунта Telegram пользователя
+}
+
 // Bot основной тип для работы с Telegram ботом.
 // Инкапсулирует всю логику взаимодействия с Telegram API и управления состоянием бота.
 type Bot struct {
-	cfg           *config.Config // Конфигурация приложения
-	allowedUserID int64          // ID пользователя, которому разрешено взаимодействие с ботом
-
-	// LastMessageID int // id редактируемого сообщения. В боте всегда одно сообщение, которое мы редактируем.
+	cfg  *config.Config // Конфигурация приложения
+	mode string         // Способ получения обновлений: ModeNgrok, ModeWebhook, ModePolling
 
-	// параметры ngrok туннеля
+	// параметры вебхука и ngrok туннеля
 	srv   *http.Server
 	agent ngrok.Agent
 	tun   ngrok.EndpointListener
 
+	stopPolling context.CancelFunc // Остановка long polling
+
 	bot     *telego.Bot // параметры телеграм бота
 	bh      *th.BotHandler
 	updates <-chan telego.Update
+	started atomic.Bool // Получение обновлений запущено
 
 	wg *sync.WaitGroup // Контроль запущенных горутин (веб-сервер)
 
-	coreService router.CoreService // Слой бизнес-логики
+	users UserService // Пользователи бота, у каждого свой слой бизнес-логики
+
+	sessionsMu sync.Mutex
+	sessions   map[int64]*userSession // Состояние меню пользователей, по ID пользователя
 
-	router *router.CallbackRouter // Роутер меню телеграм бота
+	loginsMu sync.Mutex
+	logins   map[int64]*login // Незавершенные подключения аккаунтов, по ID пользователя
 }
 
 // New создает и инициализирует новый экземпляр Telegram бота.
 // Принимает:
 //   - cfg: конфигурация приложения
-//   - coreService: сервис ядра приложения для обработки бизнес-логики
+//   - users: пользователи бота, у каждого свой сервис ядра приложения для обработки бизнес-логики
 //
 // Возвращает:
 //   - *Bot: инициализированный экземпляр бота
@@ -51,50 +65,63 @@ type Bot struct {
 //
 // Выполняет:
 //   - Создание бота с указанным токеном
-//   - Инициализацию ngrok-агента для туннелирования
-//   - Создание HTTP-сервера для вебхуков
-//   - Настройку роутера колбэков
-func New(cfg *config.Config, coreService router.CoreService) (*Bot, error) {
+//   - Инициализацию ngrok-агента для туннелирования (режим ModeNgrok)
+//   - Создание HTTP-сервера для вебхуков (режимы ModeNgrok и ModeWebhook)
+func New(cfg *config.Config, users UserService) (*Bot, error) {
 	log := slog.With("func", "bot.New")
 	log.Info("Initializing bot")
 
 	// создаем бота
-	bot, errB := telego.NewBot(cfg.Bot.Token,
-		telego.WithDefaultDebugLogger()) // TODO change DefaultDebugLogger Note: Please keep in mind that default logger may expose sensitive information, use in development only
+	bot, errB := telego.NewBot(cfg.Bot.Token.Value(),
+		telego.WithLogger(newBotLogger())) // Запросы к Bot API содержат токен бота и секрет вебхука, они маскируются в логе
 	if errB != nil {
 		return nil, fmt.Errorf("[bot.New] bot initialization failed: %w", errB)
 	}
-
-	// создаем агента
-	// TODO после получения хостинга / белого IP + сертификата: отказ от ngrok?
-	agent, errA := ngrok.NewAgent(ngrok.WithAuthtoken(cfg.Bot.NgrokAuthToken))
-	if errA != nil {
-		return nil, fmt.Errorf("[bot.New] ngrok agent initialization failed: %w", errA)
+	logger.AddSecrets(bot.SecretToken())
+
+	b := &Bot{
+		bot:      bot,
+		cfg:      cfg,
+		mode:     cfg.Bot.Mode,
+		users:    users,
+		wg:       &sync.WaitGroup{},
+		sessions: make(map[int64]*userSession),
+		logins:   make(map[int64]*login),
+	}
+	if b.mode == "" {
+		b.mode = ModeNgrok
 	}
 
-	// Создаем сервер, для обработки запросов вебхука Telegram
+	switch b.mode {
+	case ModePolling:
+	case ModeWebhook:
+		if cfg.Bot.WebhookURL == "" || cfg.Bot.ListenAddress == "" {
+			return nil, fmt.Errorf("[bot.New] webhook mode requires bot.webhook_url and bot.listen_address")
+		}
+	case ModeNgrok:
+		// создаем агента
+		agent, errA := ngrok.NewAgent(ngrok.WithAuthtoken(cfg.Bot.NgrokAuthToken.Value()))
+		if errA != nil {
+			return nil, fmt.Errorf("[bot.New] ngrok agent initialization failed: %w", errA)
+		}
+		b.agent = agent
+	default:
+		return nil, fmt.Errorf("[bot.New] unknown bot mode %q, expected %s, %s or %s", b.mode, ModeNgrok, ModeWebhook, ModePolling)
+	}
 
-	srv := &http.Server{
-		ReadHeaderTimeout: cfg.Bot.ReadHeaderTimeout,
+	if b.mode != ModePolling {
+		// Создаем сервер, для обработки запросов вебхука Telegram
+		b.srv = &http.Server{
+			ReadHeaderTimeout: cfg.Bot.ReadHeaderTimeout,
+		}
 	}
 
-	return &Bot{
-		bot:           bot,
-		srv:           srv,
-		agent:         agent,
-		cfg:           cfg,
-		coreService:   coreService,
-		wg:            &sync.WaitGroup{},
-		allowedUserID: cfg.Client.UserID,
-		router:        router.NewCallbackRouter(),
-	}, nil
+	return b, nil
 }
 
-// Run запускает Telegram-бота, начиная обработку вебхуков и обновлений.
+// Run запускает Telegram-бота, начиная получение и обработку обновлений.
 // Инициализирует и запускает все необходимые компоненты для работы бота:
-//   - Создает ngrok-туннель для доступа к вебхукам
-//   - Запускает HTTP-сервер для приема обновлений
-//   - Регистрирует вебхук в Telegram API
+//   - Запускает получение обновлений в режиме, заданном в конфигурации (bot.mode): ngrok-туннель, собственный вебхук или long polling
 //   - Инициализирует обработчики команд и колбэков
 //
 // Принимает:
@@ -110,51 +137,30 @@ func (b *Bot) Run(ctx context.Context, serverErr chan error) {
 		return
 	}
 
-	// создаем туннель
-	var errT error
-
-	b.tun, errT = b.agent.Listen(ctx,
-		ngrok.WithURL(b.cfg.Bot.NgrokDomain))
-	if errT != nil {
-		serverErr <- fmt.Errorf("[bot.Run] ngrok listen failed: %w", errT)
-		return
+	var errU error
+	switch b.mode {
+	case ModePolling:
+		b.updates, errU = b.updatesViaLongPolling(ctx)
+	case ModeWebhook:
+		b.updates, errU = b.updatesViaWebhook(ctx, serverErr)
+	default:
+		b.updates, errU = b.updatesViaNgrok(ctx, serverErr)
 	}
-
-	// Запускаем http сервер на ngrok-туннеле для получения запросов от Telegram
-	b.wg.Go(func() {
-		errS := b.srv.Serve(b.tun)
-		if errS != nil {
-			if !errors.Is(errS, http.ErrServerClosed) {
-				serverErr <- fmt.Errorf("[bot.Run] bot webhook serve failed: %w", errS)
-			}
-		}
-	})
-
-	// Регистрируем вебхук, получаем канал обновлений, используя Ngrok
-	var errB error
-	b.updates, errB = b.bot.UpdatesViaWebhook(ctx,
-		// Use net/http webhook server
-		telego.WebhookHTTPServer(b.srv, "/bot", b.bot.SecretToken()),
-		// Calls SetWebhook before starting webhook and provide dynamic Ngrok tunnel URL
-		telego.WithWebhookSet(ctx, &telego.SetWebhookParams{
-			URL:         b.tun.URL().String() + "/bot",
-			SecretToken: b.bot.SecretToken(),
-		}),
-	)
-	if errB != nil {
-		serverErr <- fmt.Errorf("[bot.Run] bot updates via webhook failed: %w", errB)
+	if errU != nil {
+		serverErr <- fmt.Errorf("[bot.Run] %s: %w", b.mode, errU)
 		return
 	}
 
 	b.RegisterHandlers(ctx, serverErr) // Регистрируем все обработчики
+	b.started.Store(true)
 
-	log.Info("bot started")
+	log.Info("bot started", slog.String("mode", b.mode))
 }
 
 // Close корректно завершает работу Telegram бота, освобождая все ресурсы.
 // Принимает контекст для управления дедлайном завершения операций.
 // Последовательно:
-//   - Удаляет вебхук
+//   - Удаляет вебхук или останавливает long polling
 //   - Останавливает обработку обновлений
 //   - Выключает HTTP-сервер
 //   - Закрывает ngrok-туннель
@@ -166,24 +172,36 @@ func (b *Bot) Close(ctx context.Context) {
 	log := slog.With("func", "bot.Close")
 	log.Info("Stopping...")
 
-	errD := b.bot.DeleteWebhook(ctx, &telego.DeleteWebhookParams{})
-	if errD != nil {
-		log.Error("Delete webhook error", slog.Any("error", errD))
+	if b.mode == ModePolling {
+		if b.stopPolling != nil {
+			b.stopPolling() // Закрывает канал обновлений
+		}
+	} else {
+		errD := b.bot.DeleteWebhook(ctx, &telego.DeleteWebhookParams{})
+		if errD != nil {
+			log.Error("Delete webhook error", slog.Any("error", errD))
+		}
 	}
 
-	errH := b.bh.Stop()
-	if errH != nil {
-		log.Error("Error stopping handling of updates", slog.Any("error", errH))
+	if b.bh != nil {
+		errH := b.bh.Stop()
+		if errH != nil {
+			log.Error("Error stopping handling of updates", slog.Any("error", errH))
+		}
 	}
 
-	errS := b.srv.Shutdown(ctx)
-	if errS != nil {
-		log.Error("Error shutting down server", slog.Any("error", errS))
+	if b.srv != nil {
+		errS := b.srv.Shutdown(ctx)
+		if errS != nil {
+			log.Error("Error shutting down server", slog.Any("error", errS))
+		}
 	}
 
-	errT := b.tun.CloseWithContext(ctx)
-	if errT != nil {
-		log.Error("Error shutting down tunnel", slog.Any("error", errT))
+	if b.tun != nil {
+		errT := b.tun.CloseWithContext(ctx)
+		if errT != nil {
+			log.Error("Error shutting down tunnel", slog.Any("error", errT))
+		}
 	}
 
 	// TODO Метод Close нельзя вызывать раньше чем через 10 минут после запуска.! Подумать для чего он вообще вызывается.
@@ -0,0 +1,159 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"golang.ngrok.com/ngrok/v2"
+)
+
+// Способы получения обновлений от Telegram, задаются в bot.mode
+const (
+	ModeNgrok   = "ngrok"   // Вебхук через ngrok-туннель, по умолчанию
+	ModeWebhook = "webhook" // Вебхук на собственном адресе, с TLS или за reverse proxy
+	ModePolling = "polling" // Long polling, не требует входящих соединений
+)
+
+const webhookPath = "/bot"
+
+const webhookErrorWindow = 10 * time.Minute // Ошибка доставки обновлений на вебхук за это время считается текущей
+
+// updatesViaNgrok создает ngrok-туннель, запускает на нем HTTP-сервер и регистрирует вебхук с адресом туннеля.
+func (b *Bot) updatesViaNgrok(ctx context.Context, serverErr chan error) (<-chan telego.Update, error) {
+	// TODO после получения хостинга / белого IP + сертификата: отказ от ngrok?
+	var errT error
+
+	b.tun, errT = b.agent.Listen(ctx,
+		ngrok.WithURL(b.cfg.Bot.NgrokDomain))
+	if errT != nil {
+		return nil, fmt.Errorf("ngrok listen failed: %w", errT)
+	}
+
+	// Запускаем http сервер на ngrok-туннеле для получения запросов от Telegram
+	b.serve(serverErr, func() error { return b.srv.Serve(b.tun) })
+
+	return b.bot.UpdatesViaWebhook(ctx,
+		// Use net/http webhook server
+		telego.WebhookHTTPServer(b.srv, webhookPath, b.bot.SecretToken()),
+		// Calls SetWebhook before starting webhook and provide dynamic Ngrok tunnel URL
+		telego.WithWebhookSet(ctx, &telego.SetWebhookParams{
+			URL:         b.tun.URL().String() + webhookPath,
+			SecretToken: b.bot.SecretToken(),
+		}),
+	)
+}
+
+// updatesViaWebhook запускает HTTP-сервер на bot.listen_address и регистрирует вебхук с адресом bot.webhook_url.
+// Если заданы tls_cert_file и tls_key_file, сервер работает по HTTPS, иначе TLS завершается на reverse proxy.
+func (b *Bot) updatesViaWebhook(ctx context.Context, serverErr chan error) (<-chan telego.Update, error) {
+	cfg := b.cfg.Bot
+
+	params := &telego.SetWebhookParams{
+		URL:         strings.TrimSuffix(cfg.WebhookURL, "/") + webhookPath,
+		SecretToken: b.bot.SecretToken(),
+	}
+	if cfg.UploadCertificate { // Самоподписанный сертификат передается Telegram при регистрации вебхука
+		cert, errO := os.Open(cfg.TLSCertFile)
+		if errO != nil {
+			return nil, fmt.Errorf("open webhook certificate: %w", errO)
+		}
+		defer func() { _ = cert.Close() }()
+		certificate := tu.File(cert)
+		params.Certificate = &certificate
+	}
+
+	listener, errL := net.Listen("tcp", cfg.ListenAddress)
+	if errL != nil {
+		return nil, fmt.Errorf("webhook listen failed: %w", errL)
+	}
+
+	updates, errU := b.bot.UpdatesViaWebhook(ctx,
+		telego.WebhookHTTPServer(b.srv, webhookPath, b.bot.SecretToken()),
+		telego.WithWebhookSet(ctx, params),
+	)
+	if errU != nil {
+		_ = listener.Close()
+		return nil, errU
+	}
+
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		b.serve(serverErr, func() error { return b.srv.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile) })
+	} else {
+		b.serve(serverErr, func() error { return b.srv.Serve(listener) })
+	}
+
+	return updates, nil
+}
+
+// updatesViaLongPolling удаляет вебхук, если он был зарегистрирован, и запускает long polling.
+func (b *Bot) updatesViaLongPolling(ctx context.Context) (<-chan telego.Update, error) {
+	// Telegram не отдает обновления через getUpdates, пока зарегистрирован вебхук
+	if errD := b.bot.DeleteWebhook(ctx, &telego.DeleteWebhookParams{}); errD != nil {
+		return nil, fmt.Errorf("delete webhook: %w", errD)
+	}
+
+	ctxPolling, cancel := context.WithCancel(ctx)
+	b.stopPolling = cancel
+
+	timeout := int(b.cfg.Bot.PollingTimeout.Seconds())
+	if timeout <= 0 {
+		timeout = 30
+	}
+
+	updates, errU := b.bot.UpdatesViaLongPolling(ctxPolling, &telego.GetUpdatesParams{Timeout: timeout})
+	if errU != nil {
+		cancel()
+		return nil, errU
+	}
+
+	return updates, nil
+}
+
+// serve запускает HTTP-сервер вебхука в отдельной горутине.
+func (b *Bot) serve(serverErr chan error, serve func() error) {
+	b.wg.Go(func() {
+		errS := serve()
+		if errS != nil {
+			if !errors.Is(errS, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("[bot.Run] bot webhook serve failed: %w", errS)
+			}
+		}
+	})
+}
+
+// CheckUpdates проверка получения обновлений: в режиме вебхука у Telegram зарегистрирован ожидаемый адрес
+// и нет недавних ошибок доставки.
+func (b *Bot) CheckUpdates(ctx context.Context) error {
+	if !b.started.Load() {
+		return errors.New("updates are not started")
+	}
+	if b.mode == ModePolling {
+		return nil
+	}
+
+	info, errW := b.bot.GetWebhookInfo(ctx)
+	if errW != nil {
+		return fmt.Errorf("get webhook info: %w", errW)
+	}
+
+	expected := strings.TrimSuffix(b.cfg.Bot.WebhookURL, "/") + webhookPath
+	if b.mode == ModeNgrok {
+		expected = b.tun.URL().String() + webhookPath
+	}
+	if info.URL != expected {
+		return fmt.Errorf("webhook url %q, expected %q", info.URL, expected)
+	}
+
+	if info.LastErrorDate != 0 && time.Since(time.Unix(info.LastErrorDate, 0)) < webhookErrorWindow {
+		return fmt.Errorf("webhook delivery error: %s", info.LastErrorMessage)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package tgbot
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/arslanovdi/Gist/core/internal/adapters/in/tgbot/router"
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// userSessionKey ключ сессии пользователя в контексте обработчика.
+type userSessionKey struct{}
+
+// userSession состояние меню пользователя бота с подключенным аккаунтом Telegram.
+type userSession struct {
+	base   *router.BaseHandler    // Общие зависимости обработчиков меню
+	router *router.CallbackRouter // Роутер меню пользователя
+}
+
+// userSession возвращает сессию пользователя, создает при первом обращении. false - аккаунт Telegram пользователя не подключен.
+// После повторного подключения аккаунта сессия создается заново.
+func (b *Bot) userSession(userID int64) (*userSession, bool) {
+	coreService, ok := b.users.CoreService(userID)
+	if !ok {
+		return nil, false
+	}
+
+	b.sessionsMu.Lock()
+	defer b.sessionsMu.Unlock()
+
+	s, ok := b.sessions[userID]
+	if !ok || s.base.CoreService != coreService {
+		uiSession := router.NewUISession()
+		if ok { // Аккаунт переподключен, экраны в сообщениях бота остаются
+			uiSession = s.base.Session
+		}
+		base := &router.BaseHandler{
+			Bot:         b.bot,
+			CoreService: coreService,
+			Session:     uiSession,
+			UserID:      userID,
+		}
+		s = &userSession{base: base, router: newUserRouter(base)}
+		b.sessions[userID] = s
+	}
+
+	return s, true
+}
+
+// user возвращает сессию пользователя, от которого пришло обновление. Сессию в контекст добавляет authorizedOnlyMiddleware.
+func (b *Bot) user(ctx context.Context) *userSession {
+	s, _ := ctx.Value(userSessionKey{}).(*userSession)
+	return s
+}
+
+// UserNotifier оповещения пользователя бота. Реализация интерфейсов core.JobNotifier и core.AlertNotifier
+type UserNotifier struct {
+	bot    *Bot
+	userID int64
+}
+
+// Notifier возвращает оповещения пользователя бота.
+func (b *Bot) Notifier(userID int64) *UserNotifier {
+	return &UserNotifier{bot: b, userID: userID}
+}
+
+// JobFinished оповещение пользователя о завершении фоновой задачи.
+func (n *UserNotifier) JobFinished(ctx context.Context, job model.Job) {
+	s, ok := n.bot.userSession(n.userID)
+	if !ok {
+		slog.With("func", "tgbot.JobFinished").Debug("user disconnected", slog.Int64("user_id", n.userID))
+		return
+	}
+	s.base.NotifyJobFinished(ctx, job)
+}
+
+// WatchAlert оповещение пользователя о сообщении из списка наблюдения.
+func (n *UserNotifier) WatchAlert(ctx context.Context, alert model.WatchAlert) {
+	s, ok := n.bot.userSession(n.userID)
+	if !ok {
+		slog.With("func", "tgbot.WatchAlert").Debug("user disconnected", slog.Int64("user_id", n.userID))
+		return
+	}
+	s.base.NotifyWatchAlert(ctx, alert)
+}
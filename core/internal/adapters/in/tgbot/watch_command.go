@@ -0,0 +1,120 @@
+package tgbot
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+const watchUsage = `Использование:
+/watch [-re|-mention] [-chat] шаблон
+ -re — шаблон регулярное выражение
+ -mention — упоминание @username, без шаблона — упоминание вас
+ -chat — только в открытом в боте чате, иначе во всех чатах
+/unwatch ID — удалить правило
+/watchlist — список правил`
+
+// WatchCommand обрабатывает команду /watch - добавление правила в список наблюдения.
+func (b *Bot) WatchCommand(ctx *th.Context, message telego.Message) error {
+	_, _, payload := tu.ParseCommandPayload(message.Text)
+
+	rule := model.WatchRule{Kind: model.WatchKeyword}
+	for { // Разбор флагов в начале аргументов, остаток - шаблон
+		payload = strings.TrimSpace(payload)
+		flag, rest, _ := strings.Cut(payload, " ")
+		switch flag {
+		case "-re":
+			rule.Kind = model.WatchRegex
+		case "-mention":
+			rule.Kind = model.WatchMention
+		case "-chat":
+			openChatID := b.user(ctx).base.Session.OpenChatID()
+			if openChatID == 0 {
+				return b.reply(ctx, message, "⚠️ Откройте чат в боте, чтобы добавить правило только для него")
+			}
+			rule.ChatID = openChatID
+		default:
+			rule.Pattern = payload
+			return b.addWatchRule(ctx, message, rule)
+		}
+		payload = rest
+	}
+}
+
+// addWatchRule добавляет правило и сообщает пользователю результат.
+func (b *Bot) addWatchRule(ctx *th.Context, message telego.Message, rule model.WatchRule) error {
+	log := slog.With("func", "tgbot.addWatchRule")
+
+	if rule.Pattern == "" && rule.Kind != model.WatchMention {
+		return b.reply(ctx, message, watchUsage)
+	}
+
+	added, errA := b.user(ctx).base.CoreService.AddWatchRule(ctx, rule)
+	if errA != nil {
+		log.Error("add watch rule error", slog.Any("error", errA))
+		return b.reply(ctx, message, fmt.Sprintf("❌ Правило не добавлено: %v\n\n%s", errA, watchUsage))
+	}
+
+	return b.reply(ctx, message, fmt.Sprintf("👁 Правило #%d добавлено: %s, %s", added.ID, added, b.watchScope(ctx, added)))
+}
+
+// UnwatchCommand обрабатывает команду /unwatch - удаление правила из списка наблюдения.
+func (b *Bot) UnwatchCommand(ctx *th.Context, message telego.Message) error {
+	_, _, args := tu.ParseCommand(message.Text)
+	if len(args) != 1 {
+		return b.reply(ctx, message, watchUsage)
+	}
+
+	ruleID, errP := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if errP != nil {
+		return b.reply(ctx, message, watchUsage)
+	}
+
+	if errR := b.user(ctx).base.CoreService.RemoveWatchRule(ctx, ruleID); errR != nil {
+		return b.reply(ctx, message, fmt.Sprintf("❌ Правило #%d не удалено: %v", ruleID, errR))
+	}
+
+	return b.reply(ctx, message, fmt.Sprintf("🗑 Правило #%d удалено", ruleID))
+}
+
+// WatchlistCommand обрабатывает команду /watchlist - вывод списка наблюдения.
+func (b *Bot) WatchlistCommand(ctx *th.Context, message telego.Message) error {
+	rules := b.user(ctx).base.CoreService.GetWatchRules(ctx)
+	if len(rules) == 0 {
+		return b.reply(ctx, message, "👁 Список наблюдения пуст\n\n"+watchUsage)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "👁 Список наблюдения (%d шт.)\n", len(rules))
+	for _, rule := range rules {
+		fmt.Fprintf(&text, "\n#%d %s, %s", rule.ID, rule, b.watchScope(ctx, rule))
+	}
+
+	return b.reply(ctx, message, text.String())
+}
+
+// watchScope описание области действия правила.
+func (b *Bot) watchScope(ctx *th.Context, rule model.WatchRule) string {
+	if rule.ChatID == 0 {
+		return "во всех чатах"
+	}
+	if chat, errD := b.user(ctx).base.CoreService.GetChatDetail(ctx, rule.ChatID); errD == nil {
+		return fmt.Sprintf("в чате «%s»", chat.Title)
+	}
+	return fmt.Sprintf("в чате %d", rule.ChatID)
+}
+
+// reply отправляет ответ на сообщение пользователя.
+func (b *Bot) reply(ctx *th.Context, message telego.Message, text string) error {
+	_, err := b.bot.SendMessage(ctx, tu.Message(tu.ID(message.Chat.ID), text))
+	if err != nil {
+		return fmt.Errorf("send reply error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/openai/openai-go"
+)
+
+// Ссылка на сообщение в ответе LLM, формат [#ID].
+var citationRe = regexp.MustCompile(`\[#(\d+)\]`)
+
+// Тип входных данных для запроса к LLM в режиме вопрос-ответ.
+type question struct {
+	Question string          `json:"question"`
+	Messages []model.Message `json:"messages"`
+}
+
+// AnswerQuestion выполняет запрос к LLM - сценарий answerQuestionFlow. Отвечает на вопрос по переданным сообщениям чата.
+func (s *GenkitService) AnswerQuestion(ctx context.Context, text string, messages []model.Message) (*model.Answer, error) {
+	log := slog.With("func", "llm.AnswerQuestion")
+	log.Debug("answer question start", slog.Int("message count", len(messages)))
+
+	ctxFlow, cancel := context.WithTimeout(ctx, s.cfg.Live().PromptTimeout)
+	defer cancel()
+
+	resp, errR := s.answerQuestionFlow.Run(ctxFlow, &question{Question: text, Messages: messages})
+	if errR != nil {
+		return nil, fmt.Errorf("llm.AnswerQuestion: %w", errR)
+	}
+
+	return &model.Answer{
+		Text:      resp,
+		Citations: parseCitations(resp, messages),
+	}, nil
+}
+
+// parseCitations извлекает из ответа ссылки на сообщения. Ссылки на сообщения, которых не было во входных данных, отбрасываются.
+func parseCitations(text string, messages []model.Message) []model.Citation {
+	citations := make([]model.Citation, 0)
+	seen := make(map[int]struct{})
+
+	for _, match := range citationRe.FindAllStringSubmatch(text, -1) {
+		id, errA := strconv.Atoi(match[1])
+		if errA != nil {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		for i := range messages {
+			if messages[i].ID == id {
+				citations = append(citations, model.Citation{MessageID: id, Timestamp: messages[i].Timestamp})
+				seen[id] = struct{}{}
+				break
+			}
+		}
+	}
+
+	return citations
+}
+
+// defineAnswerQuestionFlow определяет сценарий ответа на вопрос по сообщениям чата.
+func (s *GenkitService) defineAnswerQuestionFlow() {
+
+	config := &openai.ChatCompletionNewParams{ //конфигурация для OpenRouter provider (OpenAI compatible), для других провайдеров нужно изменять!
+		Temperature: openai.Float(0.1), // Ответ должен опираться только на факты из сообщений
+	}
+
+	prompt := `Роль: Ты — ассистент, который отвечает на вопросы по истории чата в Telegram.
+
+Входные данные:
+Вопрос пользователя: {{question}}
+Сообщения чата, отобранные по вопросу (в хронологическом порядке, формат JSON): {{messages}}
+
+Инструкции:
+1. Отвечай только на основе переданных сообщений. Не додумывай факты.
+2. Учитывай поле reply_to_msg_id, чтобы понимать, на какое сообщение дан ответ.
+3. После каждого утверждения ставь ссылку на подтверждающее сообщение в формате [#id], где id — поле id сообщения. Можно несколько ссылок подряд.
+4. Если в сообщениях нет ответа на вопрос, прямо так и скажи.
+5. Не используй числовые sender_id в тексте ответа, пиши «один из участников», «другой участник».
+6. Отвечай кратко, не более 1500 символов, на языке вопроса.
+`
+
+	answerQuestionPrompt := genkit.DefinePrompt(s.g, "answerQuestionPrompt",
+		ai.WithPrompt(prompt),
+		ai.WithInputType(question{}),
+		ai.WithOutputFormat(ai.OutputFormatText),
+		ai.WithConfig(config),
+		ai.WithModelName(s.DefaultTextModel), // Используем дефолтную модель провайдер по умолчанию, заданного в конфигурации
+	)
+
+	s.answerQuestionFlow = genkit.DefineFlow(s.g, "answerQuestionFlow", func(ctx context.Context, input *question) (string, error) {
+		log := slog.With("func", "answerQuestionFlow")
+
+		// выполняем простой запрос с Retry wrapper для обработки 429
+		modelName, modelOptions := s.modelOptions(ctx)
+		resp, err := s.retryPrompt(ctx, answerQuestionPrompt, modelName, input, log, modelOptions...)
+		if err != nil {
+			return "", fmt.Errorf("answerQuestionFlow.answerQuestionPrompt: %w", err)
+		}
+		model.RecordUsage(ctx, s.usage(modelName, resp, 0))
+
+		log.Debug("ответ от llm", slog.String("resp.Text()", resp.Text()))
+
+		return resp.Text(), nil
+	})
+}
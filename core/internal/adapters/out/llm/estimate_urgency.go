@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/openai/openai-go"
+)
+
+// Оценка срочности в ответе LLM - первое число.
+var urgencyRe = regexp.MustCompile(`\d+`)
+
+// Тип входных данных для запроса к LLM на оценку срочности.
+type urgencyInput struct {
+	Messages []model.Message `json:"messages"`
+}
+
+// EstimateUrgency выполняет запрос к LLM - сценарий estimateUrgencyFlow. Оценивает срочность сообщений по шкале 1..10.
+func (s *GenkitService) EstimateUrgency(ctx context.Context, messages []model.Message) (int, error) {
+	log := slog.With("func", "llm.EstimateUrgency")
+	log.Debug("estimate urgency start", slog.Int("message count", len(messages)))
+
+	ctxFlow, cancel := context.WithTimeout(ctx, s.cfg.Live().PromptTimeout)
+	defer cancel()
+
+	urgency, errR := s.estimateUrgencyFlow.Run(ctxFlow, &urgencyInput{Messages: messages})
+	if errR != nil {
+		return 0, fmt.Errorf("llm.EstimateUrgency: %w", errR)
+	}
+
+	return urgency, nil
+}
+
+// parseUrgency извлекает оценку из ответа LLM и приводит ее к диапазону 1..10.
+func parseUrgency(text string) (int, error) {
+	match := urgencyRe.FindString(text)
+	if match == "" {
+		return 0, fmt.Errorf("no urgency in response: %q", text)
+	}
+
+	urgency, errA := strconv.Atoi(match)
+	if errA != nil {
+		return 0, errA
+	}
+
+	return min(max(urgency, 1), 10), nil
+}
+
+// defineEstimateUrgencyFlow определяет сценарий оценки срочности непрочитанных сообщений чата.
+func (s *GenkitService) defineEstimateUrgencyFlow() {
+
+	config := &openai.ChatCompletionNewParams{ //конфигурация для OpenRouter provider (OpenAI compatible), для других провайдеров нужно изменять!
+		Temperature: openai.Float(0),
+	}
+
+	prompt := `Роль: Ты — ассистент, который помогает решить, какие чаты в Telegram прочитать в первую очередь.
+
+Входные данные:
+Последние непрочитанные сообщения чата (в хронологическом порядке, формат JSON): {{messages}}
+
+Инструкции:
+1. Оцени, насколько срочно пользователю нужно прочитать эти сообщения, по шкале от 1 до 10.
+2. 10 — требуется немедленное действие или ответ пользователя (вопрос к нему, срочная проблема, дедлайн сегодня).
+3. 5 — важная информация, но можно прочитать позже.
+4. 1 — болтовня, реклама, новости без действия со стороны пользователя.
+5. Поля mentioned и reply_to_me означают, что сообщение адресовано пользователю, это повышает срочность.
+6. Ответь только одним целым числом, без пояснений.
+`
+
+	estimateUrgencyPrompt := genkit.DefinePrompt(s.g, "estimateUrgencyPrompt",
+		ai.WithPrompt(prompt),
+		ai.WithInputType(urgencyInput{}),
+		ai.WithOutputFormat(ai.OutputFormatText),
+		ai.WithConfig(config),
+		ai.WithModelName(s.DefaultTextModel),
+	)
+
+	s.estimateUrgencyFlow = genkit.DefineFlow(s.g, "estimateUrgencyFlow", func(ctx context.Context, input *urgencyInput) (int, error) {
+		log := slog.With("func", "estimateUrgencyFlow")
+
+		modelName, modelOptions := s.modelOptions(ctx)
+		resp, err := s.retryPrompt(ctx, estimateUrgencyPrompt, modelName, input, log, modelOptions...)
+		if err != nil {
+			return 0, fmt.Errorf("estimateUrgencyFlow.estimateUrgencyPrompt: %w", err)
+		}
+		model.RecordUsage(ctx, s.usage(modelName, resp, 0))
+
+		log.Debug("ответ от llm", slog.String("resp.Text()", resp.Text()))
+
+		urgency, errP := parseUrgency(resp.Text())
+		if errP != nil {
+			return 0, fmt.Errorf("estimateUrgencyFlow: %w", errP)
+		}
+
+		return urgency, nil
+	})
+}
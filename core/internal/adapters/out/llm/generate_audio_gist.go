@@ -12,6 +12,7 @@ import (
 	"github.com/arslanovdi/Gist/core/internal/adapters/out/llm/tts"
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/arslanovdi/Gist/core/internal/infra/ffmpeg"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
 	"github.com/arslanovdi/Gist/core/internal/infra/utils"
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
@@ -19,6 +20,7 @@ import (
 )
 
 type Params struct {
+	Dir          string `json:"dir,omitempty"`      // Каталог файлов аудиопересказа пользователя
 	Filename     string `json:"filename,omitempty"` // Имя файла, в который сохраняем аудиопересказ батча
 	LanguageCode string `json:"language_code,omitempty"`
 	VoiceName    string `json:"voice_name,omitempty"`
@@ -28,7 +30,8 @@ type Params struct {
 // GenerateAudioGist выполняет запрос к LLM - сценарий GenerateAudioGistFlow для каждого батча.
 // Генерирует аудиопересказ чата, по батчам. Сохраняет в mp3 файлы. Имена файлов сохраняются в chat по указателю.
 // batchID - номер батча, для которого нужно сгенерировать аудиопересказ, если batchID = 0 генерируем аудиопересказы всех батчей, пропуская существующие.
-func (s *GenkitService) GenerateAudioGist(ctx context.Context, chat *model.Chat, batchID int) error {
+// dir - каталог, в который сохраняются файлы.
+func (s *GenkitService) GenerateAudioGist(ctx context.Context, chat *model.Chat, batchID int, dir string) error {
 
 	log := slog.With("func", "llm.GenerateAudioGist")
 
@@ -45,7 +48,9 @@ func (s *GenkitService) GenerateAudioGist(ctx context.Context, chat *model.Chat,
 		}
 	}()
 
-	ctxFlow, cancel := context.WithTimeout(ctx, s.flowTimeout) // Общий тайм-аут на обработку всех батчей
+	settings := s.requestSettings(ctx) // Голос и язык аудиопересказа
+
+	ctxFlow, cancel := context.WithTimeout(ctx, s.cfg.Live().FlowTimeout) // Общий тайм-аут на обработку всех батчей
 	defer cancel()
 
 	i := batchID
@@ -71,9 +76,10 @@ func (s *GenkitService) GenerateAudioGist(ctx context.Context, chat *model.Chat,
 
 		filename, errF := s.generateAudioGistFlow.Run(ctxFlow,
 			Params{
+				Dir:          dir,
 				Filename:     fmt.Sprintf("%d_%d", chat.ID, chat.Gist[i].LastMessageID),
-				LanguageCode: s.languageCode,
-				VoiceName:    s.voiceName,
+				LanguageCode: settings.TTSLanguage,
+				VoiceName:    settings.TTSVoice,
 				Prompt:       chat.Gist[i].Gist,
 			})
 		if errF != nil {
@@ -106,7 +112,7 @@ func (s *GenkitService) GenerateAudioGist(ctx context.Context, chat *model.Chat,
 		}
 
 		if fileSize > s.cfg.LLM.TTS.MaxAudioFileSize*1024*1024 { // Размер файла превышает максимально разрешенный
-			files, errT := ffmpeg.SplitMP3(filename, s.cfg.LLM.TTS.MaxAudioFileSize) // Разбиваем на несколько
+			files, errT := ffmpeg.SplitMP3(ctx, filename, s.cfg.LLM.TTS.MaxAudioFileSize) // Разбиваем на несколько
 			if errT != nil {
 				log.Error("Trim audiofile error", slog.String("filename", filename), slog.Any("error", errT))
 			}
@@ -154,31 +160,25 @@ func (s *GenkitService) defineGenerateAudioGistFlow() {
 		Text string `json:"text"`
 	}
 
+	ttsModel := "googleai/" + s.cfg.LLM.TTS.Gemini.Model // TODO для TTS жестко задан провайдер Google AI, если появится что-то другое унифицировать эту часть кода.
+
 	// Определяем простой запрос(prompt) generateAudioGistPrompt
 	generateAudioGistPrompt := genkit.DefinePrompt(s.g, "generateAudioGistPrompt", // TODO можно ли передавать параметры в prompt?
 		ai.WithPrompt("{{text}}"),
 		ai.WithInputType(promptInput{}),
 		ai.WithOutputFormat(ai.OutputFormatText), // выходные данные
-		ai.WithConfig(&genai.GenerateContentConfig{
-			Temperature:        genai.Ptr[float32](1.0),
-			ResponseModalities: []string{"AUDIO"},
-			SpeechConfig: &genai.SpeechConfig{
-				VoiceConfig: &genai.VoiceConfig{
-					PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{
-						VoiceName: s.voiceName,
-					},
-				},
-				LanguageCode: s.languageCode,
-			},
-		}),
-		ai.WithModelName("googleai/"+s.cfg.LLM.TTS.Gemini.Model), // TODO для TTS жестко задан провайдер Google AI, если появится что-то другое унифицировать эту часть кода.
+		ai.WithConfig(ttsConfig(s.cfg.LLM.TTS.Gemini.VoiceName, s.cfg.LLM.TTS.Gemini.LanguageCode)),
+		ai.WithModelName(ttsModel),
 	)
 
 	// Определяем сценарий, генерирующий аудио из текста.
 	s.generateAudioGistFlow = genkit.DefineFlow(s.g, "generateAudioGistFlow", func(ctx context.Context, input Params) (string, error) {
 
+		start := time.Now()
+
 		// выполняем простой запрос с Retry wrapper для обработки 429
-		resp, err := s.retryPrompt(ctx, generateAudioGistPrompt, promptInput{Text: input.Prompt}, log)
+		resp, err := s.retryPrompt(ctx, generateAudioGistPrompt, ttsModel, promptInput{Text: input.Prompt}, log,
+			ai.WithConfig(ttsConfig(input.VoiceName, input.LanguageCode))) // Голос пользователя вместо голоса из конфигурации
 		if err != nil {
 			return "", fmt.Errorf("generateAudioGistFlow.generateAudioGistPrompt: %w", err)
 		}
@@ -189,6 +189,11 @@ func (s *GenkitService) defineGenerateAudioGistFlow() {
 
 		// Парсим data URI и извлекаем PCM данные + sample rate
 		pcmData, sampleRate, err := tts.ParseDataURI(dataURI)
+		audioSeconds := 0.0
+		if err == nil && sampleRate > 0 {
+			audioSeconds = float64(len(pcmData)) / float64(2*sampleRate) // PCM 16 бит, моно
+		}
+		model.RecordUsage(ctx, s.usage(ttsModel, resp, audioSeconds))
 		if err != nil {
 			return "", err
 		}
@@ -205,7 +210,7 @@ func (s *GenkitService) defineGenerateAudioGistFlow() {
 		}
 
 		// Сохраняем WAV файл
-		wavPath := filepath.Join(s.cfg.Project.AudioPath, input.Filename+".wav")
+		wavPath := filepath.Join(input.Dir, input.Filename+".wav")
 		err = os.WriteFile(wavPath, wavData, 0644)
 		if err != nil {
 			return "", err
@@ -214,8 +219,8 @@ func (s *GenkitService) defineGenerateAudioGistFlow() {
 		log.Debug("WAV file saved", slog.String("file", wavPath), slog.Int("size", len(wavData)))
 
 		// Конвертируем WAV в mp3
-		mp3path := filepath.Join(s.cfg.Project.AudioPath, input.Filename+".mp3")
-		errM := ffmpeg.ConvertWavToMp3(wavPath, mp3path)
+		mp3path := filepath.Join(input.Dir, input.Filename+".mp3")
+		errM := ffmpeg.ConvertWavToMp3(ctx, wavPath, mp3path)
 		if errM != nil {
 			return "", errM
 		}
@@ -223,9 +228,29 @@ func (s *GenkitService) defineGenerateAudioGistFlow() {
 		// удаляем wav файл
 		errR := os.Remove(wavPath)
 		if errR != nil {
-			log.Error("error removing temp WAV file", errR)
+			log.Error("error removing temp WAV file", slog.Any("error", errR))
+		}
+
+		if info, errS := os.Stat(mp3path); errS == nil {
+			metrics.ObserveTTS(time.Since(start), info.Size())
 		}
 
 		return mp3path, nil
 	})
 }
+
+// ttsConfig конфигурация запроса синтеза речи с голосом voiceName на языке languageCode.
+func ttsConfig(voiceName, languageCode string) *genai.GenerateContentConfig {
+	return &genai.GenerateContentConfig{
+		Temperature:        genai.Ptr[float32](1.0),
+		ResponseModalities: []string{"AUDIO"},
+		SpeechConfig: &genai.SpeechConfig{
+			VoiceConfig: &genai.VoiceConfig{
+				PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{
+					VoiceName: voiceName,
+				},
+			},
+			LanguageCode: languageCode,
+		},
+	}
+}
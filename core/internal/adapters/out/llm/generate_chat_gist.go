@@ -7,19 +7,29 @@ import (
 	"log/slog"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/core"
 	"github.com/firebase/genkit/go/genkit"
 	"github.com/openai/openai-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Тип входных данных для запроса к LLM.
 type chat struct {
-	Messages []model.Message `json:"messages"`
+	Messages     []model.Message `json:"messages"`
+	Instructions string          `json:"instructions"` // Требования к стилю и объему пересказа из настроек пользователя
+}
+
+// Ход выполнения сценария generateChatGistStreamingFlow.
+type gistProgress struct {
+	Percent int              `json:"percent"`         // Процент обработанных сообщений
+	Batch   *model.BatchGist `json:"batch,omitempty"` // Пересказ только что обработанного батча
 }
 
 // GenerateChatGist выполняет запрос к LLM - сценарий generateChatGistStreamingFlow. callback - функция для оповещения пользователя о процессе выполнения.
-func (s *GenkitService) GenerateChatGist(ctx context.Context, messages []model.Message, callback func(message string, progress int, llm bool)) ([]model.BatchGist, error) {
+// onBatch вызывается по завершении каждого батча, позволяет сохранить результат до завершения всего сценария.
+func (s *GenkitService) GenerateChatGist(ctx context.Context, messages []model.Message, onBatch func(batch model.BatchGist), callback func(message string, progress int, llm bool)) ([]model.BatchGist, error) {
 
 	log := slog.With("func", "llm.GenerateChatGist")
 	log.Debug("get chat gist start", slog.Int("message count", len(messages)))
@@ -30,21 +40,25 @@ func (s *GenkitService) GenerateChatGist(ctx context.Context, messages []model.M
 		}
 	}()
 
-	ctxFlow, cancel := context.WithTimeout(ctx, s.flowTimeout)
+	ctxFlow, cancel := context.WithTimeout(ctx, s.cfg.Live().FlowTimeout)
 	defer cancel()
 
-	streamIter := s.generateChatGistStreamingFlow.Stream(ctxFlow, &chat{messages}) // Обработка Streaming Flow. С пошаговым оповещением пользователя о ходе процесса.
+	input := &chat{Messages: messages, Instructions: gistInstructions(s.requestSettings(ctx))}
+	streamIter := s.generateChatGistStreamingFlow.Stream(ctxFlow, input) // Обработка Streaming Flow. С пошаговым оповещением пользователя о ходе процесса.
 	var gist []model.BatchGist
 	var errI error
-	streamIter(func(value *core.StreamingFlowValue[[]model.BatchGist, *int], err error) bool {
+	streamIter(func(value *core.StreamingFlowValue[[]model.BatchGist, *gistProgress], err error) bool {
 		if err != nil {
 			log.Error("stream error", slog.Any("error", err))
 			errI = err
 			return false
 		}
 		if value.Stream != nil {
-			callback("⏳ Генерируем пересказ...", *value.Stream, true)
-			log.Debug("flow step", slog.Int("progress", *value.Stream)) // уведомления пользователю value.Stream - % завершения
+			if value.Stream.Batch != nil && onBatch != nil {
+				onBatch(*value.Stream.Batch) // сохраняем готовый батч
+			}
+			callback("⏳ Генерируем пересказ...", value.Stream.Percent, true)
+			log.Debug("flow step", slog.Int("progress", value.Stream.Percent)) // уведомления пользователю value.Stream - % завершения
 		}
 		if value.Done {
 			gist = value.Output // окончательный ответ streaming flow
@@ -164,6 +178,7 @@ func (s *GenkitService) defineGenerateChatGistFlow() {
  - Максимально лаконично, только факты.
  - Внутри темы соблюдай хронологию.
  - Агрегируй информацию: объединяй похожие реплики от одного пользователя, избегай перечисления каждого сообщения.
+{{instructions}}
 `
 
 	// Определяем простой запрос(prompt) generateChatGistPrompt
@@ -177,9 +192,14 @@ func (s *GenkitService) defineGenerateChatGistFlow() {
 
 	// Определяем потоковый	 сценарий(streaming flow) generateChatGistStreamingFlow
 	s.generateChatGistStreamingFlow = genkit.DefineStreamingFlow(s.g, "generateChatGistStreamingFlow",
-		func(ctx context.Context, input *chat, cb func(ctx context.Context, percent *int) error) ([]model.BatchGist, error) {
+		func(ctx context.Context, input *chat, cb func(ctx context.Context, progress *gistProgress) error) ([]model.BatchGist, error) {
 
 			log := slog.With("func", "generateChatGistStreamingFlow")
+			settings := s.requestSettings(ctx)
+			textModel, contextWindow := s.textModel(settings) // Модель и размер батча по настройкам пользователя
+			_, modelOptions := s.modelOptions(ctx)
+			batchLimit := max(settings.BatchSize, 1)
+			log.Debug("generate chat gist", slog.String("model", textModel), slog.Int("batch size", batchLimit))
 			// Разбивка сообщений на батчи, размером = contextWindow - driftPercent токенов.
 			from := 0                          // начало батча
 			to := 0                            // конец батча
@@ -187,13 +207,13 @@ func (s *GenkitService) defineGenerateChatGistFlow() {
 			messageProcessed := 0              // Счетчик обработанных сообщений
 			progress := 0
 
-			_ = cb(ctx, &progress) // show processing to user
+			_ = cb(ctx, &gistProgress{Percent: progress}) // show processing to user
 
 			for {
 				batchSize := 0
-				for batchSize < (s.contextWindow-(s.contextWindow*s.driftPercent/100))*s.symbolPerToken && // Ищем конец батча, укладывающегося в контекстное окно
+				for batchSize < (contextWindow-(contextWindow*s.driftPercent/100))*s.symbolPerToken && // Ищем конец батча, укладывающегося в контекстное окно
 					to < len(input.Messages) && // Ограничение по количеству сообщений
-					(to-from < s.messagesPerBatch) { // Ограничение по количеству сообщений в батче
+					(to-from < batchLimit) { // Ограничение по количеству сообщений в батче
 
 					jsonData, errJ := json.Marshal(input.Messages[to])
 					if errJ != nil {
@@ -205,40 +225,50 @@ func (s *GenkitService) defineGenerateChatGistFlow() {
 
 				log.Debug("Get chat gist batch",
 					slog.Int("batch size (symbols)", batchSize),
-					slog.Int("context window", s.contextWindow),
+					slog.Int("context window", contextWindow),
 					slog.Int("batch from", from),
 					slog.Int("batch to", to),
 					slog.Int("all messages count", len(input.Messages)))
 
 				batch := chat{
-					Messages: input.Messages[from:to],
+					Messages:     input.Messages[from:to],
+					Instructions: input.Instructions,
 				}
 
+				batchCtx, span := tracing.Start(ctx, "llm.batch",
+					attribute.Int("batch.from", from),
+					attribute.Int("batch.to", to),
+					attribute.Int("batch.symbols", batchSize),
+					attribute.String("llm.model", textModel))
+
 				// выполняем простой запрос с Retry wrapper для обработки 429
-				resp, err := s.retryPrompt(ctx, generateChatGistPrompt, batch, log)
+				resp, err := s.retryPrompt(batchCtx, generateChatGistPrompt, textModel, batch, log, modelOptions...)
+				tracing.End(span, err)
 				if err != nil {
 					return nil, fmt.Errorf("getChatGistFlow.getChatGistPrompt: %w", err)
 				}
-
-				messageProcessed += to - from
-				progress = messageProcessed * 100 / len(input.Messages)
-				_ = cb(ctx, &progress) // show processing to user
+				usage := s.usage(textModel, resp, 0)
+				model.RecordUsage(ctx, usage)
 
 				log.Debug("ответ от llm", slog.Any("resp.Text()", resp.Text()))
 
-				lastMessageID := to // ID последнего сообщения в батче
-				if lastMessageID == len(input.Messages) {
-					lastMessageID--
-				}
+				last := to - 1 // индекс последнего сообщения в батче, to указывает на начало следующего
 
-				gist = append(gist, model.BatchGist{
+				batchGist := model.BatchGist{
 					Gist:             resp.Text(),
+					FirstMessageID:   input.Messages[from].ID,
 					FirstMessageData: input.Messages[from].Timestamp,
-					LastMessageID:    input.Messages[lastMessageID].ID,
-					LastMessageData:  input.Messages[lastMessageID].Timestamp,
+					LastMessageID:    input.Messages[last].ID,
+					LastMessageData:  input.Messages[last].Timestamp,
 					MessageCount:     to - from, // кол-во обработанных сообщений в батче, учитывая и пропущенные (пустые, системные и т.п.)
 					Audio:            make([]model.AudioGist, 0),
-				}) // сохраняем суть сообщений текущего батча
+					Usage:            usage,
+				}
+				gist = append(gist, batchGist) // сохраняем суть сообщений текущего батча
+
+				messageProcessed += to - from
+				progress = messageProcessed * 100 / len(input.Messages)
+				_ = cb(ctx, &gistProgress{Percent: progress, Batch: &batchGist}) // show processing to user, checkpoint
 
 				if to == len(input.Messages) { // Прерываем цикл, после обработки всех сообщений
 					break
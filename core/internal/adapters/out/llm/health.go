@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// CheckProvider проверка доступности провайдера LLM дешевым запросом, без генерации:
+// у Ollama список локальных моделей, у остальных провайдеров запрос к API с ключом.
+func (s *GenkitService) CheckProvider(ctx context.Context, provider string) error {
+	p, ok := s.provider(provider)
+	if !ok || !p.enabled {
+		return fmt.Errorf("llm.CheckProvider: %w: provider %q is not enabled", model.ErrUnknownModel, provider)
+	}
+
+	var url, header, value string
+	switch provider {
+	case "Ollama":
+		if _, errO := s.ollamaModels(ctx); errO != nil {
+			return fmt.Errorf("llm.CheckProvider: %w", errO)
+		}
+		return nil
+	case "OpenRouter":
+		url, header, value = "https://openrouter.ai/api/v1/key", "Authorization", "Bearer "+s.cfg.LLM.OpenRouter.ApiKey.Value()
+	case "Gemini":
+		if len(s.cfg.LLM.Gemini.ApiKeys) == 0 {
+			return fmt.Errorf("llm.CheckProvider: Gemini api keys are not set")
+		}
+		url, header, value = "https://generativelanguage.googleapis.com/v1beta/models?pageSize=1", "x-goog-api-key", s.cfg.LLM.Gemini.ApiKeys[s.currentGeminiApiKeyIndex].Value()
+	case "OpenAI":
+		url, header, value = "https://api.openai.com/v1/models", "Authorization", "Bearer "+s.cfg.LLM.OpenAI.ApiKey.Value()
+	}
+
+	req, errR := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if errR != nil {
+		return fmt.Errorf("llm.CheckProvider: %w", errR)
+	}
+	req.Header.Set(header, value)
+
+	resp, errD := http.DefaultClient.Do(req)
+	if errD != nil {
+		return fmt.Errorf("llm.CheckProvider: %w", errD)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("llm.CheckProvider: %s status %s", provider, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/firebase/genkit/go/plugins/ollama"
+)
+
+const modelsCacheTTL = 10 * time.Minute // Список моделей провайдера меняется редко, а у OpenRouter он на сотни позиций
+
+// cachedModels список моделей провайдера и время его получения.
+type cachedModels struct {
+	models  []string
+	updated time.Time
+}
+
+// ListModels возвращает модели провайдера LLM: локальные модели Ollama или модели, которые отдает API провайдера.
+// Модель из конфигурации провайдера всегда в списке.
+func (s *GenkitService) ListModels(ctx context.Context, provider string) ([]string, error) {
+	log := slog.With("func", "llm.ListModels", slog.String("provider", provider))
+
+	p, ok := s.provider(provider)
+	if !ok || !p.enabled {
+		return nil, fmt.Errorf("llm.ListModels: %w: provider %q is not enabled", model.ErrUnknownModel, provider)
+	}
+
+	s.modelsMu.Lock()
+	cached, found := s.modelsCache[p.prefix]
+	s.modelsMu.Unlock()
+	if found && time.Since(cached.updated) < modelsCacheTTL {
+		return cached.models, nil
+	}
+
+	var models []string
+	var errL error
+	if p.prefix == ollamaPrefix {
+		models, errL = s.ollamaModels(ctx)
+	} else {
+		models, errL = s.pluginModels(ctx, p.prefix)
+	}
+	if errL != nil {
+		return nil, fmt.Errorf("llm.ListModels: %w", errL)
+	}
+
+	if p.model != "" {
+		models = append(models, p.model)
+	}
+	slices.Sort(models)
+	models = slices.Compact(models)
+	log.Debug("models listed", slog.Int("count", len(models)))
+
+	s.modelsMu.Lock()
+	s.modelsCache[p.prefix] = cachedModels{models: models, updated: time.Now()}
+	s.modelsMu.Unlock()
+
+	return models, nil
+}
+
+// pluginModels модели, которые плагин genkit получает от API провайдера.
+func (s *GenkitService) pluginModels(ctx context.Context, prefix string) ([]string, error) {
+	plugin, ok := genkit.LookupPlugin(s.g, prefix).(api.DynamicPlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s does not list models", prefix)
+	}
+
+	var models []string
+	for _, desc := range plugin.ListActions(ctx) { // При ошибке API плагин возвращает пустой список
+		if desc.Type != api.ActionTypeModel {
+			continue
+		}
+		name := strings.TrimPrefix(desc.Name, prefix+"/")
+		if !isTextModel(name) {
+			continue
+		}
+		models = append(models, name)
+	}
+
+	return models, nil
+}
+
+// isTextModel отсеивает модели генерации изображений, видео, речи и эмбеддингов.
+func isTextModel(name string) bool {
+	for _, kind := range []string{"imagen", "veo", "tts", "embedding", "dall-e", "whisper"} {
+		if strings.Contains(name, kind) {
+			return false
+		}
+	}
+	return true
+}
+
+// ollamaModels локальные модели, загруженные на сервер Ollama.
+func (s *GenkitService) ollamaModels(ctx context.Context) ([]string, error) {
+	ctxReq, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.LLM.Ollama.Timeout)*time.Second)
+	defer cancel()
+
+	req, errR := http.NewRequestWithContext(ctxReq, http.MethodGet, strings.TrimSuffix(s.cfg.LLM.Ollama.ServerAddress, "/")+"/api/tags", http.NoBody)
+	if errR != nil {
+		return nil, fmt.Errorf("ollama tags request: %w", errR)
+	}
+
+	resp, errD := http.DefaultClient.Do(req)
+	if errD != nil {
+		return nil, fmt.Errorf("ollama tags request: %w", errD)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama tags request: status %s", resp.Status)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if errJ := json.NewDecoder(resp.Body).Decode(&tags); errJ != nil {
+		return nil, fmt.Errorf("ollama tags decode: %w", errJ)
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
+}
+
+// defineOllamaModel определяет модель Ollama в genkit, если она еще не определена.
+// Плагин Ollama не получает модели от сервера сам, без определения модель нельзя использовать в промпте.
+func (s *GenkitService) defineOllamaModel(name string) {
+	s.modelsMu.Lock()
+	defer s.modelsMu.Unlock()
+
+	if s.ollama == nil || ollama.IsDefinedModel(s.g, name) {
+		return
+	}
+
+	s.ollama.DefineModel(s.g,
+		ollama.ModelDefinition{
+			Name: name,
+			Type: "chat", // "chat" or "generate"
+		},
+		&ai.ModelOptions{
+			Supports: &ai.ModelSupports{
+				Multiturn:  true,
+				SystemRole: true,
+				Tools:      false,
+				Media:      false,
+			},
+		},
+	)
+	slog.With("func", "llm.defineOllamaModel").Info("ollama model defined", slog.String("model", name))
+}
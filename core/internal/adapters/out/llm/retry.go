@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
 	"github.com/firebase/genkit/go/ai"
 	"google.golang.org/genai"
 )
@@ -17,7 +18,8 @@ import (
 
 // Retry логика с экспоненциальным backoff для ошибок. // TODO и тайм-аутом ответа от llm в 60 секунд.
 // Если с ошибкой прилетает время задержки, то выбирается оно, вместо экспоненциального.
-func (s *GenkitService) retryPrompt(ctx context.Context, prompt ai.Prompt, input any, log *slog.Logger) (*ai.ModelResponse, error) {
+// modelName - модель genkit, которой выполняется промпт, для метрик. opts - опции выполнения промпта, например модель пользователя.
+func (s *GenkitService) retryPrompt(ctx context.Context, prompt ai.Prompt, modelName string, input any, log *slog.Logger, opts ...ai.PromptExecuteOption) (*ai.ModelResponse, error) {
 
 	start := time.Now()
 
@@ -25,18 +27,25 @@ func (s *GenkitService) retryPrompt(ctx context.Context, prompt ai.Prompt, input
 	baseDelay := 1 * time.Second
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
-		ctxPrompt, cancelPrompt := context.WithTimeout(ctx, s.cfg.LLM.PromptTimeout)
+		ctxPrompt, cancelPrompt := context.WithTimeout(ctx, s.cfg.Live().PromptTimeout)
 		log.Debug("Запуск промпта", slog.Int("попытка", attempt))
-		resp, err := prompt.Execute(ctxPrompt, ai.WithInput(input))
+		attemptStart := time.Now()
+		resp, err := prompt.Execute(ctxPrompt, append([]ai.PromptExecuteOption{ai.WithInput(input)}, opts...)...)
 		if err == nil {
 			log.Debug("запрос к llm выполнен успешно", slog.Any("время обработки", time.Since(start).String()))
 			cancelPrompt()
+			metrics.ObserveLLMPrompt(modelName, prompt.Name(), "ok", time.Since(attemptStart))
+			if resp.Usage != nil {
+				metrics.AddLLMTokens(modelName, resp.Usage.InputTokens, resp.Usage.OutputTokens)
+			}
 			return resp, nil // Успех
 		}
 		cancelPrompt()
+		metrics.ObserveLLMPrompt(modelName, prompt.Name(), "error", time.Since(attemptStart))
 
 		// Проверяем, ретраить ли данную ошибку
-		if isErrorToRetry(err) { // TODO может быть ретраить ВСЕ ошибки?
+		if category := retryCategory(err); category != "" { // TODO может быть ретраить ВСЕ ошибки?
+			metrics.IncLLMRetry(category)
 			if attempt == maxRetries {
 				return nil, fmt.Errorf("max retries exceeded for rate limit: %w", err)
 			}
@@ -86,12 +95,20 @@ func (s *GenkitService) retryPrompt(ctx context.Context, prompt ai.Prompt, input
 	return nil, fmt.Errorf("unreachable")
 }
 
-// Проверяет, является ли ошибка 429, 502, 503 и еще несколько.
-func isErrorToRetry(err error) bool {
+// retryCategory категория ошибки, которую нужно ретраить: 429, 502, 503 и еще несколько. "" - ошибку не ретраим.
+func retryCategory(err error) string {
 	errStr := strings.ToLower(err.Error())
-	return errors.Is(err, context.DeadlineExceeded) || // Дедлайн промпта
-		strings.Contains(errStr, "429") || // 429 Too Many Requests	(OpenRouter, Ollama, Gemini)
-		strings.Contains(errStr, "502") || // 502 Bad Gateway	(OpenRouter)
-		strings.Contains(errStr, "503") || // 503 The model is overloaded	(Gemini)
-		strings.Contains(errStr, "no choices in completion") // Ошибка возвращается если модель перегружена, бывает у free моделей (OpenRouter)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded): // Дедлайн промпта
+		return "timeout"
+	case strings.Contains(errStr, "429"): // 429 Too Many Requests	(OpenRouter, Ollama, Gemini)
+		return "rate_limit"
+	case strings.Contains(errStr, "502"): // 502 Bad Gateway	(OpenRouter)
+		return "bad_gateway"
+	case strings.Contains(errStr, "503"): // 503 The model is overloaded	(Gemini)
+		return "overloaded"
+	case strings.Contains(errStr, "no choices in completion"): // Ошибка возвращается если модель перегружена, бывает у free моделей (OpenRouter)
+		return "no_choices"
+	}
+	return ""
 }
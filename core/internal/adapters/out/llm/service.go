@@ -5,11 +5,11 @@ import (
 	"context"
 	"log/slog"
 	"os"
-	"time"
+	"sync"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/arslanovdi/Gist/core/internal/infra/config"
-	"github.com/firebase/genkit/go/ai"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
 	"github.com/firebase/genkit/go/core"
 	"github.com/firebase/genkit/go/core/api"
 	"github.com/firebase/genkit/go/genkit"
@@ -20,11 +20,10 @@ import (
 )
 
 /*
-ENV must be set for specific llm
-export OPENROUTER_API_KEY=<your API key>
-export GEMINI_API_KEY=<your API key>
-export OPENAI_API_KEY=<your API key>
-etc...
+Ключи API задаются в конфигурации: llm.OpenRouter.api_key, llm.Gemini.api_keys, llm.OpenAI.api_key.
+Значение можно задать ссылкой, по умолчанию:
+llm.OpenRouter.api_key: env:OPENROUTER_API_KEY
+llm.OpenAI.api_key: env:OPENAI_API_KEY
 */
 
 // GenkitService структура для работы с LLM через фреймворк Genkit.
@@ -32,32 +31,36 @@ etc...
 type GenkitService struct {
 	g *genkit.Genkit
 
-	contextWindow    int           // context window
-	driftPercent     int           // Процент отклонения от заданного контекстного окна (в минус), так как количество токенов можно посчитать только приблизительно.
-	symbolPerToken   int           // 1 токен ~ 3 символа. Расчет приблизительный, так как неизвестно как работают токенизаторы различных LLM.
-	messagesPerBatch int           // Максимальное количество сообщений в одном запросе к LLM
-	flowTimeout      time.Duration // Тайм-аут выполнения сценария LLM
+	contextWindow    int // context window
+	driftPercent     int // Процент отклонения от заданного контекстного окна (в минус), так как количество токенов можно посчитать только приблизительно.
+	symbolPerToken   int // 1 токен ~ 3 символа. Расчет приблизительный, так как неизвестно как работают токенизаторы различных LLM.
+	messagesPerBatch int // Максимальное количество сообщений в одном запросе к LLM
 
 	DefaultTextModel string // Модель для текстового запроса, задается в настройках model дефолтного провайдера (default_provider)
 
 	// TTS
-	languageCode             string
-	voiceName                string
 	currentGeminiApiKeyIndex int // Параметр хранит номер используемого api ключа
 
 	cfg *config.Config
 
-	generateChatGistStreamingFlow *core.Flow[*chat, []model.BatchGist, *int]
+	ollama      *ollama.Ollama // Модели Ollama определяются по мере выбора пользователями
+	modelsMu    sync.Mutex
+	modelsCache map[string]cachedModels // Списки моделей по префиксу плагина
+
+	generateChatGistStreamingFlow *core.Flow[*chat, []model.BatchGist, *gistProgress]
 	generateAudioGistFlow         *core.Flow[Params, string, struct{}]
+	answerQuestionFlow            *core.Flow[*question, string, struct{}]
+	estimateUrgencyFlow           *core.Flow[*urgencyInput, int, struct{}]
+	summarizeBriefingFlow         *core.Flow[*briefingInput, string, struct{}]
 }
 
 // withOpenRouter возвращает genkit plugin для работы с платформой агрегатором LLM - OpenRouter.
 // OpenAI compatible.
 func (s *GenkitService) withOpenRouter() api.Plugin {
 
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
+	apiKey := s.cfg.LLM.OpenRouter.ApiKey.Value()
 	if apiKey == "" {
-		slog.With("func", "llm.withOpenRouter").Error("OPENROUTER_API_KEY environment variable not set")
+		slog.With("func", "llm.withOpenRouter").Error("llm.OpenRouter.api_key not set")
 		return nil
 	}
 
@@ -92,29 +95,15 @@ func (s *GenkitService) withOpenRouter() api.Plugin {
 }
 
 // withOllama возвращает genkit plugin для работы с платформой для локального запуска LLM - Ollama.
+// Модели определяются после инициализации genkit, см. defineOllamaModel.
 func (s *GenkitService) withOllama() api.Plugin {
 
-	ollamaPlugin := &ollama.Ollama{
+	s.ollama = &ollama.Ollama{
 		ServerAddress: s.cfg.LLM.Ollama.ServerAddress,
 		Timeout:       s.cfg.LLM.Ollama.Timeout,
 	}
 
-	ollamaPlugin.DefineModel(s.g,
-		ollama.ModelDefinition{
-			Name: s.cfg.LLM.Ollama.Model,
-			Type: "chat", // "chat" or "generate"
-		},
-		&ai.ModelOptions{
-			Supports: &ai.ModelSupports{
-				Multiturn:  true,
-				SystemRole: true,
-				Tools:      false,
-				Media:      false,
-			},
-		},
-	) // define model
-
-	return ollamaPlugin
+	return s.ollama
 }
 
 // withGemini возвращает genkit plugin для работы с семейством моделей (LLM) Gemini AI от Google. nextApiKey указывает на использование следующего api ключа из массива.
@@ -130,9 +119,10 @@ func (s *GenkitService) withGemini(nextApiKey bool) api.Plugin {
 		}
 
 		log.Info("Set next Gemini API KEY", slog.Int("GeminiApiKeyIndex", s.currentGeminiApiKeyIndex))
+		metrics.IncGeminiKeyRotation()
 	}
 
-	apiKey := s.cfg.LLM.Gemini.ApiKeys[s.currentGeminiApiKeyIndex]
+	apiKey := s.cfg.LLM.Gemini.ApiKeys[s.currentGeminiApiKeyIndex].Value()
 
 	/*config := &genai.GenerateContentConfig{ // конфигурация
 		Temperature: genai.Ptr[float32](1.0), // Устанавливается температура 1.0 — это делает ответы более креативными и менее предсказуемыми.
@@ -146,9 +136,9 @@ func (s *GenkitService) withGemini(nextApiKey bool) api.Plugin {
 // withOpenAI возвращает genkit plugin для работы с моделями (LLM) от OpenAI (различные версии GPT).
 func (s *GenkitService) withOpenAI() api.Plugin {
 
-	apiKey := os.Getenv("OPENAI_API_KEY")
+	apiKey := s.cfg.LLM.OpenAI.ApiKey.Value()
 	if apiKey == "" {
-		slog.With("func", "llm.withOpenAI").Error("OPENAI_API_KEY environment variable not set")
+		slog.With("func", "llm.withOpenAI").Error("llm.OpenAI.api_key not set")
 		return nil
 	}
 
@@ -177,17 +167,15 @@ func NewGenkitService(ctx context.Context, cfg *config.Config) (*GenkitService,
 		log.Info("Start genkit in development mode")
 	}
 
-	s := &GenkitService{}
+	s := &GenkitService{
+		modelsCache: make(map[string]cachedModels),
+	}
 
 	s.cfg = cfg
-	s.flowTimeout = cfg.LLM.FlowTimeout
 	s.driftPercent = cfg.LLM.DriftPercent
 	s.symbolPerToken = cfg.LLM.SymbolPerToken
 	s.messagesPerBatch = cfg.LLM.MessagesPerBatch
 
-	s.languageCode = cfg.LLM.TTS.Gemini.LanguageCode
-	s.voiceName = cfg.LLM.TTS.Gemini.VoiceName
-
 	s.initGenkit(ctx, false)
 
 	return s, nil
@@ -198,6 +186,9 @@ func (s *GenkitService) registerFlows() {
 
 	s.defineGenerateChatGistFlow()
 	s.defineGenerateAudioGistFlow()
+	s.defineAnswerQuestionFlow()
+	s.defineEstimateUrgencyFlow()
+	s.defineSummarizeBriefingFlow()
 
 }
 
@@ -206,20 +197,10 @@ func (s *GenkitService) initGenkit(ctx context.Context, nextApiKey bool) {
 
 	log := slog.With("func", "llm.initGenkit")
 
-	switch s.cfg.LLM.DefaultProvider {
-	case "Ollama":
-		s.contextWindow = s.cfg.LLM.Ollama.ContextWindow
-		s.DefaultTextModel = "ollama/" + s.cfg.LLM.Ollama.Model
-	case "OpenRouter":
-		s.contextWindow = s.cfg.LLM.OpenRouter.ContextWindow
-		s.DefaultTextModel = "openrouter/" + s.cfg.LLM.OpenRouter.Model
-	case "Gemini":
-		s.contextWindow = s.cfg.LLM.Gemini.ContextWindow
-		s.DefaultTextModel = "googleai/" + s.cfg.LLM.Gemini.Model
-	case "OpenAI":
-		s.contextWindow = s.cfg.LLM.OpenAI.ContextWindow
-		s.DefaultTextModel = "openai/" + s.cfg.LLM.OpenAI.Model
-	default:
+	if p, ok := s.provider(s.cfg.LLM.DefaultProvider); ok {
+		s.contextWindow = p.contextWindow
+		s.DefaultTextModel = p.prefix + "/" + p.model
+	} else {
 		log.Error("unknown provider", slog.String("defaultProvider", s.cfg.LLM.DefaultProvider))
 	}
 
@@ -249,5 +230,9 @@ func (s *GenkitService) initGenkit(ctx context.Context, nextApiKey bool) {
 		genkit.WithPlugins(plugins...),
 	)
 
+	if s.cfg.LLM.Ollama.Enabled {
+		s.defineOllamaModel(s.cfg.LLM.Ollama.Model)
+	}
+
 	s.registerFlows()
 }
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// textTemperature температура текстовых запросов. При смене модели конфигурация промпта не подходит другому провайдеру,
+// поэтому она передается в общем для всех плагинов виде.
+const textTemperature = 0.1
+
+const ollamaPrefix = "ollama"
+
+// providerModel модель провайдера LLM из конфигурации.
+type providerModel struct {
+	prefix        string // Префикс плагина genkit в имени модели
+	model         string // Модель из конфигурации провайдера
+	contextWindow int
+	enabled       bool
+}
+
+// provider возвращает описание провайдера по имени из конфигурации: Ollama, OpenRouter, Gemini, OpenAI. false - провайдер неизвестен.
+func (s *GenkitService) provider(name string) (providerModel, bool) {
+	switch name {
+	case "Ollama":
+		return providerModel{ollamaPrefix, s.cfg.LLM.Ollama.Model, s.cfg.LLM.Ollama.ContextWindow, s.cfg.LLM.Ollama.Enabled}, true
+	case "OpenRouter":
+		return providerModel{"openrouter", s.cfg.LLM.OpenRouter.Model, s.cfg.LLM.OpenRouter.ContextWindow, s.cfg.LLM.OpenRouter.Enabled}, true
+	case "Gemini":
+		return providerModel{"googleai", s.cfg.LLM.Gemini.Model, s.cfg.LLM.Gemini.ContextWindow, s.cfg.LLM.Gemini.Enabled}, true
+	case "OpenAI":
+		return providerModel{"openai", s.cfg.LLM.OpenAI.Model, s.cfg.LLM.OpenAI.ContextWindow, s.cfg.LLM.OpenAI.Enabled}, true
+	default:
+		return providerModel{}, false
+	}
+}
+
+// requestSettings настройки пользователя запроса. Без настроек в контексте (запрос не от пользователя) - значения из конфигурации.
+func (s *GenkitService) requestSettings(ctx context.Context) model.Settings {
+	if settings, ok := model.SettingsFromContext(ctx); ok {
+		return settings
+	}
+
+	live := s.cfg.Live()
+
+	return model.Settings{
+		Provider:    s.cfg.LLM.DefaultProvider,
+		GistStyle:   model.GistStyleTopics,
+		GistLength:  model.GistLengthMedium,
+		TTSVoice:    live.TTSVoice,
+		TTSLanguage: live.TTSLanguage,
+		BatchSize:   s.messagesPerBatch,
+	}
+}
+
+// textModel имя модели и контекстное окно по настройкам пользователя.
+// Неизвестный или отключенный провайдер заменяется провайдером по умолчанию.
+func (s *GenkitService) textModel(settings model.Settings) (string, int) {
+	p, ok := s.provider(settings.Provider)
+	if !ok || !p.enabled {
+		if settings.Provider != s.cfg.LLM.DefaultProvider {
+			slog.With("func", "llm.textModel").Warn("llm provider is not available, use default", slog.String("provider", settings.Provider))
+		}
+		return s.DefaultTextModel, s.contextWindow
+	}
+
+	name := p.model
+	if settings.Model != "" {
+		name = settings.Model
+	}
+	if p.prefix == ollamaPrefix {
+		s.defineOllamaModel(name)
+	}
+
+	return p.prefix + "/" + name, p.contextWindow
+}
+
+// modelOptions имя модели пользователя и опции выполнения текстового промпта с ней.
+// Для модели по умолчанию опций нет, используются модель и конфигурация, заданные при определении промпта.
+func (s *GenkitService) modelOptions(ctx context.Context) (string, []ai.PromptExecuteOption) {
+	name, _ := s.textModel(s.requestSettings(ctx))
+	if name == s.DefaultTextModel {
+		return name, nil
+	}
+
+	return name, []ai.PromptExecuteOption{
+		ai.WithModelName(name),
+		ai.WithConfig(map[string]any{"temperature": textTemperature}),
+	}
+}
+
+// gistInstructions дополнительные требования к пересказу по стилю и объему из настроек пользователя.
+func gistInstructions(settings model.Settings) string {
+	instructions := ""
+
+	switch settings.GistStyle {
+	case model.GistStyleBullets:
+		instructions += "\n- Содержание каждой темы изложи маркированным списком из коротких пунктов вместо абзаца."
+	case model.GistStyleNarrative:
+		instructions += "\n- Не разбивай пересказ на темы: изложи суть обсуждения связным текстом из нескольких абзацев, в конце общий итог."
+	}
+
+	switch settings.GistLength {
+	case model.GistLengthShort:
+		instructions += "\n- Пересказ должен быть очень кратким: не более 1200 символов, только главное."
+	case model.GistLengthLong:
+		instructions += "\n- Пересказ может быть подробным: используй доступный объем до 3900 символов, сохраняй важные детали и аргументы."
+	}
+
+	if instructions == "" {
+		return ""
+	}
+	return "\nДополнительные требования пользователя (имеют приоритет над структурой выше):" + instructions
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/openai/openai-go"
+)
+
+// Тип входных данных для запроса к LLM на абзац сводки.
+type briefingInput struct {
+	Title string   `json:"title"`
+	Gists []string `json:"gists"`
+}
+
+// SummarizeBriefing выполняет запрос к LLM - сценарий summarizeBriefingFlow. Сводит пересказы батчей чата в один абзац.
+func (s *GenkitService) SummarizeBriefing(ctx context.Context, title string, gists []string) (string, error) {
+	log := slog.With("func", "llm.SummarizeBriefing")
+	log.Debug("summarize briefing start", slog.Int("batch count", len(gists)))
+
+	ctxFlow, cancel := context.WithTimeout(ctx, s.cfg.Live().PromptTimeout)
+	defer cancel()
+
+	summary, errR := s.summarizeBriefingFlow.Run(ctxFlow, &briefingInput{Title: title, Gists: gists})
+	if errR != nil {
+		return "", fmt.Errorf("llm.SummarizeBriefing: %w", errR)
+	}
+
+	return summary, nil
+}
+
+// defineSummarizeBriefingFlow определяет сценарий абзаца сводки: второй проход по готовым пересказам батчей чата.
+func (s *GenkitService) defineSummarizeBriefingFlow() {
+
+	config := &openai.ChatCompletionNewParams{ //конфигурация для OpenRouter provider (OpenAI compatible), для других провайдеров нужно изменять!
+		Temperature: openai.Float(0.2),
+	}
+
+	prompt := `Роль: Ты — ассистент, который составляет утреннюю сводку по непрочитанным чатам в Telegram.
+
+Входные данные:
+Название чата: {{title}}
+Краткие пересказы непрочитанных сообщений чата по частям, в хронологическом порядке: {{gists}}
+
+Инструкции:
+1. Сведи пересказы в один абзац из 2–4 предложений: о чем шла речь и что из этого важно пользователю.
+2. В первую очередь упомяни вопросы и просьбы к пользователю, решения, договоренности и сроки.
+3. Не перечисляй все темы подряд, опусти второстепенное.
+4. Пиши связным текстом без заголовков, списков и разметки, не более 400 символов.
+5. Не используй числовые идентификаторы участников.
+6. Отвечай на языке пересказов.
+`
+
+	summarizeBriefingPrompt := genkit.DefinePrompt(s.g, "summarizeBriefingPrompt",
+		ai.WithPrompt(prompt),
+		ai.WithInputType(briefingInput{}),
+		ai.WithOutputFormat(ai.OutputFormatText),
+		ai.WithConfig(config),
+		ai.WithModelName(s.DefaultTextModel),
+	)
+
+	s.summarizeBriefingFlow = genkit.DefineFlow(s.g, "summarizeBriefingFlow", func(ctx context.Context, input *briefingInput) (string, error) {
+		log := slog.With("func", "summarizeBriefingFlow")
+
+		modelName, modelOptions := s.modelOptions(ctx)
+		resp, err := s.retryPrompt(ctx, summarizeBriefingPrompt, modelName, input, log, modelOptions...)
+		if err != nil {
+			return "", fmt.Errorf("summarizeBriefingFlow.summarizeBriefingPrompt: %w", err)
+		}
+		model.RecordUsage(ctx, s.usage(modelName, resp, 0))
+
+		log.Debug("ответ от llm", slog.String("resp.Text()", resp.Text()))
+
+		return strings.TrimSpace(resp.Text()), nil
+	})
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// usage расход запроса к модели modelName ("провайдер/модель") по данным провайдера, стоимость по таблице цен из конфигурации.
+// audioSeconds - длительность синтезированной речи, для текстовых запросов 0.
+func (s *GenkitService) usage(modelName string, resp *ai.ModelResponse, audioSeconds float64) model.Usage {
+	provider, name, _ := strings.Cut(modelName, "/")
+	u := model.Usage{
+		Provider:     provider,
+		Model:        name,
+		Requests:     1,
+		AudioSeconds: audioSeconds,
+	}
+	if resp != nil && resp.Usage != nil {
+		u.InputTokens = resp.Usage.InputTokens
+		u.OutputTokens = resp.Usage.OutputTokens
+	}
+
+	if price, ok := s.price(modelName); ok {
+		u.Cost = float64(u.InputTokens)*price.Input/1e6 +
+			float64(u.OutputTokens)*price.Output/1e6 +
+			u.AudioSeconds*price.AudioMinute/60
+	}
+
+	return u
+}
+
+// price цена модели: точное совпадение имени или самый длинный префикс из цен со "*" в конце. false - модель бесплатная.
+func (s *GenkitService) price(modelName string) (config.LLMPrice, bool) {
+	var found config.LLMPrice
+	prefixLen := -1
+	for _, p := range s.cfg.LLM.Prices {
+		if p.Model == modelName {
+			return p, true
+		}
+		prefix, ok := strings.CutSuffix(p.Model, "*")
+		if ok && strings.HasPrefix(modelName, prefix) && len(prefix) > prefixLen {
+			found, prefixLen = p, len(prefix)
+		}
+	}
+
+	return found, prefixLen >= 0
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const checkpointsDir = "checkpoints"
+
+// checkpoint сохраненный пересказ батча. Ключ - диапазон сообщений батча.
+type checkpoint struct {
+	FirstMessageID int             `json:"first_message_id"`
+	LastMessageID  int             `json:"last_message_id"`
+	Batch          model.BatchGist `json:"batch"`
+}
+
+func checkpointFile(chatID int64) string {
+	return filepath.Join(checkpointsDir, strconv.FormatInt(chatID, 10)+".json")
+}
+
+// SaveCheckpoint сохраняет пересказ батча чата. Батч с тем же диапазоном сообщений перезаписывается.
+func (s *Store) SaveCheckpoint(_ context.Context, chatID int64, batch model.BatchGist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoints := make([]checkpoint, 0)
+	if err := s.load(checkpointFile(chatID), &checkpoints); err != nil {
+		return err
+	}
+
+	batch.Audio = nil // Аудиофайлы не сохраняем, они генерируются отдельно
+	checkpoints = slices.DeleteFunc(checkpoints, func(c checkpoint) bool {
+		return c.FirstMessageID == batch.FirstMessageID && c.LastMessageID == batch.LastMessageID
+	})
+	checkpoints = append(checkpoints, checkpoint{
+		FirstMessageID: batch.FirstMessageID,
+		LastMessageID:  batch.LastMessageID,
+		Batch:          batch,
+	})
+	slices.SortFunc(checkpoints, func(a, b checkpoint) int {
+		return a.FirstMessageID - b.FirstMessageID
+	})
+
+	return s.save(checkpointFile(chatID), checkpoints)
+}
+
+// LoadCheckpoints возвращает сохраненные пересказы батчей чата в хронологическом порядке.
+func (s *Store) LoadCheckpoints(_ context.Context, chatID int64) ([]model.BatchGist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoints := make([]checkpoint, 0)
+	if err := s.load(checkpointFile(chatID), &checkpoints); err != nil {
+		return nil, err
+	}
+
+	batches := make([]model.BatchGist, 0, len(checkpoints))
+	for i := range checkpoints {
+		batch := checkpoints[i].Batch
+		batch.Audio = make([]model.AudioGist, 0)
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
+
+// DeleteCheckpoints удаляет пересказы батчей чата, заканчивающиеся не позже сообщения lastMessageID. 0 - удаляет все.
+func (s *Store) DeleteCheckpoints(_ context.Context, chatID int64, lastMessageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lastMessageID == 0 {
+		return s.remove(checkpointFile(chatID))
+	}
+
+	checkpoints := make([]checkpoint, 0)
+	if err := s.load(checkpointFile(chatID), &checkpoints); err != nil {
+		return err
+	}
+
+	checkpoints = slices.DeleteFunc(checkpoints, func(c checkpoint) bool {
+		return c.LastMessageID <= lastMessageID
+	})
+	if len(checkpoints) == 0 {
+		return s.remove(checkpointFile(chatID))
+	}
+
+	return s.save(checkpointFile(chatID), checkpoints)
+}
+
+// CheckpointChats возвращает ID чатов, для которых есть сохраненные пересказы батчей.
+func (s *Store) CheckpointChats(_ context.Context) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, checkpointsDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("storage read checkpoints dir: %w", err)
+	}
+
+	chats := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		chatID, errP := strconv.ParseInt(name, 10, 64)
+		if errP != nil {
+			continue
+		}
+		chats = append(chats, chatID)
+	}
+
+	return chats, nil
+}
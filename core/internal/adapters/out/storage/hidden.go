@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const hiddenFile = "hidden.json"
+
+// LoadHiddenChats возвращает сохраненный список скрытых чатов.
+func (s *Store) LoadHiddenChats(_ context.Context) ([]model.HiddenChat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := make([]model.HiddenChat, 0)
+	if err := s.load(hiddenFile, &chats); err != nil {
+		return nil, err
+	}
+
+	return chats, nil
+}
+
+// SaveHiddenChats сохраняет список скрытых чатов, заменяя ранее сохраненный.
+func (s *Store) SaveHiddenChats(_ context.Context, chats []model.HiddenChat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save(hiddenFile, chats)
+}
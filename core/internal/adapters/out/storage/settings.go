@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const settingsFile = "settings.json"
+
+// LoadSettings возвращает сохраненные настройки пользователя. false - настройки еще не сохранялись.
+func (s *Store) LoadSettings(_ context.Context) (model.Settings, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var settings *model.Settings
+	if err := s.load(settingsFile, &settings); err != nil {
+		return model.Settings{}, false, err
+	}
+	if settings == nil {
+		return model.Settings{}, false, nil
+	}
+
+	return *settings, true, nil
+}
+
+// SaveSettings сохраняет настройки пользователя.
+func (s *Store) SaveSettings(_ context.Context, settings model.Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save(settingsFile, settings)
+}
@@ -0,0 +1,76 @@
+// Package storage хранение данных приложения в JSON файлах на диске.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store файловое хранилище. Каждый набор данных хранится в отдельном JSON файле в каталоге dir.
+type Store struct {
+	mu  sync.Mutex // Сериализует чтение-изменение-запись файлов
+	dir string
+}
+
+// New создает хранилище в каталоге dir, при отсутствии каталог создается.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("storage.New create dir %q: %w", dir, err)
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// load читает JSON файл name в v. Отсутствие файла не ошибка, v остается без изменений.
+func (s *Store) load(name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("storage read %q: %w", name, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("storage decode %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// save атомарно записывает v в JSON файл name: сначала во временный файл, затем переименование.
+func (s *Store) save(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("storage encode %q: %w", name, err)
+	}
+
+	path := filepath.Join(s.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("storage create dir for %q: %w", name, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("storage write %q: %w", name, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("storage rename %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// remove удаляет файл name. Отсутствие файла не ошибка.
+func (s *Store) remove(name string) error {
+	err := os.Remove(filepath.Join(s.dir, name))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage remove %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const usageFile = "usage.json"
+
+// LoadUsage возвращает сохраненный расход LLM.
+func (s *Store) LoadUsage(_ context.Context) ([]model.UsageRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]model.UsageRecord, 0)
+	if err := s.load(usageFile, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// SaveUsage сохраняет расход LLM, заменяя ранее сохраненный.
+func (s *Store) SaveUsage(_ context.Context, records []model.UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save(usageFile, records)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const watchlistFile = "watchlist.json"
+
+// LoadWatchRules возвращает сохраненные правила списка наблюдения.
+func (s *Store) LoadWatchRules(_ context.Context) ([]model.WatchRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := make([]model.WatchRule, 0)
+	if err := s.load(watchlistFile, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// SaveWatchRules сохраняет правила списка наблюдения, заменяя ранее сохраненные.
+func (s *Store) SaveWatchRules(_ context.Context, rules []model.WatchRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save(watchlistFile, rules)
+}
@@ -14,30 +14,35 @@ import (
 
 // Authenticate выполняет аутентификацию пользователя в Telegram API.
 //
-// Запрашивает код подтверждения через консольный ввод
+// Код подтверждения и пароль запрашиваются через SessionParams.Auth, по умолчанию через консольный ввод
 func (s *Session) Authenticate(ctx context.Context) error {
 	log := slog.With("func", "tgclient.authenticate", slog.Any("user_id", s.userID))
 
-	// Функция для запроса кода подтверждения в консоли
-	codePrompt := func(_ context.Context, _ *tg.AuthSentCode) (string, error) {
-		fmt.Print("Enter code: ")
-		code, err := bufio.NewReader(os.Stdin).ReadString('\n')
-		if err != nil {
-			return "", err
+	var flow auth.Flow
+	if s.auth == nil {
+		// Функция для запроса кода подтверждения в консоли
+		codePrompt := func(_ context.Context, _ *tg.AuthSentCode) (string, error) {
+			fmt.Print("Enter code: ")
+			code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(code), nil
 		}
-		return strings.TrimSpace(code), nil
-	}
 
-	// Аутентификация без пароля
-	flow := auth.NewFlow(
-		auth.CodeOnly(s.phone, auth.CodeAuthenticatorFunc(codePrompt)),
-		auth.SendCodeOptions{},
-	)
+		// Аутентификация без пароля
+		flow = auth.NewFlow(
+			auth.CodeOnly(s.phone, auth.CodeAuthenticatorFunc(codePrompt)),
+			auth.SendCodeOptions{},
+		)
+	} else {
+		flow = auth.NewFlow(userAuth{phone: s.phone, auth: s.auth}, auth.SendCodeOptions{})
+	}
 
 	errF := flow.Run(ctx, s.client.Auth())
 	if errF != nil {
 		// При ошибке аутентификации удаляем сессию и пробуем снова
-		if errR := os.Remove("session.json"); errR != nil && !os.IsNotExist(errR) {
+		if errR := os.Remove(s.sessionPath); errR != nil && !os.IsNotExist(errR) {
 			log.Error("Warning: failed to remove session file", slog.Any("error", errR))
 		}
 		log.Debug("authentication failed", slog.Any("error", errF), slog.Int64("user_id", s.userID))
@@ -0,0 +1,163 @@
+package tgclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
+	"github.com/gotd/td/telegram/query"
+	"github.com/gotd/td/tg"
+)
+
+// FetchMessages выгружает сообщения из телеграмм чата в диапазоне rng (по умолчанию непрочитанные).
+// callback - оповещение пользователя о ходе выполнения.
+// return слайс сообщений, кол-во пропущенных сообщений, ошибку.
+//
+//nolint:gocognit,gocyclo // cognit-21, cyclo-15
+func (s *Session) FetchMessages(ctx context.Context, chat *model.Chat, rng model.MessageRange, callback func(message string, count int, llm bool)) ([]model.Message, int, error) {
+	log := slog.With(slog.String("func", "tgclient.FetchMessages"), slog.Any("chatID", chat.ID), slog.String("range", rng.String()))
+	log.Debug("Get messages from chat")
+
+	if !s.ready.Load() {
+		return nil, 0, model.ErrNotReady
+	}
+
+	start := time.Now()
+
+	progress := fmt.Sprintf("📥 Загружаем сообщения из Telegram... (%d) сообщений.", chat.UnreadCount)
+	if !rng.IsUnread() {
+		progress = fmt.Sprintf("📥 Загружаем сообщения из Telegram... (%s)", rng)
+	}
+
+	callback(progress, 0, false) // Оповещение пользователю в телеграм бот
+	ticker := time.Now()
+
+	msgs := make([]model.Message, 0)
+	skipped := 0
+
+	raw := tg.NewClient(s.client)
+
+	builder := query.Messages(raw)
+
+	historyBuilder := builder.GetHistory(chat.Peer)
+	historyBuilder.BatchSize(batchLimit)
+	if rng.Kind == model.RangeBetween && rng.ToID > 0 {
+		historyBuilder.OffsetID(rng.ToID + 1) // История выдается от новых к старым, начиная с сообщения меньше offsetID
+	}
+
+	iter := historyBuilder.Iter()
+
+	for iter.Next(ctx) {
+		// пропускаем сервисные сообщения
+		if _, ok := iter.Value().Msg.(*tg.MessageService); ok {
+			continue
+		}
+
+		tgMsg, ok := iter.Value().Msg.(*tg.Message)
+		if !ok {
+			log.Error("Got message with unexpected type", slog.Any("type", iter.Value().Msg))
+
+			continue
+		}
+
+		// Читаем сообщения только из заданного диапазона.
+		if beforeRange(tgMsg, chat, rng) {
+			break
+		}
+
+		// Пропускаем пустые сообщения (без текста).
+		if tgMsg.Message == "" {
+			skipped++
+			continue
+		}
+
+		msgs = append(msgs, convertMessage(tgMsg, log))
+
+		if time.Since(ticker) > time.Second {
+			callback(progress, len(msgs), false) // Оповещение пользователю в телеграм бот
+			ticker = time.Now()
+		}
+
+		if rng.Kind == model.RangeLast && len(msgs) >= rng.Last { // Набрали нужное количество сообщений
+			break
+		}
+	}
+
+	// Проверяем финальную ошибку итератора
+	if iter.Err() != nil {
+		return nil, 0, fmt.Errorf("tgclient.FetchMessages failed to iterate messages: %w", iter.Err())
+	}
+
+	log.Debug("Get messages done",
+		slog.Int("count", len(msgs)),
+		slog.Int("skipped", skipped))
+	metrics.ObserveFetch(len(msgs), time.Since(start))
+
+	// Возвращаем сообщения в правильной хронологии, так как вычитывали их с конца.
+	slices.SortFunc(msgs, func(a, b model.Message) int {
+		return a.ID - b.ID
+	})
+
+	return msgs, skipped, nil
+}
+
+// beforeRange сообщение старше начала диапазона, дальше история не нужна.
+func beforeRange(msg *tg.Message, chat *model.Chat, rng model.MessageRange) bool {
+	switch rng.Kind {
+	case model.RangeSince:
+		return time.Unix(int64(msg.Date), 0).Before(rng.Since)
+	case model.RangeBetween:
+		return msg.ID < rng.FromID
+	case model.RangeLast:
+		return false
+	default: // Только новые сообщения
+		return msg.ID <= chat.LastReadMessageID
+	}
+}
+
+// convertMessage преобразует сообщение Telegram в модель приложения.
+func convertMessage(tgMsg *tg.Message, log *slog.Logger) model.Message {
+	message := model.Message{
+		ID:           tgMsg.ID,
+		Text:         tgMsg.Message,
+		Timestamp:    time.Unix(int64(tgMsg.Date), 0),
+		IsEdited:     tgMsg.EditDate > 0,
+		SenderID:     0,     // Заполняется дальше
+		ReplyToMsgID: 0,     // Заполняется дальше
+		IsForwarded:  false, // Заполняется дальше
+		Mentioned:    tgMsg.Mentioned,
+	}
+
+	if _, ok := tgMsg.GetFwdFrom(); ok {
+		message.IsForwarded = true
+	}
+
+	// Заполняем SenderID
+	if peerClass, ok := tgMsg.GetFromID(); ok {
+		switch fromID := peerClass.(type) {
+		case *tg.PeerUser:
+			message.SenderID = fromID.UserID
+		case *tg.PeerChat:
+			message.SenderID = fromID.ChatID
+		case *tg.PeerChannel:
+			message.SenderID = fromID.ChannelID
+		case nil:
+			log.Error("FromID type is nil")
+		default:
+			log.Error("FromID type is unknown")
+		}
+	}
+
+	// Заполняем ReplyToMsgID
+	if messageReply, ok := tgMsg.GetReplyTo(); ok {
+		if replyHeader, ok := messageReply.(*tg.MessageReplyHeader); ok {
+			message.ReplyToMsgID = replyHeader.ReplyToMsgID
+		}
+	}
+
+	return message
+}
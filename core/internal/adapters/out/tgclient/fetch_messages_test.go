@@ -0,0 +1,58 @@
+package tgclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/gotd/td/tg"
+)
+
+func TestBeforeRange(t *testing.T) {
+	since := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	chat := &model.Chat{ID: 1, LastReadMessageID: 100}
+
+	tests := []struct {
+		name string
+		msg  *tg.Message
+		rng  model.MessageRange
+		want bool
+	}{
+		{name: "unread new message", msg: &tg.Message{ID: 101}, rng: model.MessageRange{}},
+		{name: "unread last read message", msg: &tg.Message{ID: 100}, rng: model.MessageRange{}, want: true},
+		{name: "unread old message", msg: &tg.Message{ID: 50}, rng: model.MessageRange{}, want: true},
+		{
+			name: "since after start",
+			msg:  &tg.Message{ID: 10, Date: int(since.Add(time.Second).Unix())},
+			rng:  model.MessageRange{Kind: model.RangeSince, Since: since},
+		},
+		{
+			name: "since at start",
+			msg:  &tg.Message{ID: 10, Date: int(since.Unix())},
+			rng:  model.MessageRange{Kind: model.RangeSince, Since: since},
+		},
+		{
+			name: "since before start",
+			msg:  &tg.Message{ID: 10, Date: int(since.Add(-time.Second).Unix())},
+			rng:  model.MessageRange{Kind: model.RangeSince, Since: since},
+			want: true,
+		},
+		{
+			name: "since ignores read messages",
+			msg:  &tg.Message{ID: 50, Date: int(since.Add(time.Hour).Unix())},
+			rng:  model.MessageRange{Kind: model.RangeSince, Since: since},
+		},
+		{name: "last", msg: &tg.Message{ID: 1}, rng: model.MessageRange{Kind: model.RangeLast, Last: 10}},
+		{name: "between first message", msg: &tg.Message{ID: 20}, rng: model.MessageRange{Kind: model.RangeBetween, FromID: 20, ToID: 30}},
+		{name: "between before first", msg: &tg.Message{ID: 19}, rng: model.MessageRange{Kind: model.RangeBetween, FromID: 20, ToID: 30}, want: true},
+		{name: "between open end", msg: &tg.Message{ID: 500}, rng: model.MessageRange{Kind: model.RangeBetween, FromID: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := beforeRange(tt.msg, chat, tt.rng); got != tt.want {
+				t.Errorf("beforeRange(#%d, %s) = %v, want %v", tt.msg.ID, tt.rng, got, tt.want)
+			}
+		})
+	}
+}
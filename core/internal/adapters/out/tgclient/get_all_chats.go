@@ -42,6 +42,8 @@ func (s *Session) GetAllChats(ctx context.Context) ([]model.Chat, error) {
 		case *tg.Dialog:
 			chat.UnreadCount = d.UnreadCount
 			chat.LastReadMessageID = d.ReadInboxMaxID
+			chat.UnreadMentions = d.UnreadMentionsCount
+			chat.UnreadReactions = d.UnreadReactionsCount
 		case *tg.DialogFolder:
 			log.Info("tg.DialogFolder")
 		case nil:
@@ -53,14 +55,20 @@ func (s *Session) GetAllChats(ctx context.Context) ([]model.Chat, error) {
 		switch peer := elem.Peer.(type) {
 		case *tg.InputPeerChat:
 			chat.ID = peer.ChatID
+			chat.Type = model.ChatGroup
 			chat.Title = elem.Entities.Chats()[chat.ID].Title
 			chat.Peer = peer
 		case *tg.InputPeerUser:
 			chat.ID = peer.UserID
+			chat.Type = model.ChatPrivate
 			chat.Title = elem.Entities.Users()[chat.ID].Username
 			chat.Peer = peer
 		case *tg.InputPeerChannel:
 			chat.ID = peer.ChannelID
+			chat.Type = model.ChatGroup // Супергруппа
+			if channel, ok := elem.Entities.Channels()[chat.ID]; ok && channel.Broadcast {
+				chat.Type = model.ChatChannel
+			}
 			chat.Title = elem.Entities.Channels()[chat.ID].Title
 			chat.Peer = peer
 		case *tg.InputPeerEmpty:
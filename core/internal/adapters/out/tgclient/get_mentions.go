@@ -0,0 +1,120 @@
+package tgclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/gotd/td/telegram/query"
+	"github.com/gotd/td/tg"
+)
+
+// GetMentions возвращает непрочитанные упоминания пользователя в чате (messages.getUnreadMentions).
+// Telegram включает в упоминания и ответы на сообщения пользователя, такие сообщения отмечаются ReplyToMe.
+func (s *Session) GetMentions(ctx context.Context, chat *model.Chat) ([]model.Message, error) {
+	log := slog.With(slog.String("func", "tgclient.GetMentions"), slog.Any("chatID", chat.ID))
+	log.Debug("Get unread mentions from chat")
+
+	if !s.ready.Load() {
+		return nil, model.ErrNotReady
+	}
+
+	raw := tg.NewClient(s.client)
+
+	iter := query.Messages(raw).GetUnreadMentions(chat.Peer).BatchSize(batchLimit).Iter()
+
+	msgs := make([]model.Message, 0)
+	for iter.Next(ctx) {
+		tgMsg, ok := iter.Value().Msg.(*tg.Message)
+		if !ok || tgMsg.Message == "" {
+			continue
+		}
+		message := convertMessage(tgMsg, log)
+		message.Mentioned = true
+		msgs = append(msgs, message)
+	}
+	if iter.Err() != nil {
+		return nil, fmt.Errorf("tgclient.GetMentions failed to iterate messages: %w", iter.Err())
+	}
+
+	slices.SortFunc(msgs, func(a, b model.Message) int {
+		return a.ID - b.ID
+	})
+
+	if errR := s.markRepliesToMe(ctx, chat.Peer, msgs); errR != nil {
+		return nil, fmt.Errorf("tgclient.GetMentions: %w", errR)
+	}
+
+	log.Debug("Get unread mentions done", slog.Int("count", len(msgs)))
+
+	return msgs, nil
+}
+
+// MarkRepliesToMe отмечает ответы на сообщения пользователя в сообщениях чата. Требует дополнительных запросов к Telegram,
+// поэтому FetchMessages ответы не отмечает.
+func (s *Session) MarkRepliesToMe(ctx context.Context, chat *model.Chat, msgs []model.Message) error {
+	if !s.ready.Load() {
+		return model.ErrNotReady
+	}
+
+	if errR := s.markRepliesToMe(ctx, chat.Peer, msgs); errR != nil {
+		return fmt.Errorf("tgclient.MarkRepliesToMe: %w", errR)
+	}
+	return nil
+}
+
+// markRepliesToMe отмечает сообщения, которые являются ответами на сообщения пользователя.
+// Сообщения, на которые отвечают, запрашиваются у Telegram пачками по batchLimit.
+func (s *Session) markRepliesToMe(ctx context.Context, peer tg.InputPeerClass, msgs []model.Message) error {
+	ids := make([]int, 0)
+	for i := range msgs {
+		if msgs[i].ReplyToMsgID != 0 && !slices.Contains(ids, msgs[i].ReplyToMsgID) {
+			ids = append(ids, msgs[i].ReplyToMsgID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	raw := tg.NewClient(s.client)
+	own := make(map[int]bool, len(ids))
+
+	for chunk := range slices.Chunk(ids, batchLimit) {
+		inputs := make([]tg.InputMessageClass, 0, len(chunk))
+		for _, id := range chunk {
+			inputs = append(inputs, &tg.InputMessageID{ID: id})
+		}
+
+		var resp tg.MessagesMessagesClass
+		var err error
+		if channel, ok := peer.(*tg.InputPeerChannel); ok { // Сообщения каналов и супергрупп запрашиваются отдельным методом
+			resp, err = raw.ChannelsGetMessages(ctx, &tg.ChannelsGetMessagesRequest{
+				Channel: &tg.InputChannel{ChannelID: channel.ChannelID, AccessHash: channel.AccessHash},
+				ID:      inputs,
+			})
+		} else {
+			resp, err = raw.MessagesGetMessages(ctx, inputs)
+		}
+		if err != nil {
+			return fmt.Errorf("get replied messages failed: %w", err)
+		}
+
+		modified, ok := resp.AsModified()
+		if !ok {
+			continue
+		}
+		for _, m := range modified.GetMessages() {
+			if tgMsg, ok := m.(*tg.Message); ok && tgMsg.Out {
+				own[tgMsg.ID] = true
+			}
+		}
+	}
+
+	for i := range msgs {
+		msgs[i].ReplyToMe = own[msgs[i].ReplyToMsgID]
+	}
+
+	return nil
+}
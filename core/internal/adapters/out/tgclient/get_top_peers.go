@@ -0,0 +1,62 @@
+package tgclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/gotd/td/tg"
+)
+
+const topPeersLimit = 100
+
+// GetTopPeers возвращает рейтинг общения пользователя с чатами (contacts.getTopPeers), по ID чата.
+// Telegram считает рейтинг по частоте сообщений пользователя, если рейтинг отключен в настройках - пустой результат.
+func (s *Session) GetTopPeers(ctx context.Context) (map[int64]float64, error) {
+	log := slog.With("func", "tgclient.GetTopPeers")
+
+	if !s.ready.Load() {
+		return nil, model.ErrNotReady
+	}
+
+	raw := tg.NewClient(s.client)
+	resp, err := raw.ContactsGetTopPeers(ctx, &tg.ContactsGetTopPeersRequest{
+		Correspondents: true,
+		Groups:         true,
+		Channels:       true,
+		Limit:          topPeersLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tgclient.GetTopPeers: %w", err)
+	}
+
+	ratings := make(map[int64]float64)
+
+	topPeers, ok := resp.(*tg.ContactsTopPeers)
+	if !ok { // contacts.topPeersDisabled, contacts.topPeersNotModified
+		log.Debug("top peers not available", slog.String("type", resp.TypeName()))
+		return ratings, nil
+	}
+
+	for _, category := range topPeers.Categories {
+		for _, peer := range category.Peers {
+			var id int64
+			switch p := peer.Peer.(type) {
+			case *tg.PeerUser:
+				id = p.UserID
+			case *tg.PeerChat:
+				id = p.ChatID
+			case *tg.PeerChannel:
+				id = p.ChannelID
+			default:
+				continue
+			}
+			ratings[id] = max(ratings[id], peer.Rating)
+		}
+	}
+
+	log.Debug("Get top peers done", slog.Int("count", len(ratings)))
+
+	return ratings, nil
+}
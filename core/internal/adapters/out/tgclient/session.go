@@ -9,63 +9,146 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
 	"github.com/gotd/contrib/middleware/floodwait"
 	"github.com/gotd/contrib/middleware/ratelimit"
+	"github.com/gotd/contrib/oteltg"
 	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/time/rate"
 )
 
 const batchLimit = 100
 
-// Session структура телеграм клиента. В этой реализации подключение только одного пользователя!
+const defaultSessionPath = "session.json"
+
+// SessionParams параметры сессии пользователя Telegram.
+type SessionParams struct {
+	UserID      int64
+	Phone       string
+	SessionPath string              // Файл сессии, по умолчанию "session.json"
+	Auth        model.Authenticator // Ввод кода подтверждения и пароля, nil - консольный ввод
+	NoAuth      bool                // Не запускать авторизацию, недействительная сессия - ошибка model.ErrSessionExpired
+}
+
+// SessionState состояние сессии Telegram клиента.
+type SessionState int32
+
+// Состояния сессии
+const (
+	StateConnecting  SessionState = iota // Подключение и проверка авторизации
+	StateAuthorizing                     // Ожидание кода подтверждения или пароля
+	StateReady                           // Клиент авторизован и готов к работе
+	StateStopped                         // Клиент остановлен
+	StateFailed                          // Клиент остановлен с ошибкой
+)
+
+func (st SessionState) String() string {
+	switch st {
+	case StateConnecting:
+		return "connecting"
+	case StateAuthorizing:
+		return "authorizing"
+	case StateReady:
+		return "ready"
+	case StateStopped:
+		return "stopped"
+	case StateFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("SessionState(%d)", int32(st))
+	}
+}
+
+// Session структура телеграм клиента. Одна сессия - один аккаунт пользователя.
 type Session struct {
-	userID int64  // Идентификатор пользователя Telegram
-	phone  string // Номер телефона, привязанный к аккаунту
+	userID      int64  // Идентификатор пользователя Telegram
+	phone       string // Номер телефона, привязанный к аккаунту
+	sessionPath string
+	auth        model.Authenticator
+	noAuth      bool
 
 	client     *telegram.Client
 	wg         *sync.WaitGroup
-	ready      atomic.Bool        // True - клиент готов к работе
+	ready      atomic.Bool // True - клиент готов к работе
+	stateMu    sync.Mutex
+	state      SessionState // Состояние клиента для проверок готовности приложения
+	stateErr   error        // Ошибка остановки клиента
+	readyOnce  sync.Once
+	readyCh    chan struct{}      // Закрывается, когда клиент впервые готов к работе
 	cancelFunc context.CancelFunc // Отмена контекста вызовет закрытие telegram.Client.
 	waiter     *floodwait.Waiter
+	updates    *updates.Manager // Восстановление пропущенных обновлений и преобразование коротких обновлений
+
+	watchMu      sync.RWMutex
+	watchRules   []compiledRule                                    // Правила списка наблюдения
+	alertHandler func(ctx context.Context, alert model.WatchAlert) // Оповещение о сработавшем правиле
+	alerts       chan model.WatchAlert                             // Очередь оповещений, отправляет alertWorker
 }
 
 // NewSession создает и инициализирует новый экземпляр сессии Telegram клиента.
-// Принимает конфигурацию приложения, содержащую:
-//   - AppID и AppHash для аутентификации в Telegram API
-//   - UserID и Phone пользователя
+// Принимает:
+//   - cfg: конфигурация приложения, содержащая AppID и AppHash для аутентификации в Telegram API
+//   - params: UserID и Phone пользователя, файл сессии и способ ввода кода подтверждения
 //
 // Возвращает готовый к использованию экземпляр Session.
-// Сессия сохраняется локально в файл "session.json".
-func NewSession(cfg *config.Config) *Session {
+// Сессия сохраняется локально в файл params.SessionPath.
+func NewSession(cfg *config.Config, params SessionParams) *Session {
 
 	// обработчик ошибки FlOOD_WAIT
 	waiter := floodwait.NewWaiter().WithCallback(func(_ context.Context, wait floodwait.FloodWait) {
 		slog.Error("Got FLOOD_WAIT", slog.Any("sleep", wait.Duration.String()))
+		metrics.ObserveFloodWait(wait.Duration)
 	})
 
+	if params.SessionPath == "" {
+		params.SessionPath = defaultSessionPath
+	}
+
+	middlewares := []telegram.Middleware{
+		waiter, // обработчик FLOOD_WAIT
+		ratelimit.New(rate.Every(100*time.Millisecond), 5), // Общий rate limit, чтобы реже ловить FLOOD_WAIT. Субъективно, не особо помогает.
+	}
+	// Спан на каждый RPC вызов Telegram API. Первым в цепочке, чтобы в спан попадало и ожидание FLOOD_WAIT.
+	otelMiddleware, errM := oteltg.New(otel.GetMeterProvider(), otel.GetTracerProvider())
+	if errM != nil {
+		slog.Error("telegram tracing middleware", slog.Any("error", errM))
+	} else {
+		middlewares = append([]telegram.Middleware{otelMiddleware}, middlewares...)
+	}
+
+	s := &Session{
+		userID:      params.UserID,
+		phone:       params.Phone,
+		sessionPath: params.SessionPath,
+		auth:        params.Auth,
+		noAuth:      params.NoAuth,
+		wg:          &sync.WaitGroup{},
+		waiter:      waiter,
+		readyCh:     make(chan struct{}),
+		alerts:      make(chan model.WatchAlert, alertQueueSize),
+	}
+
+	s.updates = newUpdateManager(s)
+
 	// Настройка клиента Telegram с сохранением сессии
-	client := telegram.NewClient(
+	s.client = telegram.NewClient(
 		cfg.Client.AppID,
-		cfg.Client.AppHash,
+		cfg.Client.AppHash.Value(),
 		telegram.Options{
 			SessionStorage: &telegram.FileSessionStorage{ // TODO Реализовать сохранение во внешнее хранилище сессий
-				Path: "session.json",
-			},
-			Middlewares: []telegram.Middleware{
-				waiter, // обработчик FLOOD_WAIT
-				ratelimit.New(rate.Every(100*time.Millisecond), 5), // Общий rate limit, чтобы реже ловить FLOOD_WAIT. Субъективно, не особо помогает.
+				Path: s.sessionPath,
 			},
+			Middlewares:   middlewares,
+			UpdateHandler: s.updates,
 		},
 	)
 
-	return &Session{
-		userID: cfg.Client.UserID,
-		phone:  cfg.Client.Phone,
-		client: client,
-		wg:     &sync.WaitGroup{},
-		waiter: waiter,
-	}
+	return s
 }
 
 // Run запускает клиент Telegram в отдельной горутине.
@@ -95,6 +178,8 @@ func (s *Session) Run(ctx context.Context, serverErr chan error) {
 	ctxClient, cancelClient := context.WithCancel(ctx)
 	s.cancelFunc = cancelClient
 
+	s.wg.Go(func() { s.alertWorker(ctxClient) }) // Оповещения списка наблюдения
+
 	// Запускаем клиент в отдельной горутине
 	s.wg.Go(func() {
 		log.Debug("Starting Telegram client...")
@@ -110,7 +195,11 @@ func (s *Session) Run(ctx context.Context, serverErr chan error) {
 
 				// Если не авторизованы, выполняем полный процесс авторизации
 				if !authStatus.Authorized {
+					if s.noAuth {
+						return fmt.Errorf("get auth status: %w", model.ErrSessionExpired)
+					}
 					log.Debug("Not authenticated, starting authentication flow...", slog.Int64("user_id", s.userID))
+					s.setState(StateAuthorizing, nil)
 					if errA := s.Authenticate(ctx); errA != nil {
 						return errA
 					}
@@ -120,21 +209,92 @@ func (s *Session) Run(ctx context.Context, serverErr chan error) {
 
 				// Сигнализируем, что клиент готов
 				s.ready.Store(true)
+				s.setState(StateReady, nil)
+				s.readyOnce.Do(func() { close(s.readyCh) })
 				log.Debug("Telegram client is ready")
 
-				// Ждем отмены контекста
-				<-ctx.Done()
+				// Обрабатываем обновления до отмены контекста
+				errU := s.runUpdates(ctx)
 				s.ready.Store(false)
+				if errU != nil && ctx.Err() == nil {
+					return errU
+				}
+				s.setState(StateStopped, nil)
 				return nil
 			})
 		}); err != nil {
 			log.Error("Telegram client stopped with error", slog.Any("error", err))
 			s.ready.Store(false)
+			s.setState(StateFailed, err)
 			serverErr <- err
 		}
 	})
 }
 
+// newUpdateManager возвращает обработчик обновлений клиента. Входящие сообщения проверяются по списку наблюдения.
+// Диспетчер обернут в менеджер обновлений: диспетчер не обрабатывает короткие обновления UpdateShortMessage,
+// UpdateShortChatMessage и UpdatesTooLong, в которых Telegram присылает большинство сообщений личных чатов и групп.
+func newUpdateManager(s *Session) *updates.Manager {
+	dispatcher := tg.NewUpdateDispatcher()
+	dispatcher.OnNewMessage(s.onNewMessage)
+	dispatcher.OnNewChannelMessage(s.onNewChannelMessage)
+
+	return updates.New(updates.Config{Handler: dispatcher})
+}
+
+// runUpdates запускает менеджер обновлений авторизованного клиента и блокируется до отмены контекста.
+func (s *Session) runUpdates(ctx context.Context) error {
+	self, errS := s.client.Self(ctx)
+	if errS != nil {
+		return fmt.Errorf("get self: %w", errS)
+	}
+
+	if errU := s.updates.Run(ctx, s.client.API(), self.ID, updates.AuthOptions{}); errU != nil {
+		return fmt.Errorf("run updates manager: %w", errU)
+	}
+	return nil
+}
+
+// State возвращает состояние клиента и ошибку, с которой он остановился.
+func (s *Session) State() (SessionState, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	return s.state, s.stateErr
+}
+
+func (s *Session) setState(state SessionState, err error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	s.state, s.stateErr = state, err
+}
+
+// Check проверяет, что клиент готов к работе и сессия авторизована на сервере Telegram.
+func (s *Session) Check(ctx context.Context) error {
+	state, errS := s.State()
+	if state != StateReady {
+		if errS != nil {
+			return fmt.Errorf("client %s: %w", state, errS)
+		}
+		return fmt.Errorf("client %s", state)
+	}
+
+	status, errA := s.client.Auth().Status(ctx)
+	if errA != nil {
+		return fmt.Errorf("get auth status: %w", errA)
+	}
+	if !status.Authorized {
+		return fmt.Errorf("get auth status: %w", model.ErrSessionExpired)
+	}
+	return nil
+}
+
+// Ready возвращает канал, который закрывается, когда клиент авторизован и готов к работе.
+func (s *Session) Ready() <-chan struct{} {
+	return s.readyCh
+}
+
 // Close корректно завершает работу клиента Telegram.
 // Останавливает все запущенные горутины и освобождает ресурсы.
 // Принимает контекст для контроля времени ожидания завершения.
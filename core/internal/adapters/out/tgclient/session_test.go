@@ -0,0 +1,123 @@
+package tgclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+)
+
+// fakeUpdatesAPI сервер Telegram для менеджера обновлений. Первый getDifference при запуске менеджера пустой,
+// последующие возвращают diff.
+type fakeUpdatesAPI struct {
+	mu    sync.Mutex
+	calls int
+	diff  tg.UpdatesDifferenceClass
+}
+
+func (f *fakeUpdatesAPI) UpdatesGetState(context.Context) (*tg.UpdatesState, error) {
+	return &tg.UpdatesState{Pts: 1, Date: int(time.Now().Unix()), Seq: 1}, nil
+}
+
+func (f *fakeUpdatesAPI) UpdatesGetDifference(_ context.Context, req *tg.UpdatesGetDifferenceRequest) (tg.UpdatesDifferenceClass, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.calls == 1 || f.diff == nil {
+		return &tg.UpdatesDifferenceEmpty{Date: req.Date, Seq: 1}, nil
+	}
+	return f.diff, nil
+}
+
+func (f *fakeUpdatesAPI) UpdatesGetChannelDifference(context.Context, *tg.UpdatesGetChannelDifferenceRequest) (tg.UpdatesChannelDifferenceClass, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestUpdateManagerShortUpdates(t *testing.T) {
+	const selfID, senderID, groupID = 1, 42, 7
+	date := int(time.Now().Unix())
+
+	tests := []struct {
+		name       string
+		update     tg.UpdatesClass
+		diff       tg.UpdatesDifferenceClass
+		wantChatID int64
+		wantMsgID  int
+	}{
+		{
+			name:       "short private message",
+			update:     &tg.UpdateShortMessage{ID: 10, UserID: senderID, Message: "Deploy сегодня", Pts: 2, PtsCount: 1, Date: date},
+			wantChatID: senderID,
+			wantMsgID:  10,
+		},
+		{
+			name:       "short basic group message",
+			update:     &tg.UpdateShortChatMessage{ID: 11, FromID: senderID, ChatID: groupID, Message: "когда deploy?", Pts: 2, PtsCount: 1, Date: date},
+			wantChatID: groupID,
+			wantMsgID:  11,
+		},
+		{
+			name:   "too long",
+			update: &tg.UpdatesTooLong{},
+			diff: &tg.UpdatesDifference{
+				NewMessages: []tg.MessageClass{&tg.Message{
+					ID:      12,
+					PeerID:  &tg.PeerChat{ChatID: groupID},
+					FromID:  &tg.PeerUser{UserID: senderID},
+					Message: "DEPLOY откатили",
+					Date:    date,
+				}},
+				State: tg.UpdatesState{Pts: 5, Date: date, Seq: 1},
+			},
+			wantChatID: groupID,
+			wantMsgID:  12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{alerts: make(chan model.WatchAlert, alertQueueSize)}
+			s.SetAlertHandler(func(context.Context, model.WatchAlert) {})
+			if err := s.SetWatchRules([]model.WatchRule{{ID: 1, Kind: model.WatchKeyword, Pattern: "deploy"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			manager := newUpdateManager(s)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			started := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = manager.Run(ctx, &fakeUpdatesAPI{diff: tt.diff}, selfID, updates.AuthOptions{
+					OnStart: func(context.Context) { close(started) },
+				})
+			}()
+			t.Cleanup(func() {
+				cancel()
+				<-done
+			})
+
+			<-started
+			if err := manager.Handle(ctx, tt.update); err != nil {
+				t.Fatalf("handle: %v", err)
+			}
+
+			select {
+			case alert := <-s.alerts:
+				if alert.ChatID != tt.wantChatID || alert.MessageID != tt.wantMsgID || alert.Rule.ID != 1 {
+					t.Errorf("alert = chat %d, message %d, rule %d; want chat %d, message %d, rule 1",
+						alert.ChatID, alert.MessageID, alert.Rule.ID, tt.wantChatID, tt.wantMsgID)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("watch rule did not fire")
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package tgclient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tg"
+)
+
+// userAuth реализация auth.UserAuthenticator, код подтверждения и пароль запрашиваются у пользователя через model.Authenticator.
+type userAuth struct {
+	phone string
+	auth  model.Authenticator
+}
+
+func (a userAuth) Phone(_ context.Context) (string, error) {
+	return a.phone, nil
+}
+
+func (a userAuth) Password(ctx context.Context) (string, error) {
+	return a.auth.Password(ctx)
+}
+
+func (a userAuth) Code(ctx context.Context, _ *tg.AuthSentCode) (string, error) {
+	return a.auth.Code(ctx)
+}
+
+// AcceptTermsOfService регистрация новых аккаунтов не поддерживается.
+func (a userAuth) AcceptTermsOfService(_ context.Context, tos tg.HelpTermsOfService) error {
+	return &auth.SignUpRequired{TermsOfService: tos}
+}
+
+// SignUp регистрация новых аккаунтов не поддерживается.
+func (a userAuth) SignUp(_ context.Context) (auth.UserInfo, error) {
+	return auth.UserInfo{}, errors.New("sign up is not supported, register account in Telegram app")
+}
@@ -0,0 +1,222 @@
+package tgclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/gotd/td/tg"
+)
+
+const (
+	snippetLength  = 300 // Максимальная длина фрагмента сообщения в оповещении, символов
+	alertQueueSize = 100 // Оповещения, ожидающие отправки. При переполнении новые оповещения отбрасываются
+)
+
+// compiledRule правило списка наблюдения, подготовленное для проверки сообщений.
+type compiledRule struct {
+	rule   model.WatchRule
+	re     *regexp.Regexp // Для WatchRegex
+	needle string         // Для WatchKeyword и WatchMention, в нижнем регистре
+}
+
+// alertWorker отправляет оповещения из очереди. Обработчик оповещений вызывает Bot API, поэтому отправка
+// вынесена из диспетчера обновлений: медленный ответ бота не задерживает обработку обновлений клиента.
+func (s *Session) alertWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-s.alerts:
+			s.watchMu.RLock()
+			handler := s.alertHandler
+			s.watchMu.RUnlock()
+
+			if handler != nil {
+				handler(ctx, alert)
+			}
+		}
+	}
+}
+
+// SetWatchRules заменяет правила, по которым проверяются входящие сообщения.
+func (s *Session) SetWatchRules(rules []model.WatchRule) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c := compiledRule{rule: rule, needle: strings.ToLower(rule.Pattern)}
+		if rule.Kind == model.WatchRegex {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("tgclient.SetWatchRules rule %d: %w", rule.ID, err)
+			}
+			c.re = re
+		}
+		compiled = append(compiled, c)
+	}
+
+	s.watchMu.Lock()
+	s.watchRules = compiled
+	s.watchMu.Unlock()
+
+	return nil
+}
+
+// SetAlertHandler задает обработчик оповещений о сообщениях, подходящих под правила списка наблюдения.
+func (s *Session) SetAlertHandler(handler func(ctx context.Context, alert model.WatchAlert)) {
+	s.watchMu.Lock()
+	s.alertHandler = handler
+	s.watchMu.Unlock()
+}
+
+// onNewMessage обработчик новых сообщений личных чатов и групп.
+func (s *Session) onNewMessage(_ context.Context, e tg.Entities, update *tg.UpdateNewMessage) error {
+	s.watchMessage(e, update.Message)
+	return nil
+}
+
+// onNewChannelMessage обработчик новых сообщений каналов и супергрупп.
+func (s *Session) onNewChannelMessage(_ context.Context, e tg.Entities, update *tg.UpdateNewChannelMessage) error {
+	s.watchMessage(e, update.Message)
+	return nil
+}
+
+// watchMessage проверяет входящее сообщение по правилам списка наблюдения, на каждое сработавшее правило ставит оповещение в очередь.
+func (s *Session) watchMessage(e tg.Entities, msgClass tg.MessageClass) {
+	msg, ok := msgClass.(*tg.Message)
+	if !ok || msg.Out || msg.Message == "" { // Свои сообщения и сообщения без текста не проверяем
+		return
+	}
+
+	s.watchMu.RLock()
+	rules := s.watchRules
+	handler := s.alertHandler
+	s.watchMu.RUnlock()
+
+	if handler == nil || len(rules) == 0 {
+		return
+	}
+
+	chatID, title := peerInfo(e, msg.PeerID)
+	text := strings.ToLower(msg.Message)
+
+	for i := range rules {
+		if rules[i].rule.ChatID != 0 && rules[i].rule.ChatID != chatID {
+			continue
+		}
+		if !rules[i].match(msg, text) {
+			continue
+		}
+
+		slog.With("func", "tgclient.watchMessage").Debug("watch rule matched",
+			slog.Int64("rule_id", rules[i].rule.ID), slog.Int64("chat_id", chatID), slog.Int("message_id", msg.ID))
+
+		alert := model.WatchAlert{
+			Rule:      rules[i].rule,
+			ChatID:    chatID,
+			ChatTitle: title,
+			MessageID: msg.ID,
+			Snippet:   snippet(msg.Message),
+			Link:      messageLink(e, msg.PeerID, msg.ID),
+			Timestamp: time.Unix(int64(msg.Date), 0),
+		}
+		select {
+		case s.alerts <- alert:
+		default:
+			slog.With("func", "tgclient.watchMessage").Warn("alert queue is full, alert dropped",
+				slog.Int64("rule_id", rules[i].rule.ID), slog.Int64("chat_id", chatID), slog.Int("message_id", msg.ID))
+		}
+	}
+}
+
+// match сообщение подходит под правило. text - текст сообщения в нижнем регистре.
+func (c *compiledRule) match(msg *tg.Message, text string) bool {
+	switch c.rule.Kind {
+	case model.WatchKeyword:
+		return strings.Contains(text, c.needle)
+	case model.WatchRegex:
+		return c.re.MatchString(msg.Message)
+	case model.WatchMention:
+		if c.needle == "" {
+			return msg.Mentioned // Упомянули пользователя клиента
+		}
+		return mentions(msg, c.needle)
+	default:
+		return false
+	}
+}
+
+// mentions в сообщении есть упоминание @username, needle - упоминание в нижнем регистре.
+// Проверяются только сущности упоминаний: @ivan не срабатывает на @ivanov и на текст, похожий на упоминание.
+func mentions(msg *tg.Message, needle string) bool {
+	var text []uint16 // Смещения сущностей в UTF-16
+	for _, entity := range msg.Entities {
+		mention, ok := entity.(*tg.MessageEntityMention)
+		if !ok {
+			continue
+		}
+		if text == nil {
+			text = utf16.Encode([]rune(msg.Message))
+		}
+		if mention.Offset < 0 || mention.Length <= 0 || mention.Offset+mention.Length > len(text) {
+			continue
+		}
+		if strings.EqualFold(string(utf16.Decode(text[mention.Offset:mention.Offset+mention.Length])), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerInfo возвращает ID и название чата, в котором написано сообщение.
+func peerInfo(e tg.Entities, peer tg.PeerClass) (int64, string) {
+	switch p := peer.(type) {
+	case *tg.PeerChannel:
+		if channel, ok := e.Channels[p.ChannelID]; ok {
+			return p.ChannelID, channel.Title
+		}
+		return p.ChannelID, ""
+	case *tg.PeerChat:
+		if chat, ok := e.Chats[p.ChatID]; ok {
+			return p.ChatID, chat.Title
+		}
+		return p.ChatID, ""
+	case *tg.PeerUser:
+		if user, ok := e.Users[p.UserID]; ok {
+			if user.Username != "" {
+				return p.UserID, user.Username
+			}
+			return p.UserID, strings.TrimSpace(user.FirstName + " " + user.LastName)
+		}
+		return p.UserID, ""
+	default:
+		return 0, ""
+	}
+}
+
+// messageLink возвращает ссылку t.me на сообщение. Ссылки есть только у сообщений каналов и супергрупп.
+func messageLink(e tg.Entities, peer tg.PeerClass, messageID int) string {
+	p, ok := peer.(*tg.PeerChannel)
+	if !ok {
+		return ""
+	}
+
+	if channel, ok := e.Channels[p.ChannelID]; ok && channel.Username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", channel.Username, messageID)
+	}
+
+	return fmt.Sprintf("https://t.me/c/%d/%d", p.ChannelID, messageID)
+}
+
+// snippet обрезает текст сообщения до snippetLength символов.
+func snippet(text string) string {
+	if utf8.RuneCountInString(text) <= snippetLength {
+		return text
+	}
+	return string([]rune(text)[:snippetLength]) + "…"
+}
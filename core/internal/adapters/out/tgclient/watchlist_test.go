@@ -0,0 +1,138 @@
+package tgclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/gotd/td/tg"
+)
+
+// mention сущность упоминания со смещением и длиной в UTF-16.
+func mention(offset, length int) tg.MessageEntityClass {
+	return &tg.MessageEntityMention{Offset: offset, Length: length}
+}
+
+func TestCompiledRuleMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      model.WatchRule
+		msg       *tg.Message
+		wantMatch bool
+	}{
+		{
+			name:      "keyword ignores case",
+			rule:      model.WatchRule{Kind: model.WatchKeyword, Pattern: "Deploy"},
+			msg:       &tg.Message{Message: "DEPLOY finished"},
+			wantMatch: true,
+		},
+		{
+			name:      "cyrillic keyword ignores case",
+			rule:      model.WatchRule{Kind: model.WatchKeyword, Pattern: "релиз"},
+			msg:       &tg.Message{Message: "РЕЛИЗ готов"},
+			wantMatch: true,
+		},
+		{
+			name:      "keyword inside word",
+			rule:      model.WatchRule{Kind: model.WatchKeyword, Pattern: "deploy"},
+			msg:       &tg.Message{Message: "redeployed"},
+			wantMatch: true,
+		},
+		{
+			name: "keyword absent",
+			rule: model.WatchRule{Kind: model.WatchKeyword, Pattern: "deploy"},
+			msg:  &tg.Message{Message: "release is ready"},
+		},
+		{
+			name:      "regex",
+			rule:      model.WatchRule{Kind: model.WatchRegex, Pattern: `v\d+\.\d+`},
+			msg:       &tg.Message{Message: "release v1.2 is out"},
+			wantMatch: true,
+		},
+		{
+			name: "regex is case sensitive",
+			rule: model.WatchRule{Kind: model.WatchRegex, Pattern: `Error`},
+			msg:  &tg.Message{Message: "ERROR: disk full"},
+		},
+		{
+			name:      "regex with case insensitive flag",
+			rule:      model.WatchRule{Kind: model.WatchRegex, Pattern: `(?i)error`},
+			msg:       &tg.Message{Message: "ERROR: disk full"},
+			wantMatch: true,
+		},
+		{
+			name:      "mention of client user",
+			rule:      model.WatchRule{Kind: model.WatchMention},
+			msg:       &tg.Message{Message: "look at this", Mentioned: true},
+			wantMatch: true,
+		},
+		{
+			name: "no mention of client user",
+			rule: model.WatchRule{Kind: model.WatchMention},
+			msg:  &tg.Message{Message: "look at this"},
+		},
+		{
+			name:      "mention of username",
+			rule:      model.WatchRule{Kind: model.WatchMention, Pattern: "@Ivan"},
+			msg:       &tg.Message{Message: "hi @ivan", Entities: []tg.MessageEntityClass{mention(3, 5)}},
+			wantMatch: true,
+		},
+		{
+			name: "unknown kind",
+			rule: model.WatchRule{Kind: 42, Pattern: "deploy"},
+			msg:  &tg.Message{Message: "deploy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{}
+			if err := s.SetWatchRules([]model.WatchRule{tt.rule}); err != nil {
+				t.Fatalf("SetWatchRules: unexpected error: %v", err)
+			}
+
+			if got := s.watchRules[0].match(tt.msg, strings.ToLower(tt.msg.Message)); got != tt.wantMatch {
+				t.Errorf("match(%q) = %v, want %v", tt.msg.Message, got, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestSetWatchRulesInvalidRegex(t *testing.T) {
+	s := &Session{}
+	if err := s.SetWatchRules([]model.WatchRule{{ID: 1, Kind: model.WatchRegex, Pattern: "("}}); err == nil {
+		t.Fatal("SetWatchRules with invalid regex: want error")
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		entities []tg.MessageEntityClass
+		want     bool
+	}{
+		{name: "mention", text: "@ivan hi", entities: []tg.MessageEntityClass{mention(0, 5)}, want: true},
+		{name: "mention ignores case", text: "hi @IVAN", entities: []tg.MessageEntityClass{mention(3, 5)}, want: true},
+		{name: "longer username", text: "hi @ivanov", entities: []tg.MessageEntityClass{mention(3, 7)}},
+		{name: "text without entity", text: "hi @ivan"},
+		{name: "other entity", text: "hi @ivan", entities: []tg.MessageEntityClass{&tg.MessageEntityBold{Offset: 3, Length: 5}}},
+		{name: "second mention", text: "@petr @ivan", entities: []tg.MessageEntityClass{mention(0, 5), mention(6, 5)}, want: true},
+		// 👋 вне BMP и занимает две позиции UTF-16, смещения сущностей считаются в UTF-16
+		{name: "emoji before mention", text: "👋 @ivan", entities: []tg.MessageEntityClass{mention(3, 5)}, want: true},
+		{name: "emoji offset in runes", text: "👋 @ivan", entities: []tg.MessageEntityClass{mention(2, 5)}},
+		{name: "cyrillic before mention", text: "привет @ivan", entities: []tg.MessageEntityClass{mention(7, 5)}, want: true},
+		{name: "entity out of text", text: "@ivan", entities: []tg.MessageEntityClass{mention(1, 5)}},
+		{name: "negative offset", text: "@ivan", entities: []tg.MessageEntityClass{mention(-1, 5)}},
+		{name: "empty entity", text: "@ivan", entities: []tg.MessageEntityClass{mention(0, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &tg.Message{Message: tt.text, Entities: tt.entities}
+			if got := mentions(msg, "@ivan"); got != tt.want {
+				t.Errorf("mentions(%q, @ivan) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
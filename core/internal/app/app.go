@@ -6,36 +6,52 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/arslanovdi/Gist/core/internal/adapters/in/httpapi"
 	"github.com/arslanovdi/Gist/core/internal/adapters/in/tgbot"
 	"github.com/arslanovdi/Gist/core/internal/adapters/out/llm"
-	"github.com/arslanovdi/Gist/core/internal/adapters/out/tgclient"
-	"github.com/arslanovdi/Gist/core/internal/domain/core"
 	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"github.com/arslanovdi/Gist/core/internal/infra/ffmpeg"
+	"github.com/arslanovdi/Gist/core/internal/infra/health"
+	"github.com/arslanovdi/Gist/core/internal/infra/logger"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
 	"github.com/joho/godotenv"
 )
 
 const envFileName = ".env"
 
+// Время кэширования результатов проверок /readyz, частые пробы не должны нагружать Telegram API и файловую систему
+const (
+	checkTTL     = 15 * time.Second
+	slowCheckTTL = time.Minute // Проверки провайдеров LLM и ffmpeg обращаются к внешним API и процессам
+)
+
 // App структура со всеми зависимостями приложения
 type App struct {
-	Cfg            *config.Config    // Конфигурация
-	TelegramBot    *tgbot.Bot        // Телеграм бот
-	TelegramClient *tgclient.Session // Телеграм клиент
-	CoreService    *core.Gist        // Слой бизнес логики
-	LLM            *llm.GenkitService
+	Cfg         *config.Config  // Конфигурация
+	TelegramBot *tgbot.Bot      // Телеграм бот
+	Users       *Users          // Пользователи бота: телеграм клиент и слой бизнес логики каждого пользователя
+	API         *httpapi.Server // HTTP API, nil - отключено в конфигурации
+	Metrics     *metrics.Server // Prometheus метрики и пробы /healthz, /readyz, nil - отключено в конфигурации
+	Health      *health.Checker // Пробы /healthz и /readyz с проверками компонентов, nil - сервер метрик отключен
+	LLM         *llm.GenkitService
+
+	shutdownTracing func(context.Context) error // Отправка накопленных спанов трассировки
 }
 
 // New создает и инициализирует экземпляр приложения.
 // Выполняет настройку всех компонентов в правильном порядке:
-//  1. Загружает конфигурацию из .env файла (если доступен)
-//  2. Инициализирует Telegram клиент
-//  3. Настраивает LLM-сервис
-//  4. Создает сервис ядра (бизнес-логика)
-//  5. Инициализирует Telegram бота
+//  1. Загружает конфигурацию из .env файла (если доступен), проверяет ее и отслеживает изменения файла конфигурации
+//  2. Настраивает трассировку и LLM-сервис
+//  3. Инициализирует Telegram бота
+//  4. Создает для каждого пользователя Telegram клиент и сервис ядра (бизнес-логика)
 func New(ctx context.Context) (*App, error) {
 	log := slog.With("func", "app.New")
 
@@ -49,30 +65,71 @@ func New(ctx context.Context) (*App, error) {
 		return nil, fmt.Errorf("[app.New] Error loading config: %w", errC)
 	}
 
+	logger.AddSecrets(cfg.Secrets()...) // Значения секретов не попадают в лог, даже в тексте ошибок сторонних библиотек
 	log.Info("configuration loaded")
 
-	telegramClient := tgclient.NewSession(cfg)
+	cfg.Watch(func(live config.Reloadable) { // Пороги, тайм-ауты и голос TTS читаются через cfg.Live, здесь только уровень логирования
+		if live.Debug {
+			logger.SetLogLevel(slog.LevelDebug)
+		} else {
+			logger.SetLogLevel(slog.LevelInfo)
+		}
+	})
+
+	shutdownTracing, errT := tracing.Init(ctx, cfg)
+	if errT != nil {
+		return nil, fmt.Errorf("[app.new] tracing initialization failed: %w", errT)
+	}
 
 	llmClient, errL := llm.NewGenkitService(ctx, cfg)
 	if errL != nil {
 		return nil, fmt.Errorf("[app.new] llm initialization failed: %w", errL)
 	}
 
-	coreService := core.NewGist(telegramClient, llmClient, cfg)
+	users := NewUsers(cfg, llmClient)
 
-	bot, errB := tgbot.New(cfg, coreService)
+	bot, errB := tgbot.New(cfg, users)
 	if errB != nil {
 		return nil, fmt.Errorf("[app.new] bot initialization failed: %w", errB)
 	}
 
-	// coreService.SetTelegramBot(bot) // Внедрение зависимости.
+	// Внедрение зависимости, оповещение о завершении фоновых задач и сообщениях из списка наблюдения.
+	users.SetNotifier(func(userID int64) Notifier { return bot.Notifier(userID) })
+
+	if errU := users.Init(ctx); errU != nil {
+		return nil, fmt.Errorf("[app.new] users initialization failed: %w", errU)
+	}
+
+	var api *httpapi.Server
+	if cfg.API.Enabled {
+		var errA error
+		api, errA = httpapi.New(cfg, func(userID int64) (httpapi.CoreService, bool) {
+			return users.CoreService(userID)
+		})
+		if errA != nil {
+			return nil, fmt.Errorf("[app.new] api initialization failed: %w", errA)
+		}
+	}
+
+	var metricsServer *metrics.Server
+	var checker *health.Checker
+	if cfg.Metrics.Enabled {
+		metricsServer = metrics.New(cfg)
+		checker = newHealthChecker(cfg, users, bot, llmClient)
+		metricsServer.Handle("GET /healthz", http.HandlerFunc(checker.Healthz))
+		metricsServer.Handle("GET /readyz", http.HandlerFunc(checker.Readyz))
+	}
 
 	return &App{
-		Cfg:            cfg,
-		TelegramBot:    bot,
-		TelegramClient: telegramClient,
-		CoreService:    coreService,
-		LLM:            llmClient,
+		Cfg:         cfg,
+		TelegramBot: bot,
+		Users:       users,
+		API:         api,
+		Metrics:     metricsServer,
+		Health:      checker,
+		LLM:         llmClient,
+
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
@@ -87,10 +144,19 @@ func (a *App) Run(cancelStartTimeout context.CancelFunc) error {
 
 	// Запуск всего...
 	ctx := context.WithoutCancel(context.Background()) // Нужен долгоживущий контекст (это просто явное его описание).
-	a.TelegramClient.Run(ctx, serverErr)
+	if a.Metrics != nil {
+		a.Metrics.Run(ctx, serverErr)
+	}
+	a.Users.Run(ctx, serverErr)
 	a.TelegramBot.Run(ctx, serverErr)
+	if a.API != nil {
+		a.API.Run(ctx, serverErr)
+	}
 
 	cancelStartTimeout() // все запустили, отменяем контекст запуска приложения
+	if a.Health != nil {
+		a.Health.Started()
+	}
 	log.Info("Application started")
 
 	// graceful shutdown
@@ -124,8 +190,51 @@ func (a *App) Close(ctx context.Context) {
 
 	log := slog.With("func", "app.Close")
 
+	if a.Health != nil {
+		a.Health.Shutdown() // Балансировщик перестает направлять запросы
+	}
+	if a.API != nil {
+		a.API.Close(ctx) // Не принимаем новые запросы
+	}
+	a.Users.CloseJobs(ctx) // Сначала останавливаем фоновые задачи, они используют бота и клиента
 	a.TelegramBot.Close(ctx)
-	a.TelegramClient.Close(ctx)
+	a.Users.Close(ctx)
+	if a.Metrics != nil {
+		a.Metrics.Close(ctx) // Последним, метрики нужны до полной остановки
+	}
+	if errT := a.shutdownTracing(ctx); errT != nil {
+		log.Error("Error shutting down tracing", slog.Any("error", errT))
+	}
 
 	log.Info("Application stopped")
 }
+
+// newHealthChecker регистрирует проверки компонентов для /readyz: критичные переводят пробу в 503, некритичные - в статус degraded.
+func newHealthChecker(cfg *config.Config, users *Users, bot *tgbot.Bot, llmClient *llm.GenkitService) *health.Checker {
+	checker := health.New()
+
+	checker.Add("telegram_client", true, checkTTL, users.CheckOwner)
+	checker.Add("telegram_users", false, checkTTL, users.CheckUsers)
+	checker.Add("bot_updates", true, checkTTL, bot.CheckUpdates)
+
+	for _, provider := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"Ollama", cfg.LLM.Ollama.Enabled},
+		{"OpenRouter", cfg.LLM.OpenRouter.Enabled},
+		{"Gemini", cfg.LLM.Gemini.Enabled},
+		{"OpenAI", cfg.LLM.OpenAI.Enabled},
+	} {
+		if !provider.enabled {
+			continue
+		}
+		checker.Add("llm_"+strings.ToLower(provider.name), provider.name == cfg.LLM.DefaultProvider, slowCheckTTL,
+			func(ctx context.Context) error { return llmClient.CheckProvider(ctx, provider.name) })
+	}
+
+	checker.Add("ffmpeg", false, slowCheckTTL, ffmpeg.Check)
+	checker.Add("audio_dir", false, checkTTL, health.WritableDir(cfg.Project.AudioPath))
+
+	return checker
+}
@@ -0,0 +1,417 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/arslanovdi/Gist/core/internal/adapters/in/tgbot/router"
+	"github.com/arslanovdi/Gist/core/internal/adapters/out/llm"
+	"github.com/arslanovdi/Gist/core/internal/adapters/out/storage"
+	"github.com/arslanovdi/Gist/core/internal/adapters/out/tgclient"
+	"github.com/arslanovdi/Gist/core/internal/domain/core"
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+)
+
+const (
+	usersDir        = "users"        // Каталог данных подключенных пользователей бота, внутри DataPath и AudioPath
+	sessionFileName = "session.json" // Файл сессии Telegram клиента пользователя
+
+	loginSessionSuffix = ".login" // Временный файл сессии, авторизуемой командой /login
+)
+
+// Notifier оповещение пользователя бота о завершении фоновых задач и сообщениях из списка наблюдения.
+type Notifier interface {
+	core.JobNotifier
+	core.AlertNotifier
+}
+
+// user подключенный аккаунт пользователя бота.
+type user struct {
+	session *tgclient.Session
+	core    *core.Gist
+}
+
+// Users реестр пользователей бота. У каждого пользователя своя сессия Telegram клиента, кэш чатов, избранное и настройки, LLM общий.
+//
+// Владелец (client.user_id) авторизуется через консоль, его данные хранятся в корне DataPath, как в однопользовательском режиме.
+// Остальные пользователи из client.allowed_users подключают аккаунт через бота, данные хранятся в DataPath/users/<ID>.
+type Users struct {
+	cfg *config.Config
+	llm *llm.GenkitService
+
+	notifier func(userID int64) Notifier
+
+	mu      sync.RWMutex
+	users   map[int64]*user
+	pending map[int64]struct{} // Пользователи, для которых выполняется подключение
+
+	ctx       context.Context // Контекст работы клиентов, задается в Run
+	serverErr chan error      // Ошибки клиента владельца останавливают приложение
+	wg        sync.WaitGroup
+	done      chan struct{}
+}
+
+// NewUsers конструктор
+func NewUsers(cfg *config.Config, llmClient *llm.GenkitService) *Users {
+	return &Users{
+		cfg:     cfg,
+		llm:     llmClient,
+		users:   make(map[int64]*user),
+		pending: make(map[int64]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// SetNotifier внедрение зависимости, оповещения пользователей.
+func (u *Users) SetNotifier(notifier func(userID int64) Notifier) {
+	u.notifier = notifier
+}
+
+// Allowed пользователю разрешено работать с ботом.
+func (u *Users) Allowed(userID int64) bool {
+	return userID == u.cfg.Client.UserID || slices.Contains(u.cfg.Client.AllowedUsers, userID)
+}
+
+// CoreService возвращает слой бизнес-логики пользователя. false - аккаунт Telegram пользователя не подключен.
+func (u *Users) CoreService(userID int64) (router.CoreService, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	usr, ok := u.users[userID]
+	if !ok {
+		return nil, false
+	}
+	return usr.core, true
+}
+
+// CheckOwner проверка готовности Telegram клиента владельца, без него приложение не работает.
+func (u *Users) CheckOwner(ctx context.Context) error {
+	u.mu.RLock()
+	owner, ok := u.users[u.cfg.Client.UserID]
+	u.mu.RUnlock()
+	if !ok {
+		return errors.New("owner is not initialized")
+	}
+
+	return owner.session.Check(ctx)
+}
+
+// CheckUsers проверка готовности Telegram клиентов подключенных пользователей, кроме владельца.
+func (u *Users) CheckUsers(ctx context.Context) error {
+	u.mu.RLock()
+	sessions := make(map[int64]*tgclient.Session, len(u.users))
+	for userID, usr := range u.users {
+		if userID != u.cfg.Client.UserID {
+			sessions[userID] = usr.session
+		}
+	}
+	u.mu.RUnlock()
+
+	var errs []error
+	for userID, session := range sessions {
+		if errC := session.Check(ctx); errC != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", userID, errC))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Init создает владельца и пользователей с сохраненными сессиями. Клиенты запускаются в Run.
+func (u *Users) Init(ctx context.Context) error {
+	log := slog.With("func", "app.Users.Init")
+
+	owner, errO := u.newUser(ctx, tgclient.SessionParams{
+		UserID: u.cfg.Client.UserID,
+		Phone:  u.cfg.Client.Phone,
+	}, u.cfg.Project.DataPath, u.cfg.Project.AudioPath)
+	if errO != nil {
+		return fmt.Errorf("app.Users.Init owner: %w", errO)
+	}
+	u.users[u.cfg.Client.UserID] = owner
+
+	for _, userID := range u.cfg.Client.AllowedUsers {
+		if userID == u.cfg.Client.UserID {
+			continue
+		}
+
+		params := u.sessionParams(userID, "", nil)
+		params.NoAuth = true // Код подтверждения запрашивается только командой /login
+		if _, errS := os.Stat(params.SessionPath); errS != nil {
+			continue // Пользователь еще не подключал аккаунт
+		}
+
+		usr, errU := u.newUser(ctx, params, u.dataPath(userID), u.audioPath(userID))
+		if errU != nil {
+			log.Error("restore user", slog.Int64("user_id", userID), slog.Any("error", errU))
+			continue
+		}
+		u.users[userID] = usr
+	}
+
+	log.Info("users initialized", slog.Int("count", len(u.users)))
+
+	return nil
+}
+
+// Run запускает Telegram клиенты всех пользователей.
+// Ошибка клиента владельца отправляется в serverErr, остальные пользователи при ошибке отключаются.
+func (u *Users) Run(ctx context.Context, serverErr chan error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.ctx = ctx
+	u.serverErr = serverErr
+
+	for userID, usr := range u.users {
+		u.run(userID, usr)
+	}
+}
+
+// run запускает клиент пользователя. Вызывается под блокировкой u.mu.
+func (u *Users) run(userID int64, usr *user) {
+	if userID == u.cfg.Client.UserID {
+		usr.session.Run(u.ctx, u.serverErr)
+		return
+	}
+
+	errs := make(chan error, 1)
+	usr.session.Run(u.ctx, errs)
+	u.watch(userID, usr, errs)
+}
+
+// watch отключает пользователя при ошибке его клиента.
+func (u *Users) watch(userID int64, usr *user, errs chan error) {
+	u.wg.Go(func() {
+		select {
+		case err := <-errs:
+			slog.With("func", "app.Users.watch").Error("telegram client of user stopped, user disconnected",
+				slog.Int64("user_id", userID), slog.Any("error", err))
+			u.remove(userID, usr)
+		case <-u.done:
+		}
+	})
+}
+
+// Login подключает аккаунт Telegram пользователя бота. Код подтверждения и пароль запрашиваются через authenticator.
+// Новая сессия авторизуется во временном файле: при неверном коде, тайм-ауте или отмене входа
+// текущая сессия пользователя продолжает работать. Возвращает управление после успешной авторизации или ошибки.
+func (u *Users) Login(ctx context.Context, userID int64, phone string, authenticator model.Authenticator) error {
+	log := slog.With("func", "app.Users.Login", slog.Int64("user_id", userID))
+
+	if !u.Allowed(userID) || userID == u.cfg.Client.UserID { // Владелец авторизуется через консоль
+		return model.ErrUserNotAllowed
+	}
+
+	u.mu.Lock()
+	if _, ok := u.pending[userID]; ok {
+		u.mu.Unlock()
+		return model.ErrLoginInProgress
+	}
+	if u.ctx == nil {
+		u.mu.Unlock()
+		return model.ErrNotReady
+	}
+	u.pending[userID] = struct{}{}
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		delete(u.pending, userID)
+		u.mu.Unlock()
+	}()
+
+	closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.cfg.Project.ShutdownTimeout)
+	defer cancel()
+
+	params := u.sessionParams(userID, phone, authenticator)
+	sessionPath := params.SessionPath
+	params.SessionPath += loginSessionSuffix
+
+	if errL := u.authorize(ctx, closeCtx, params); errL != nil {
+		return fmt.Errorf("app.Users.Login: %w", errL)
+	}
+
+	// Авторизация прошла, заменяем текущую сессию пользователя новой
+	u.mu.Lock()
+	old := u.users[userID]
+	delete(u.users, userID)
+	u.mu.Unlock()
+
+	if old != nil { // Повторное подключение, например, другого аккаунта
+		u.close(closeCtx, old)
+	}
+
+	if errR := os.Rename(params.SessionPath, sessionPath); errR != nil {
+		return fmt.Errorf("app.Users.Login rename session: %w", errR)
+	}
+
+	params.SessionPath = sessionPath
+	params.NoAuth = true
+	usr, errU := u.newUser(ctx, params, u.dataPath(userID), u.audioPath(userID))
+	if errU != nil {
+		return fmt.Errorf("app.Users.Login: %w", errU)
+	}
+
+	errs := make(chan error, 1)
+	usr.session.Run(u.ctx, errs)
+
+	if errW := waitReady(ctx, usr.session, errs); errW != nil {
+		u.close(closeCtx, usr)
+		return fmt.Errorf("app.Users.Login: %w", errW)
+	}
+
+	u.mu.Lock()
+	u.users[userID] = usr
+	u.watch(userID, usr, errs)
+	u.mu.Unlock()
+
+	log.Info("user logged in")
+
+	return nil
+}
+
+// authorize авторизует сессию во временном файле params.SessionPath. Клиент останавливается после авторизации,
+// при ошибке файл сессии удаляется.
+func (u *Users) authorize(ctx, closeCtx context.Context, params tgclient.SessionParams) error {
+	if errM := os.MkdirAll(filepath.Dir(params.SessionPath), 0o750); errM != nil {
+		return fmt.Errorf("create session dir: %w", errM)
+	}
+	if errR := os.Remove(params.SessionPath); errR != nil && !errors.Is(errR, os.ErrNotExist) { // Остался от прерванного входа
+		return fmt.Errorf("remove session: %w", errR)
+	}
+
+	session := tgclient.NewSession(u.cfg, params)
+	errs := make(chan error, 1)
+	session.Run(u.ctx, errs)
+
+	errW := waitReady(ctx, session, errs)
+	session.Close(closeCtx)
+
+	if errW != nil {
+		if errR := os.Remove(params.SessionPath); errR != nil && !errors.Is(errR, os.ErrNotExist) {
+			slog.With("func", "app.Users.authorize").Error("remove session", slog.Any("error", errR))
+		}
+		return errW
+	}
+	return nil
+}
+
+// waitReady ожидает готовности клиента, ошибки его запуска или отмены ctx.
+func waitReady(ctx context.Context, session *tgclient.Session, errs chan error) error {
+	select {
+	case <-session.Ready():
+		return nil
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CloseJobs останавливает фоновые задачи всех пользователей, они используют бота и клиентов.
+func (u *Users) CloseJobs(ctx context.Context) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	for _, usr := range u.users {
+		usr.core.Close(ctx)
+	}
+}
+
+// Close останавливает Telegram клиенты всех пользователей.
+func (u *Users) Close(ctx context.Context) {
+	close(u.done)
+
+	u.mu.Lock()
+	users := u.users
+	u.users = make(map[int64]*user)
+	u.mu.Unlock()
+
+	for _, usr := range users {
+		usr.session.Close(ctx)
+	}
+
+	u.wg.Wait()
+}
+
+// newUser создает клиент и слой бизнес-логики пользователя.
+func (u *Users) newUser(ctx context.Context, params tgclient.SessionParams, dataPath, audioPath string) (*user, error) {
+	if errM := os.MkdirAll(audioPath, 0o750); errM != nil {
+		return nil, fmt.Errorf("create audio dir %q: %w", audioPath, errM)
+	}
+
+	store, errS := storage.New(dataPath)
+	if errS != nil {
+		return nil, fmt.Errorf("storage initialization failed: %w", errS)
+	}
+
+	session := tgclient.NewSession(u.cfg, params)
+
+	gist := core.NewGist(session, u.llm, store, u.cfg)
+	gist.SetAudioPath(audioPath)
+
+	if errI := gist.InitSettings(ctx); errI != nil {
+		return nil, fmt.Errorf("settings initialization failed: %w", errI)
+	}
+
+	if errU := gist.InitUsage(ctx); errU != nil {
+		return nil, fmt.Errorf("usage initialization failed: %w", errU)
+	}
+
+	if errH := gist.InitHidden(ctx); errH != nil {
+		return nil, fmt.Errorf("hidden chats initialization failed: %w", errH)
+	}
+
+	notifier := u.notifier(params.UserID)
+	gist.SetJobNotifier(notifier) // Оповещение о завершении фоновых задач
+
+	if errW := gist.InitWatchlist(ctx, notifier); errW != nil { // Оповещения о сообщениях из списка наблюдения
+		return nil, fmt.Errorf("watchlist initialization failed: %w", errW)
+	}
+
+	return &user{session: session, core: gist}, nil
+}
+
+// remove отключает пользователя, если он не был заменен повторным подключением.
+func (u *Users) remove(userID int64, usr *user) {
+	u.mu.Lock()
+	if u.users[userID] == usr {
+		delete(u.users, userID)
+	}
+	u.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), u.cfg.Project.ShutdownTimeout)
+	defer cancel()
+	u.close(ctx, usr)
+}
+
+// close останавливает фоновые задачи и клиент пользователя.
+func (u *Users) close(ctx context.Context, usr *user) {
+	usr.core.Close(ctx)
+	usr.session.Close(ctx)
+}
+
+func (u *Users) sessionParams(userID int64, phone string, authenticator model.Authenticator) tgclient.SessionParams {
+	return tgclient.SessionParams{
+		UserID:      userID,
+		Phone:       phone,
+		SessionPath: filepath.Join(u.dataPath(userID), sessionFileName),
+		Auth:        authenticator,
+	}
+}
+
+func (u *Users) dataPath(userID int64) string {
+	return filepath.Join(u.cfg.Project.DataPath, usersDir, strconv.FormatInt(userID, 10))
+}
+
+func (u *Users) audioPath(userID int64) string {
+	return filepath.Join(u.cfg.Project.AudioPath, usersDir, strconv.FormatInt(userID, 10))
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	minKeywordLength = 3 // Более короткие слова вопроса не используются для поиска
+	keywordStemment  = 5 // Длина основы слова. Грубый стемминг, чтобы "подтвердил" находил "подтверждение"
+)
+
+// Служебные слова, не несущие смысла для поиска.
+var stopWords = map[string]struct{}{
+	"что": {}, "кто": {}, "как": {}, "где": {}, "когда": {}, "почему": {}, "зачем": {}, "какой": {}, "какая": {}, "какие": {},
+	"ли": {}, "ещё": {}, "еще": {}, "это": {}, "этот": {}, "эта": {}, "эти": {}, "был": {}, "была": {}, "были": {}, "было": {},
+	"для": {}, "про": {}, "при": {}, "или": {}, "кто-нибудь": {}, "кто-то": {}, "нибудь": {}, "там": {}, "тут": {}, "уже": {},
+	"the": {}, "and": {}, "did": {}, "does": {}, "was": {}, "were": {}, "what": {}, "who": {}, "when": {}, "where": {}, "why": {},
+	"how": {}, "anyone": {}, "any": {}, "about": {}, "this": {}, "that": {}, "for": {}, "with": {},
+}
+
+// AskQuestion отвечает на вопрос пользователя по загруженным сообщениям чата.
+// Для ответа LLM получает только относящиеся к вопросу сообщения и ветки ответов на них.
+func (g *Gist) AskQuestion(ctx context.Context, chatID int64, question string) (*model.Answer, error) {
+	ctx, span := tracing.Start(ctx, "core.AskQuestion", attribute.Int64("chat_id", chatID))
+	defer span.End()
+
+	log := slog.With("func", "core.AskQuestion", slog.Int64("chat_id", chatID))
+
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("core.AskQuestion: empty question")
+	}
+
+	chat, errC := g.chat(chatID)
+	if errC != nil {
+		return nil, fmt.Errorf("core.AskQuestion: %w", errC)
+	}
+
+	g.mu.RLock()
+	target := *chat
+	g.mu.RUnlock()
+
+	messages := target.Messages
+	if messages == nil { // Сообщения еще не загружены, загружаем по текущему диапазону чата
+		fetched, skipped, errF := g.tgClient.FetchMessages(ctx, &target, target.Range, func(string, int, bool) {})
+		if errF != nil {
+			return nil, fmt.Errorf("core.AskQuestion: %w", errF)
+		}
+
+		g.mu.Lock()
+		if chat.Range.Equal(target.Range) && chat.Messages == nil {
+			chat.Messages = fetched
+			chat.RepliesMarked = false
+			chat.Skipped = skipped
+		}
+		g.mu.Unlock()
+
+		messages = fetched
+	}
+
+	if len(messages) == 0 {
+		return &model.Answer{Text: "В чате нет сообщений для поиска ответа."}, nil
+	}
+
+	relevant := selectRelevantMessages(messages, question, g.cfg.Live().QAMaxMessages)
+	log.Debug("relevant messages selected", slog.Int("count", len(relevant)), slog.Int("total", len(messages)))
+
+	answer, errA := g.llmClient.AnswerQuestion(g.llmChatContext(ctx, chatID, model.UsageAnswer, model.LLMModel{}), question, relevant)
+	if errA != nil {
+		return nil, fmt.Errorf("core.AskQuestion: %w", errA)
+	}
+
+	return answer, nil
+}
+
+// selectRelevantMessages выбирает сообщения, содержащие ключевые слова вопроса, вместе с сообщениями из их веток ответов.
+// Если совпадений нет, возвращает последние limit сообщений. Результат в хронологическом порядке.
+func selectRelevantMessages(messages []model.Message, question string, limit int) []model.Message {
+	if limit <= 0 || limit > len(messages) {
+		limit = len(messages)
+	}
+
+	keywords := extractKeywords(question)
+
+	type scored struct {
+		index int
+		score int
+	}
+	matches := make([]scored, 0)
+	for i := range messages {
+		if score := matchKeywords(messages[i].Text, keywords); score > 0 {
+			matches = append(matches, scored{index: i, score: score})
+		}
+	}
+
+	if len(matches) == 0 {
+		return slices.Clone(messages[len(messages)-limit:])
+	}
+
+	// Сначала сообщения с наибольшим количеством совпадений, при равенстве более свежие
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return b.index - a.index
+	})
+
+	byID := make(map[int]int, len(messages)) // индекс сообщения по ID
+	replies := make(map[int][]int)           // индексы ответов по ID сообщения
+	for i := range messages {
+		byID[messages[i].ID] = i
+		if messages[i].ReplyToMsgID != 0 {
+			replies[messages[i].ReplyToMsgID] = append(replies[messages[i].ReplyToMsgID], i)
+		}
+	}
+
+	selected := make(map[int]struct{}, limit)
+	add := func(i int) {
+		if len(selected) < limit {
+			selected[i] = struct{}{}
+		}
+	}
+
+	for _, m := range matches {
+		if len(selected) >= limit {
+			break
+		}
+		add(m.index)
+
+		// Ветка: сообщение, на которое ответили, и ответы на найденное сообщение
+		if parent, ok := byID[messages[m.index].ReplyToMsgID]; ok {
+			add(parent)
+		}
+		for _, reply := range replies[messages[m.index].ID] {
+			add(reply)
+		}
+	}
+
+	indexes := make([]int, 0, len(selected))
+	for i := range selected {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+
+	result := make([]model.Message, 0, len(indexes))
+	for _, i := range indexes {
+		result = append(result, messages[i])
+	}
+
+	return result
+}
+
+// extractKeywords выделяет из вопроса основы значимых слов.
+func extractKeywords(question string) []string {
+	keywords := make([]string, 0)
+	for _, word := range splitWords(question) {
+		if utf8.RuneCountInString(word) < minKeywordLength {
+			continue
+		}
+		if _, ok := stopWords[word]; ok {
+			continue
+		}
+		stem := stemWord(word)
+		if !slices.Contains(keywords, stem) {
+			keywords = append(keywords, stem)
+		}
+	}
+	return keywords
+}
+
+// matchKeywords возвращает количество ключевых слов, встречающихся в тексте.
+func matchKeywords(text string, keywords []string) int {
+	if len(keywords) == 0 {
+		return 0
+	}
+
+	words := splitWords(text)
+	score := 0
+	for _, keyword := range keywords {
+		if slices.ContainsFunc(words, func(word string) bool { return strings.HasPrefix(word, keyword) }) {
+			score++
+		}
+	}
+	return score
+}
+
+// splitWords разбивает текст на слова в нижнем регистре. @упоминания и #хэштеги сохраняются как слова.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '@' && r != '#' && r != '_' && r != '-'
+	})
+}
+
+// stemWord обрезает слово до основы фиксированной длины.
+func stemWord(word string) string {
+	runes := []rune(word)
+	if len(runes) > keywordStemment {
+		return string(runes[:keywordStemment])
+	}
+	return word
+}
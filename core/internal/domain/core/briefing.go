@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
+)
+
+// StartBriefingJob ставит в очередь задачу сводки: краткие пересказы непрочитанных чатов выше порога, в порядке важности.
+// Количество чатов ограничено settings.briefing_chats. Готовые пересказы непрочитанных берутся из кэша, новые сохраняются в кэш,
+// как при пересказе каждого чата отдельно. Каждый чат в сводке - один абзац, сведенный LLM из пересказов батчей.
+func (g *Gist) StartBriefingJob(_ context.Context, callback func(job model.Job)) (model.Job, error) {
+	job := &model.Job{
+		Kind:      model.JobBriefing,
+		ChatTitle: "непрочитанные чаты",
+	}
+
+	return g.jobs.submit(job, func(ctx context.Context, job *model.Job) error {
+		briefing, errB := g.briefing(ctx, job, g.jobProgress(job, callback))
+		if errB != nil {
+			return errB
+		}
+
+		g.jobs.update(job, func(j *model.Job) {
+			j.Briefing = briefing
+		})
+		return nil
+	})
+}
+
+// briefing пересказывает непрочитанные чаты по очереди. Ошибка пересказа чата сохраняется в сводке, остальные чаты пересказываются.
+func (g *Gist) briefing(ctx context.Context, parent *model.Job, progress func(message string, part int, llm bool)) (*model.Briefing, error) {
+	ctx, span := tracing.Start(ctx, "core.Briefing")
+	defer span.End()
+
+	log := slog.With("func", "core.Briefing")
+
+	progress("📰 Выбираем непрочитанные чаты...", 0, false)
+
+	chats, errU := g.GetChatsWithUnreadMessages(ctx, model.OrderByImportance)
+	if errU != nil {
+		return nil, fmt.Errorf("core.Briefing: %w", errU)
+	}
+
+	briefing := &model.Briefing{}
+	if limit := g.cfg.Live().BriefingChats; len(chats) > limit {
+		briefing.Skipped = len(chats) - limit
+		chats = chats[:limit]
+	}
+
+	for i := range chats {
+		if errC := ctx.Err(); errC != nil {
+			return nil, fmt.Errorf("core.Briefing: %w", errC)
+		}
+
+		progress(fmt.Sprintf("📰 Пересказ %d из %d: «%s»", i+1, len(chats), chats[i].Title), i*100/len(chats), true)
+
+		item := model.BriefingChat{
+			ChatID:      chats[i].ID,
+			Title:       chats[i].Title,
+			UnreadCount: chats[i].UnreadCount,
+		}
+
+		batches, errG := g.briefingGist(ctx, parent, &chats[i])
+		if errG == nil {
+			item.Summary, errG = g.briefingSummary(ctx, &chats[i], batches)
+		}
+		if errG != nil {
+			log.Error("chat gist error", slog.Int64("chat_id", chats[i].ID), slog.Any("error", errG))
+			item.Err = errG
+		} else {
+			item.Pages = len(batches)
+		}
+
+		briefing.Chats = append(briefing.Chats, item)
+	}
+
+	return briefing, nil
+}
+
+// briefingGist возвращает пересказ непрочитанных сообщений чата. Готовый пересказ берется из кэша,
+// иначе генерируется вложенной задачей JobGist: она участвует в проверке дублей и не занимает второй слот очереди.
+// Пересказ за произвольный период в кэше не сбрасывается, чат попадает в сводку с ошибкой model.ErrCustomRangeGist.
+func (g *Gist) briefingGist(ctx context.Context, parent *model.Job, chat *model.Chat) ([]model.BatchGist, error) {
+	g.mu.RLock()
+	cached, ok := g.cache[chat.ID]
+	var batches []model.BatchGist
+	custom := false
+	if ok {
+		if cached.Range.IsUnread() && !cached.GistPartial {
+			batches = slices.Clone(cached.Gist)
+		}
+		custom = !cached.Range.IsUnread() && len(cached.Gist) > 0
+	}
+	g.mu.RUnlock()
+
+	switch {
+	case len(batches) > 0:
+		return batches, nil
+	case custom:
+		return nil, model.ErrCustomRangeGist
+	}
+
+	opts := model.GistOptions{MentionsBlock: !g.GetSettings(ctx).SkipMentionsBlock}
+	job := &model.Job{
+		Kind:      model.JobGist,
+		ChatID:    chat.ID,
+		ChatTitle: chat.Title,
+		Parent:    parent.ID,
+	}
+
+	result, errJ := g.jobs.runNested(ctx, job, func(ctx context.Context, job *model.Job) error {
+		gist, errG := g.GetChatGist(ctx, chat.ID, opts, g.jobProgress(job, nil))
+		if errG != nil {
+			return errG
+		}
+
+		g.jobs.update(job, func(j *model.Job) {
+			j.Gist = gist
+		})
+		return nil
+	})
+	if errJ != nil {
+		return nil, errJ
+	}
+	return result.Gist, nil
+}
+
+// briefingSummary сводит пересказы батчей чата в один абзац сводки вторым запросом к LLM.
+func (g *Gist) briefingSummary(ctx context.Context, chat *model.Chat, batches []model.BatchGist) (string, error) {
+	gists := make([]string, 0, len(batches))
+	for _, batch := range batches {
+		gists = append(gists, batch.Gist)
+	}
+
+	summary, errS := g.llmClient.SummarizeBriefing(g.llmContext(ctx, chat.ID, model.UsageBriefing), chat.Title, gists)
+	if errS != nil {
+		return "", fmt.Errorf("core.briefingSummary: %w", errS)
+	}
+	return summary, nil
+}
+
+// MarkBriefingAsRead отмечает прочитанными чаты сводки, сформированной задачей jobID, до последнего пересказанного сообщения.
+// Возвращает количество отмеченных чатов. Ошибки отдельных чатов объединяются, остальные чаты отмечаются.
+func (g *Gist) MarkBriefingAsRead(ctx context.Context, jobID int64) (int, error) {
+	job, ok := g.jobs.get(jobID)
+	if !ok || job.Briefing == nil {
+		return 0, fmt.Errorf("core.MarkBriefingAsRead: %w", model.ErrBriefingNotFound)
+	}
+
+	marked := 0
+	var errs []error
+	for _, chat := range job.Briefing.Chats {
+		if chat.Err != nil || chat.Pages == 0 {
+			continue
+		}
+		if _, errM := g.MarkAsRead(ctx, chat.ChatID, chat.Pages); errM != nil {
+			errs = append(errs, fmt.Errorf("chat %q: %w", chat.Title, errM))
+			continue
+		}
+		marked++
+	}
+
+	if len(errs) > 0 {
+		return marked, fmt.Errorf("core.MarkBriefingAsRead: %w", errors.Join(errs...))
+	}
+	return marked, nil
+}
@@ -3,6 +3,7 @@ package core
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
@@ -12,34 +13,95 @@ import (
 // TelegramClient контракт для работы с телеграмм клиентом
 type TelegramClient interface {
 	GetAllChats(ctx context.Context) ([]model.Chat, error)
-	FetchUnreadMessages(ctx context.Context, chat *model.Chat, callback func(message string, count int, llm bool)) ([]model.Message, int, error)
+	FetchMessages(ctx context.Context, chat *model.Chat, rng model.MessageRange, callback func(message string, count int, llm bool)) ([]model.Message, int, error)
 	MarkAsRead(ctx context.Context, chat *model.Chat, lastMessageID int) error
+	GetMentions(ctx context.Context, chat *model.Chat) ([]model.Message, error)        // Непрочитанные упоминания и ответы пользователю
+	MarkRepliesToMe(ctx context.Context, chat *model.Chat, msgs []model.Message) error // Отмечает ответы на сообщения пользователя
+	SetWatchRules(rules []model.WatchRule) error                                       // Правила, по которым проверяются входящие сообщения
+	SetAlertHandler(handler func(ctx context.Context, alert model.WatchAlert))         // Обработчик сработавших правил
+	GetTopPeers(ctx context.Context) (map[int64]float64, error)                        // Рейтинг общения пользователя с чатами
 }
 
 // LLMClient контракт для работы с LLM
 type LLMClient interface {
-	GenerateChatGist(ctx context.Context, messages []model.Message, callback func(message string, progress int, llm bool)) ([]model.BatchGist, error)
-	GenerateAudioGist(ctx context.Context, chat *model.Chat, batchID int) error // Генерирует аудиопересказы по каждому из батчей
+	GenerateChatGist(ctx context.Context, messages []model.Message, onBatch func(batch model.BatchGist), callback func(message string, progress int, llm bool)) ([]model.BatchGist, error)
+	GenerateAudioGist(ctx context.Context, chat *model.Chat, batchID int, dir string) error // Генерирует аудиопересказы по каждому из батчей
+	AnswerQuestion(ctx context.Context, question string, messages []model.Message) (*model.Answer, error)
+	EstimateUrgency(ctx context.Context, messages []model.Message) (int, error)          // Срочность сообщений 1..10
+	SummarizeBriefing(ctx context.Context, title string, gists []string) (string, error) // Абзац сводки по пересказам батчей чата
+	ListModels(ctx context.Context, provider string) ([]string, error)                   // Модели, доступные у провайдера
+}
+
+// CheckpointStore контракт хранилища промежуточных результатов генерации пересказа.
+type CheckpointStore interface {
+	SaveCheckpoint(ctx context.Context, chatID int64, batch model.BatchGist) error
+	LoadCheckpoints(ctx context.Context, chatID int64) ([]model.BatchGist, error)
+	DeleteCheckpoints(ctx context.Context, chatID int64, lastMessageID int) error // Удаляет батчи, заканчивающиеся не позже lastMessageID. 0 - удаляет все.
+	CheckpointChats(ctx context.Context) ([]int64, error)
+}
+
+// WatchStore контракт хранилища правил списка наблюдения.
+type WatchStore interface {
+	LoadWatchRules(ctx context.Context) ([]model.WatchRule, error)
+	SaveWatchRules(ctx context.Context, rules []model.WatchRule) error
+}
+
+// Store контракт хранилища данных приложения.
+type Store interface {
+	CheckpointStore
+	WatchStore
+	SettingsStore
+	UsageStore
+	HiddenStore
 }
 
 // Gist представляет ядро бизнес-логики приложения.
 type Gist struct {
 	tgClient  TelegramClient
 	llmClient LLMClient
+	store     Store // Сохраненные батчи пересказа, список наблюдения, скрытые чаты
 
+	mu         sync.RWMutex          // Защищает кэш, с ним одновременно работают обработчики бота и фоновые задачи
 	cache      map[int64]*model.Chat // Для быстрого доступа TODO вынести кэш в отдельный слой?
-	chats      []model.Chat
+	order      []int64               // ID чатов в порядке сортировки
 	lastUpdate time.Time
 	ttl        time.Duration
 
-	UnreadThreshold int
-	cfg             *config.Config
+	jobs *jobQueue // Очередь фоновых задач
+
+	watchMu       sync.Mutex
+	watchRules    []model.WatchRule // Список наблюдения
+	alertNotifier AlertNotifier
+
+	ranking ranking // Данные для оценки важности чатов
+
+	settingsMu sync.RWMutex
+	settings   model.Settings // Настройки пользователя
+
+	usageMu     sync.Mutex
+	usage       []model.UsageRecord // Расход LLM по дням
+	usageFlush  *time.Timer         // Отложенное сохранение расхода, nil - несохраненных изменений нет
+	usageSaveMu sync.Mutex
+
+	hiddenMu sync.RWMutex
+	hidden   []model.HiddenChat // Скрытые чаты, не попадают в список непрочитанных, сводку и упоминания
+
+	cfg       *config.Config
+	audioPath string // Каталог файлов аудиопересказа пользователя
 
 	requestTimeout time.Duration
 }
 
+// SetAudioPath задает каталог файлов аудиопересказа. У каждого пользователя бота свой каталог, чаты у пользователей могут совпадать.
+func (g *Gist) SetAudioPath(path string) {
+	g.audioPath = path
+}
+
 // ChangeFavorites добавление чата в избранное
 func (g *Gist) ChangeFavorites(_ context.Context, chatID int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	// TODO implement me, save to DB
 	chat, ok := g.cache[chatID]
 	if !ok {
@@ -50,8 +112,25 @@ func (g *Gist) ChangeFavorites(_ context.Context, chatID int64) error {
 	return nil
 }
 
-// GetChatDetail Получение информации о чате из кэша
+// GetChatDetail Получение информации о чате из кэша. Возвращает копию, чат в кэше может изменяться фоновыми задачами.
 func (g *Gist) GetChatDetail(_ context.Context, chatID int64) (*model.Chat, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	chat, ok := g.cache[chatID]
+	if !ok {
+		return nil, model.ErrChatNotFoundInCache
+	}
+	detail := *chat
+	detail.IsHidden = g.isHidden(chatID)
+	return &detail, nil
+}
+
+// chat возвращает указатель на чат в кэше, для изменения под блокировкой g.mu.
+func (g *Gist) chat(chatID int64) (*model.Chat, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
 	chat, ok := g.cache[chatID]
 	if !ok {
 		return nil, model.ErrChatNotFoundInCache
@@ -59,14 +138,45 @@ func (g *Gist) GetChatDetail(_ context.Context, chatID int64) (*model.Chat, erro
 	return chat, nil
 }
 
+// resetGist удаляет загруженные сообщения и пересказы чата вместе с файлами аудиопересказа. Вызывается под блокировкой g.mu.
+// Если для чата выполняется задача аудиопересказа, файлы не удаляются: задача их читает и отправляет пользователю.
+// Имена файлов постоянные для чата и батча, следующая генерация их перезапишет.
+func (g *Gist) resetGist(chat *model.Chat) {
+	if !g.jobs.active(model.JobAudio, chat.ID) {
+		for i := range chat.Gist {
+			for _, audio := range chat.Gist[i].Audio {
+				deleteFile(audio.AudioFile)
+			}
+		}
+		for _, audio := range chat.Audio {
+			deleteFile(audio.AudioFile)
+		}
+	}
+
+	chat.Messages = nil
+	chat.RepliesMarked = false
+	chat.Skipped = 0
+	chat.Gist = nil
+	chat.GistPartial = false
+	chat.AboutMe = nil
+	chat.Audio = nil
+}
+
 // NewGist конструктор
-func NewGist(tgClient TelegramClient, llmClient LLMClient, cfg *config.Config) *Gist {
-	return &Gist{
-		tgClient:        tgClient,
-		llmClient:       llmClient,
-		requestTimeout:  cfg.Client.RequestTimeout,
-		UnreadThreshold: cfg.Settings.ChatUnreadThreshold,
-		ttl:             cfg.Project.TTL,
-		cfg:             cfg,
+func NewGist(tgClient TelegramClient, llmClient LLMClient, store Store, cfg *config.Config) *Gist {
+	g := &Gist{
+		tgClient:       tgClient,
+		llmClient:      llmClient,
+		store:          store,
+		requestTimeout: cfg.Client.RequestTimeout,
+		ttl:            cfg.Project.TTL,
+		cfg:            cfg,
+		audioPath:      cfg.Project.AudioPath,
+		cache:          make(map[int64]*model.Chat),
+		jobs:           newJobQueue(cfg.Settings.JobParallelism, cfg.Settings.JobHistory, cfg.Client.RequestTimeout),
+		ranking:        ranking{urgency: make(map[int64]urgencyEstimate)},
 	}
+	g.settings = g.storedSettings(DefaultSettings(cfg))
+
+	return g
 }
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+)
+
+var errFakeLLM = errors.New("llm failed")
+
+// fakeStore хранилище в памяти. Методы, не нужные тестам, не реализованы.
+type fakeStore struct {
+	Store
+
+	mu          sync.Mutex
+	checkpoints map[int64][]model.BatchGist
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{checkpoints: make(map[int64][]model.BatchGist)}
+}
+
+func (s *fakeStore) SaveCheckpoint(_ context.Context, chatID int64, batch model.BatchGist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[chatID] = append(s.checkpoints[chatID], batch)
+	return nil
+}
+
+func (s *fakeStore) LoadCheckpoints(_ context.Context, chatID int64) ([]model.BatchGist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoints := slices.Clone(s.checkpoints[chatID])
+	slices.SortFunc(checkpoints, func(a, b model.BatchGist) int { return a.FirstMessageID - b.FirstMessageID })
+	return checkpoints, nil
+}
+
+func (s *fakeStore) DeleteCheckpoints(_ context.Context, chatID int64, lastMessageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[chatID] = slices.DeleteFunc(s.checkpoints[chatID], func(b model.BatchGist) bool {
+		return lastMessageID == 0 || b.LastMessageID <= lastMessageID
+	})
+	if len(s.checkpoints[chatID]) == 0 {
+		delete(s.checkpoints, chatID)
+	}
+	return nil
+}
+
+func (s *fakeStore) CheckpointChats(context.Context) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := make([]int64, 0, len(s.checkpoints))
+	for chatID := range s.checkpoints {
+		chats = append(chats, chatID)
+	}
+	return chats, nil
+}
+
+// fakeTelegram клиент Telegram, отдающий заданные сообщения.
+type fakeTelegram struct {
+	TelegramClient
+
+	messages []model.Message
+	peers    map[int64]float64 // Рейтинг top peers
+	fetches  atomic.Int32      // Количество загрузок сообщений
+}
+
+func (f *fakeTelegram) FetchMessages(context.Context, *model.Chat, model.MessageRange, func(string, int, bool)) ([]model.Message, int, error) {
+	f.fetches.Add(1)
+	return slices.Clone(f.messages), 0, nil
+}
+
+func (f *fakeTelegram) GetTopPeers(context.Context) (map[int64]float64, error) {
+	return f.peers, nil
+}
+
+func (f *fakeTelegram) MarkRepliesToMe(context.Context, *model.Chat, []model.Message) error {
+	return nil
+}
+
+// fakeLLM пересказывает сообщения батчами по batchSize. failAfter > 0 - ошибка после failAfter готовых батчей,
+// failAfter < 0 - ошибка оценки срочности.
+type fakeLLM struct {
+	LLMClient
+
+	batchSize int
+	failAfter int
+	urgency   map[int]int // Срочность по ID первого сообщения
+
+	mu       sync.Mutex
+	received [][]int // ID сообщений каждого вызова GenerateChatGist
+}
+
+func (f *fakeLLM) GenerateChatGist(_ context.Context, messages []model.Message, onBatch func(batch model.BatchGist), _ func(string, int, bool)) ([]model.BatchGist, error) {
+	ids := make([]int, 0, len(messages))
+	for i := range messages {
+		ids = append(ids, messages[i].ID)
+	}
+	f.mu.Lock()
+	f.received = append(f.received, ids)
+	f.mu.Unlock()
+
+	var gist []model.BatchGist
+	for chunk := range slices.Chunk(messages, f.batchSize) {
+		if f.failAfter > 0 && len(gist) == f.failAfter {
+			return nil, errFakeLLM
+		}
+		batch := model.BatchGist{
+			FirstMessageID: chunk[0].ID,
+			LastMessageID:  chunk[len(chunk)-1].ID,
+			MessageCount:   len(chunk),
+			Gist:           fmt.Sprintf("gist %d-%d", chunk[0].ID, chunk[len(chunk)-1].ID),
+		}
+		onBatch(batch)
+		gist = append(gist, batch)
+	}
+	return gist, nil
+}
+
+func (f *fakeLLM) EstimateUrgency(_ context.Context, messages []model.Message) (int, error) {
+	if f.failAfter < 0 {
+		return 0, errFakeLLM
+	}
+	return f.urgency[messages[0].ID], nil
+}
+
+// newTestGist слой бизнес-логики с фейковыми зависимостями и минимальной конфигурацией.
+func newTestGist(tg TelegramClient, llm LLMClient, store Store) *Gist {
+	cfg := &config.Config{}
+	cfg.Project.TTL = time.Hour
+	cfg.Client.RequestTimeout = time.Second
+	cfg.Settings.JobParallelism = 1
+	cfg.Settings.JobHistory = 10
+	cfg.LLM.MessagesPerBatch = 100
+
+	return NewGist(tg, llm, store, cfg)
+}
+
+// testMessages сообщения с ID from..to.
+func testMessages(from, to int) []model.Message {
+	messages := make([]model.Message, 0, to-from+1)
+	for id := from; id <= to; id++ {
+		messages = append(messages, model.Message{ID: id, Text: fmt.Sprintf("message %d", id)})
+	}
+	return messages
+}
@@ -7,16 +7,27 @@ import (
 	"time"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
 )
 
 // GetAllChats возвращает список всех чатов пользователя.
 func (g *Gist) GetAllChats(ctx context.Context) ([]model.Chat, error) {
+	ctx, span := tracing.Start(ctx, "core.GetAllChats")
+	defer span.End()
+
 	log := slog.With("func", "core.GetAllChats")
 	log.Debug("Get all chats")
 
+	g.mu.RLock()
 	if time.Since(g.lastUpdate) < g.ttl { // Ходим в кеш, пока не вышел TTL
-		return g.chats, nil
+		chats := g.snapshot()
+		g.mu.RUnlock()
+		metrics.IncCache("chats", true)
+		return chats, nil
 	}
+	g.mu.RUnlock()
+	metrics.IncCache("chats", false)
 
 	ctxClient, cancelClient := context.WithTimeout(ctx, g.requestTimeout) // Контекст ограничивающий время выполнения запроса (включая закрытие горутин аутентификации в боте и клиенте по тайм-ауту)
 	defer cancelClient()
@@ -31,15 +42,77 @@ func (g *Gist) GetAllChats(ctx context.Context) ([]model.Chat, error) {
 		return chats[i].UnreadCount > chats[j].UnreadCount
 	})
 
+	partial := g.loadPartialGists(ctx) // Пересказы, генерация которых была прервана (например, перезапуском приложения)
+
 	// Сохраняем полученные чаты в инмемори
-	g.lastUpdate = time.Now()
-	g.chats = chats
-	g.cache = make(map[int64]*model.Chat)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cache := make(map[int64]*model.Chat, len(chats))
+	order := make([]int64, 0, len(chats))
 	for i := range chats {
-		g.cache[chats[i].ID] = &chats[i]
+		chat := &chats[i]
+		if old, ok := g.cache[chat.ID]; ok { // Чат уже в кэше, обновляем по месту, чтобы не потерять пересказы и результаты выполняемых задач
+			if old.Range.IsUnread() && (old.LastReadMessageID != chat.LastReadMessageID || old.UnreadCount != chat.UnreadCount) {
+				old.Messages = nil // Появились новые сообщения, загруженные устарели
+				old.Skipped = 0
+			}
+			old.Title = chat.Title
+			old.UnreadCount = chat.UnreadCount
+			old.UnreadMentions = chat.UnreadMentions
+			old.UnreadReactions = chat.UnreadReactions
+			old.LastReadMessageID = chat.LastReadMessageID
+			old.Peer = chat.Peer
+			chat = old
+		} else if gist, ok := partial[chat.ID]; ok && gist[0].FirstMessageID > chat.LastReadMessageID {
+			chat.Gist = gist
+			chat.GistPartial = true
+		}
+		cache[chat.ID] = chat
+		order = append(order, chat.ID)
 	}
 
+	g.lastUpdate = time.Now()
+	g.cache = cache
+	g.order = order
+
 	log.Debug("Successfully get all chats", slog.Any("chats count", len(chats)))
 
-	return chats, nil
+	return g.snapshot(), nil
+}
+
+// loadPartialGists загружает сохраненные батчи пересказов из хранилища.
+func (g *Gist) loadPartialGists(ctx context.Context) map[int64][]model.BatchGist {
+	log := slog.With("func", "core.loadPartialGists")
+
+	chatIDs, errC := g.store.CheckpointChats(ctx)
+	if errC != nil {
+		log.Error("get checkpoint chats error", slog.Any("error", errC))
+		return nil
+	}
+
+	partial := make(map[int64][]model.BatchGist, len(chatIDs))
+	for _, chatID := range chatIDs {
+		gist, errL := g.store.LoadCheckpoints(ctx, chatID)
+		if errL != nil {
+			log.Error("load checkpoints error", slog.Int64("chat_id", chatID), slog.Any("error", errL))
+			continue
+		}
+		if len(gist) > 0 {
+			partial[chatID] = gist
+		}
+	}
+
+	return partial
+}
+
+// snapshot возвращает копии чатов из кэша в порядке сортировки. Вызывается под блокировкой g.mu.
+func (g *Gist) snapshot() []model.Chat {
+	chats := make([]model.Chat, 0, len(g.order))
+	for _, id := range g.order {
+		chat := *g.cache[id]
+		chat.IsHidden = g.isHidden(id)
+		chats = append(chats, chat)
+	}
+	return chats
 }
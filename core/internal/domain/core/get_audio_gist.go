@@ -6,24 +6,55 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
 	"github.com/arslanovdi/Gist/core/internal/infra/ffmpeg"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
 	"github.com/arslanovdi/Gist/core/internal/infra/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // GetAudioGist возвращает имя файла с аудиопересказом
 // batchID - номер батча, для которого нужно вернуть аудиопересказ, если batchID = 0 возвращаем аудиопересказ всего чата	todo потестить режимы.
 func (g *Gist) GetAudioGist(ctx context.Context, chatID int64, batchID int) ([]model.AudioGist, error) {
+	ctx, span := tracing.Start(ctx, "core.GetAudioGist", attribute.Int64("chat_id", chatID))
+	defer span.End()
 
-	log := slog.With("func", "core.GetAudioGist")
-	log.Debug("start GetAudioGist")
-
-	chat, errD := g.GetChatDetail(ctx, chatID)
+	chat, errD := g.chat(chatID)
 	if errD != nil {
 		return nil, fmt.Errorf("get chat detail: %w", errD)
 	}
 
+	g.mu.RLock()
+	target := *chat // Генерация идет на копии, чат в кэше не блокируем на время долгих операций
+	target.Gist = slices.Clone(chat.Gist)
+	g.mu.RUnlock()
+
+	audio, errA := g.generateAudioGist(ctx, &target, batchID)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Сохраняем сгенерированные файлы в кэш, если пересказ не изменился за время генерации
+	for i := range target.Gist {
+		if i < len(chat.Gist) && chat.Gist[i].LastMessageID == target.Gist[i].LastMessageID {
+			chat.Gist[i].Audio = target.Gist[i].Audio
+		}
+	}
+	if len(chat.Gist) == len(target.Gist) {
+		chat.Audio = target.Audio
+	}
+
+	return audio, errA
+}
+
+// generateAudioGist генерирует аудиопересказ, результат сохраняется в chat по указателю.
+func (g *Gist) generateAudioGist(ctx context.Context, chat *model.Chat, batchID int) ([]model.AudioGist, error) {
+
+	log := slog.With("func", "core.GetAudioGist")
+	log.Debug("start GetAudioGist")
+
 	// Если batchGist пустой, возвращаем ошибку
 	if len(chat.Gist) == 0 {
 		return nil, fmt.Errorf("batchGist is empty")
@@ -40,7 +71,7 @@ func (g *Gist) GetAudioGist(ctx context.Context, chatID int64, batchID int) ([]m
 		}
 
 		// Генерируем аудиопересказ, сохраняется в chat по указателю
-		errG := g.llmClient.GenerateAudioGist(ctx, chat, batchID)
+		errG := g.llmClient.GenerateAudioGist(g.llmContext(ctx, chat.ID, model.UsageAudio), chat, batchID, g.audioPath)
 		if errG != nil {
 			return nil, fmt.Errorf("core.GetAudioGist generate error: %w", errG)
 		}
@@ -59,7 +90,7 @@ func (g *Gist) GetAudioGist(ctx context.Context, chatID int64, batchID int) ([]m
 			log.Debug("Нет аудиопересказа батча", slog.Int("batch index", i))
 
 			// Генерируем аудиопересказы, при batchID = 0 сгенерируются все отсутствующие
-			errG := g.llmClient.GenerateAudioGist(ctx, chat, 0)
+			errG := g.llmClient.GenerateAudioGist(g.llmContext(ctx, chat.ID, model.UsageAudio), chat, 0, g.audioPath)
 			if errG != nil {
 				return nil, fmt.Errorf("core.GetAudioGist generate error: %w", errG)
 			}
@@ -74,10 +105,10 @@ func (g *Gist) GetAudioGist(ctx context.Context, chatID int64, batchID int) ([]m
 		}
 	}
 
-	audioFile := filepath.Join(g.cfg.Project.AudioPath, fmt.Sprintf("%d.mp3", chat.ID))
+	audioFile := filepath.Join(g.audioPath, fmt.Sprintf("%d.mp3", chat.ID))
 
 	// собираем полный аудиопересказ из батчей
-	errC := ffmpeg.ConcatMP3(list, audioFile)
+	errC := ffmpeg.ConcatMP3(ctx, list, audioFile)
 	if errC != nil {
 		return nil, fmt.Errorf("core.GetAudioGist ffmpeg concatMP3: %w", errC)
 	}
@@ -100,7 +131,7 @@ func (g *Gist) GetAudioGist(ctx context.Context, chatID int64, batchID int) ([]m
 	}
 
 	if info.Size() > g.cfg.LLM.TTS.MaxAudioFileSize*1024*1024 { // Размер файла превышает максимально разрешенный
-		files, errT := ffmpeg.SplitMP3(audioFile, g.cfg.LLM.TTS.MaxAudioFileSize) // Разбиваем на несколько
+		files, errT := ffmpeg.SplitMP3(ctx, audioFile, g.cfg.LLM.TTS.MaxAudioFileSize) // Разбиваем на несколько
 		if errT != nil {
 			log.Error("Trim audiofile error", slog.String("filename", audioFile), slog.Any("error", errT))
 		}
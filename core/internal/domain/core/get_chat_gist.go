@@ -2,38 +2,201 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"slices"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/metrics"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// GetChatGist возвращает короткий пересказ непрочитанных сообщений чата. Callback - оповещение пользователя о ходе выполнения.
-func (g *Gist) GetChatGist(ctx context.Context, chatID int64, callback func(string, int, bool)) ([]model.BatchGist, error) {
+// GetChatGist возвращает короткий пересказ сообщений чата из диапазона opts.Range (по умолчанию непрочитанных). Callback - оповещение пользователя о ходе выполнения.
+// Каждый готовый батч непрочитанных сообщений сохраняется, прерванная генерация продолжается с первого несделанного батча.
+func (g *Gist) GetChatGist(ctx context.Context, chatID int64, opts model.GistOptions, callback func(string, int, bool)) ([]model.BatchGist, error) {
+	ctx, span := tracing.Start(ctx, "core.GetChatGist", attribute.Int64("chat_id", chatID))
+	defer span.End()
 
 	log := slog.With("func", "core.GetChatGist")
 
-	chat, ok := g.cache[chatID]
-	if !ok {
-		return nil, model.ErrChatNotFoundInCache
+	if errV := opts.Range.Validate(); errV != nil {
+		return nil, fmt.Errorf("core.GetChatGist: %w", errV)
 	}
+	if !opts.Model.IsZero() {
+		m, errM := g.checkModel(opts.Model)
+		if errM != nil {
+			return nil, fmt.Errorf("core.GetChatGist: %w", errM)
+		}
+		opts.Model = m
+	}
+
+	chat, errC := g.chat(chatID)
+	if errC != nil {
+		return nil, errC
+	}
+
+	g.mu.Lock()
+	if !chat.Range.Equal(opts.Range) { // Сменился диапазон, загруженные сообщения и пересказ не подходят
+		g.resetGist(chat)
+		chat.Range = opts.Range
+	}
+	target := *chat // Копия для запросов, чат в кэше не блокируем на время долгих операций
+	g.mu.Unlock()
 
-	if chat.Messages == nil {
-		messages, skipped, errF := g.tgClient.FetchUnreadMessages(ctx, chat, callback) // получаем список непрочитанных сообщений чата
+	messages := target.Messages
+	repliesMarked := target.RepliesMarked
+	metrics.IncCache("messages", messages != nil)
+	if messages == nil {
+		fetched, skipped, errF := g.tgClient.FetchMessages(ctx, &target, opts.Range, callback) // получаем список сообщений чата из диапазона
 		if errF != nil {
 			return nil, errF
 		}
-		chat.Messages = messages
+
+		g.mu.Lock()
+		chat.Messages = fetched
+		chat.RepliesMarked = false
 		chat.Skipped = skipped
+		g.mu.Unlock()
+
+		messages = fetched
+		repliesMarked = false
 	} else {
-		log.Debug("messages already loaded", slog.Int("count", len(chat.Messages)), slog.Int("skipped", chat.Skipped))
+		log.Debug("messages already loaded", slog.Int("count", len(messages)), slog.Int("skipped", target.Skipped))
 	}
 
-	resp, errG := g.llmClient.GenerateChatGist(ctx, chat.Messages, callback) // Выделяем суть из сообщений
-	if errG != nil {
-		return nil, errG
+	var aboutMe []model.Message // Блок "Касается вас"
+	if opts.MentionsBlock {
+		if !repliesMarked {
+			messages = g.markReplies(ctx, chat, &target, messages)
+		}
+		for i := range messages {
+			if messages[i].AboutMe() {
+				aboutMe = append(aboutMe, messages[i])
+			}
+		}
 	}
 
-	chat.Gist = resp
+	g.mu.Lock()
+	chat.AboutMe = aboutMe
+	g.mu.Unlock()
+
+	checkpoints := opts.Range.IsUnread() // Сохраняем батчи только для непрочитанных сообщений, произвольные диапазоны разовые
+
+	var done []model.BatchGist
+	processed := 0
+	if checkpoints {
+		done, processed = g.resumeCheckpoints(ctx, chatID, messages) // Батчи, готовые с прошлого запуска
+	}
+	if len(done) > 0 {
+		log.Info("resume gist generation", slog.Int64("chat_id", chatID), slog.Int("batches", len(done)), slog.Int("messages", processed))
+		callback(fmt.Sprintf("▶️ Продолжаем с батча %d", len(done)+1), 0, false)
+	}
+
+	gist := slices.Clone(done)
+	if processed < len(messages) {
+		resp, errG := g.llmClient.GenerateChatGist(g.llmChatContext(ctx, chatID, model.UsageGist, opts.Model), messages[processed:], func(batch model.BatchGist) {
+			if checkpoints {
+				errS := g.store.SaveCheckpoint(ctx, chatID, batch)
+				if errS != nil {
+					log.Error("save checkpoint error", slog.Any("error", errS))
+				}
+			}
+
+			done = append(done, batch)
+
+			g.mu.Lock()
+			chat.Gist = slices.Clone(done) // Частичный пересказ доступен пользователю до завершения генерации
+			chat.GistPartial = true
+			g.mu.Unlock()
+		}, callback) // Выделяем суть из сообщений
+		if errG != nil {
+			return nil, errG // Готовые батчи сохранены, при повторном запросе генерация продолжится
+		}
+		gist = append(gist, resp...)
+	}
+
+	g.mu.Lock()
+	chat.Gist = gist
+	chat.GistPartial = false
+	g.mu.Unlock()
+
+	if checkpoints {
+		errD := g.store.DeleteCheckpoints(ctx, chatID, 0) // Пересказ готов полностью
+		if errD != nil {
+			log.Error("delete checkpoints error", slog.Any("error", errD))
+		}
+	}
 
-	return chat.Gist, nil
+	return slices.Clone(gist), nil
+}
+
+// resumeCheckpoints возвращает сохраненные батчи, последовательно покрывающие начало списка сообщений, и кол-во покрытых сообщений.
+// Устаревшие батчи (сообщения прочитаны в другом клиенте, изменился состав сообщений) удаляются.
+func (g *Gist) resumeCheckpoints(ctx context.Context, chatID int64, messages []model.Message) ([]model.BatchGist, int) {
+	log := slog.With("func", "core.resumeCheckpoints")
+
+	checkpoints, errL := g.store.LoadCheckpoints(ctx, chatID)
+	if errL != nil {
+		log.Error("load checkpoints error", slog.Any("error", errL))
+		return nil, 0
+	}
+	if len(checkpoints) == 0 {
+		return nil, 0
+	}
+
+	done := make([]model.BatchGist, 0, len(checkpoints))
+	processed := 0
+	for _, cp := range checkpoints {
+		last := processed + cp.MessageCount - 1
+		if cp.MessageCount <= 0 || last >= len(messages) ||
+			messages[processed].ID != cp.FirstMessageID || messages[last].ID != cp.LastMessageID {
+			break
+		}
+		done = append(done, cp)
+		processed += cp.MessageCount
+	}
+
+	if len(done) < len(checkpoints) { // Перезаписываем хранилище только подходящими батчами
+		errD := g.store.DeleteCheckpoints(ctx, chatID, 0)
+		if errD != nil {
+			log.Error("delete stale checkpoints error", slog.Any("error", errD))
+		}
+		for _, cp := range done {
+			errS := g.store.SaveCheckpoint(ctx, chatID, cp)
+			if errS != nil {
+				log.Error("save checkpoint error", slog.Any("error", errS))
+			}
+		}
+	}
+
+	return done, processed
+}
+
+// markReplies отмечает ответы на сообщения пользователя для блока "Касается вас". Отметка требует дополнительных запросов
+// к Telegram, поэтому выполняется только для пересказа с этим блоком и один раз для загруженных сообщений.
+// Ошибка не критична, пересказ можно сделать и без этой отметки.
+func (g *Gist) markReplies(ctx context.Context, chat, target *model.Chat, messages []model.Message) []model.Message {
+	marked := slices.Clone(messages) // Сообщения кэша читают другие задачи, отмечаем копию
+	if errR := g.tgClient.MarkRepliesToMe(ctx, target, marked); errR != nil {
+		slog.With("func", "core.markReplies").Error("mark replies to me error", slog.Any("error", errR))
+		return messages
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if sameMessages(chat.Messages, marked) { // Пока отмечали, сообщения в кэше могли перезагрузить или отметить прочитанными
+		chat.Messages = marked
+		chat.RepliesMarked = true
+	}
+	return marked
+}
+
+// sameMessages слайсы содержат одни и те же сообщения. Сообщения упорядочены по ID и не изменяются, сравниваются границы.
+func sameMessages(a, b []model.Message) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || a[0].ID == b[0].ID && a[len(a)-1].ID == b[len(b)-1].ID
 }
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const testChatID = 10
+
+func batch(first, last, count int) model.BatchGist {
+	return model.BatchGist{FirstMessageID: first, LastMessageID: last, MessageCount: count}
+}
+
+// equalBatches батчи совпадают, nil и пустой слайс равны.
+func equalBatches(a, b []model.BatchGist) bool {
+	return slices.EqualFunc(a, b, func(x, y model.BatchGist) bool { return reflect.DeepEqual(x, y) })
+}
+
+func TestResumeCheckpoints(t *testing.T) {
+	messages := testMessages(1, 6)
+
+	tests := []struct {
+		name          string
+		checkpoints   []model.BatchGist
+		wantDone      int // Количество продолжаемых батчей
+		wantProcessed int
+	}{
+		{name: "no checkpoints"},
+		{
+			name:          "matching",
+			checkpoints:   []model.BatchGist{batch(1, 3, 3), batch(4, 5, 2)},
+			wantDone:      2,
+			wantProcessed: 5,
+		},
+		{
+			name:          "covers all messages",
+			checkpoints:   []model.BatchGist{batch(1, 6, 6)},
+			wantDone:      1,
+			wantProcessed: 6,
+		},
+		{
+			name:        "messages read in another client",
+			checkpoints: []model.BatchGist{batch(0, 2, 3)},
+		},
+		{
+			name:        "changed last message id",
+			checkpoints: []model.BatchGist{batch(1, 4, 3)},
+		},
+		{
+			name:        "more messages than loaded",
+			checkpoints: []model.BatchGist{batch(1, 7, 7)},
+		},
+		{
+			name:        "empty batch",
+			checkpoints: []model.BatchGist{batch(1, 1, 0)},
+		},
+		{
+			name:          "stale tail",
+			checkpoints:   []model.BatchGist{batch(1, 3, 3), batch(4, 7, 3)},
+			wantDone:      1,
+			wantProcessed: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			for _, cp := range tt.checkpoints {
+				_ = store.SaveCheckpoint(context.Background(), testChatID, cp)
+			}
+			g := newTestGist(&fakeTelegram{}, &fakeLLM{}, store)
+
+			done, processed := g.resumeCheckpoints(context.Background(), testChatID, messages)
+
+			if len(done) != tt.wantDone || processed != tt.wantProcessed {
+				t.Fatalf("resumeCheckpoints = %d batches, %d messages; want %d batches, %d messages",
+					len(done), processed, tt.wantDone, tt.wantProcessed)
+			}
+			if !equalBatches(done, tt.checkpoints[:tt.wantDone]) {
+				t.Errorf("resumed batches = %+v, want %+v", done, tt.checkpoints[:tt.wantDone])
+			}
+
+			// В хранилище остаются только продолжаемые батчи
+			stored, _ := store.LoadCheckpoints(context.Background(), testChatID)
+			if !equalBatches(stored, done) {
+				t.Errorf("stored checkpoints = %+v, want %+v", stored, done)
+			}
+		})
+	}
+}
+
+func TestGetChatGistCheckpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		rng         model.MessageRange
+		checkpoints []model.BatchGist
+		failAfter   int
+		wantErr     bool
+		wantLLM     []int             // ID сообщений, переданных LLM
+		wantStored  []model.BatchGist // Батчи в хранилище после запроса
+		wantGist    int               // Количество батчей пересказа в кэше чата
+		wantPartial bool
+	}{
+		{
+			name:     "without checkpoints",
+			wantLLM:  []int{1, 2, 3, 4, 5, 6, 7},
+			wantGist: 3,
+		},
+		{
+			name:        "resume matching checkpoint",
+			checkpoints: []model.BatchGist{batch(1, 3, 3)},
+			wantLLM:     []int{4, 5, 6, 7},
+			wantGist:    3,
+		},
+		{
+			name:        "stale checkpoint discarded",
+			checkpoints: []model.BatchGist{batch(1, 2, 3)},
+			wantLLM:     []int{1, 2, 3, 4, 5, 6, 7},
+			wantGist:    3,
+		},
+		{
+			name:        "everything resumed",
+			checkpoints: []model.BatchGist{batch(1, 3, 3), batch(4, 7, 4)},
+			wantGist:    2,
+		},
+		{
+			name:        "interrupted generation keeps done batches",
+			checkpoints: []model.BatchGist{batch(1, 3, 3)},
+			failAfter:   1,
+			wantErr:     true,
+			wantLLM:     []int{4, 5, 6, 7},
+			wantStored:  []model.BatchGist{batch(1, 3, 3), {FirstMessageID: 4, LastMessageID: 6, MessageCount: 3, Gist: "gist 4-6"}},
+			wantGist:    2,
+			wantPartial: true,
+		},
+		{
+			name:        "custom range ignores checkpoints",
+			rng:         model.MessageRange{Kind: model.RangeLast, Last: 7},
+			checkpoints: []model.BatchGist{batch(1, 3, 3)},
+			wantLLM:     []int{1, 2, 3, 4, 5, 6, 7},
+			wantStored:  []model.BatchGist{batch(1, 3, 3)},
+			wantGist:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := newFakeStore()
+			for _, cp := range tt.checkpoints {
+				_ = store.SaveCheckpoint(ctx, testChatID, cp)
+			}
+			llm := &fakeLLM{batchSize: 3, failAfter: tt.failAfter}
+			g := newTestGist(&fakeTelegram{messages: testMessages(1, 7)}, llm, store)
+			g.cache[testChatID] = &model.Chat{ID: testChatID, UnreadCount: 7}
+
+			gist, err := g.GetChatGist(ctx, testChatID, model.GistOptions{Range: tt.rng}, func(string, int, bool) {})
+			if tt.wantErr {
+				if !errors.Is(err, errFakeLLM) {
+					t.Fatalf("error = %v, want %v", err, errFakeLLM)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(gist) != tt.wantGist {
+					t.Errorf("got %d batches, want %d", len(gist), tt.wantGist)
+				}
+			}
+
+			var llmIDs []int
+			if len(llm.received) > 0 {
+				llmIDs = llm.received[0]
+			}
+			if !slices.Equal(llmIDs, tt.wantLLM) {
+				t.Errorf("LLM got messages %v, want %v", llmIDs, tt.wantLLM)
+			}
+
+			stored, _ := store.LoadCheckpoints(ctx, testChatID)
+			if !equalBatches(stored, tt.wantStored) {
+				t.Errorf("stored checkpoints = %+v, want %+v", stored, tt.wantStored)
+			}
+
+			chat, _ := g.GetChatDetail(ctx, testChatID)
+			if len(chat.Gist) != tt.wantGist || chat.GistPartial != tt.wantPartial {
+				t.Errorf("cached gist = %d batches, partial %v; want %d batches, partial %v",
+					len(chat.Gist), chat.GistPartial, tt.wantGist, tt.wantPartial)
+			}
+		})
+	}
+}
+
+func TestGetChatGistRange(t *testing.T) {
+	since := time.Now().Add(-24 * time.Hour)
+	preset := model.MessageRange{Kind: model.RangeSince, Since: since, Preset: "24h"}
+
+	tests := []struct {
+		name        string
+		next        model.MessageRange // Диапазон повторного запроса
+		wantFetches int32
+	}{
+		{
+			name:        "preset repeated later",
+			next:        model.MessageRange{Kind: model.RangeSince, Since: since.Add(time.Minute), Preset: "24h"},
+			wantFetches: 1,
+		},
+		{
+			name:        "another preset",
+			next:        model.MessageRange{Kind: model.RangeSince, Since: since.Add(-6 * 24 * time.Hour), Preset: "7d"},
+			wantFetches: 2,
+		},
+		{
+			name:        "explicit since",
+			next:        model.MessageRange{Kind: model.RangeSince, Since: since},
+			wantFetches: 2,
+		},
+		{
+			name:        "unread",
+			wantFetches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			tg := &fakeTelegram{messages: testMessages(1, 5)}
+			g := newTestGist(tg, &fakeLLM{batchSize: 5}, newFakeStore())
+			g.cache[testChatID] = &model.Chat{ID: testChatID}
+
+			if _, err := g.GetChatGist(ctx, testChatID, model.GistOptions{Range: preset}, func(string, int, bool) {}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := g.GetChatGist(ctx, testChatID, model.GistOptions{Range: tt.next}, func(string, int, bool) {}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := tg.fetches.Load(); got != tt.wantFetches {
+				t.Errorf("messages fetched %d times, want %d", got, tt.wantFetches)
+			}
+
+			chat, _ := g.GetChatDetail(ctx, testChatID)
+			if !chat.Range.Equal(tt.next) || len(chat.Gist) != 1 {
+				t.Errorf("cached chat range %s with %d batches, want %s with 1 batch", chat.Range, len(chat.Gist), tt.next)
+			}
+		})
+	}
+}
@@ -7,12 +7,18 @@ import (
 	"sort"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
 )
 
 // GetChatsWithUnreadMessages возвращает список чатов с непрочитанными сообщениями.
 //
-// Отбирает чаты, где количество непрочитанных сообщений больше или равно пороговому значению UnreadThreshold
-func (g *Gist) GetChatsWithUnreadMessages(ctx context.Context) ([]model.Chat, error) {
+// Отбирает чаты, где количество непрочитанных сообщений больше или равно пороговому значению из настроек пользователя.
+// Скрытые чаты пропускаются, поэтому они не попадают и в сводку.
+// Сортирует по убыванию количества непрочитанных сообщений или по убыванию оценки важности.
+func (g *Gist) GetChatsWithUnreadMessages(ctx context.Context, order model.ChatOrder) ([]model.Chat, error) {
+	ctx, span := tracing.Start(ctx, "core.GetChatsWithUnreadMessages")
+	defer span.End()
+
 	log := slog.With("func", "core.GetChatsWithUnreadMessages")
 	log.Debug("get chats with unread messages")
 
@@ -21,20 +27,25 @@ func (g *Gist) GetChatsWithUnreadMessages(ctx context.Context) ([]model.Chat, er
 		return nil, fmt.Errorf("GetChatsWithUnreadMessages: %w", errA)
 	}
 
+	threshold := g.GetSettings(ctx).UnreadThreshold
 	unreadChats := make([]model.Chat, 0)
-	// Отбрасываем чаты, где UnreadCount < UnreadThreshold
+	// Отбрасываем чаты, где UnreadCount < threshold, и скрытые чаты
 	if len(chats) == 0 {
 		return nil, fmt.Errorf("GetChatsWithUnreadMessages: no chat found") // TODO обработать ошибку выше
 	}
 	for i := range chats {
-		if chats[i].UnreadCount >= g.UnreadThreshold {
+		if chats[i].UnreadCount >= threshold && !chats[i].IsHidden {
 			unreadChats = append(unreadChats, chats[i])
 		}
 	}
-	// отсортировать по убыванию UnreadCount
-	sort.Slice(unreadChats, func(i, j int) bool {
-		return unreadChats[i].UnreadCount > unreadChats[j].UnreadCount
-	})
+	if order == model.OrderByImportance {
+		g.rankChats(ctx, unreadChats)
+	} else {
+		// отсортировать по убыванию UnreadCount
+		sort.Slice(unreadChats, func(i, j int) bool {
+			return unreadChats[i].UnreadCount > unreadChats[j].UnreadCount
+		})
+	}
 
 	log.Debug("Successfully get chats with unread messages", slog.Any("unread chats count", len(unreadChats)))
 
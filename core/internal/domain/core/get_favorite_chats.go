@@ -6,10 +6,14 @@ import (
 	"log/slog"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
 )
 
 // GetFavoriteChats возвращает список избранных чатов.
 func (g *Gist) GetFavoriteChats(ctx context.Context) ([]model.Chat, error) {
+	ctx, span := tracing.Start(ctx, "core.GetFavoriteChats")
+	defer span.End()
+
 	log := slog.With("func", "core.GetFavoriteChats")
 	log.Debug("get favorite chats")
 
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
+)
+
+const mentionsParallelism = 4 // Одновременных запросов упоминаний к Telegram, больше - чаще FLOOD_WAIT
+
+// GetMentions возвращает непрочитанные упоминания пользователя и ответы на его сообщения по всем чатам.
+// Чаты только с непрочитанными реакциями возвращаются без сообщений. Скрытые чаты пропускаются.
+// Ошибка загрузки упоминаний чата сохраняется в ChatMentions.Err, остальные чаты возвращаются.
+func (g *Gist) GetMentions(ctx context.Context) ([]model.ChatMentions, error) {
+	ctx, span := tracing.Start(ctx, "core.GetMentions")
+	defer span.End()
+
+	log := slog.With("func", "core.GetMentions")
+
+	chats, errG := g.GetAllChats(ctx)
+	if errG != nil {
+		return nil, fmt.Errorf("core.GetMentions: %w", errG)
+	}
+
+	mentions := make([]model.ChatMentions, 0)
+	for i := range chats {
+		if chats[i].IsHidden || chats[i].UnreadMentions == 0 && chats[i].UnreadReactions == 0 {
+			continue
+		}
+		mentions = append(mentions, model.ChatMentions{Chat: chats[i]})
+	}
+
+	// Упоминания загружаются параллельно, у каждого чата свой тайм-аут: медленный чат не съедает время остальных
+	slots := make(chan struct{}, mentionsParallelism)
+	var wg sync.WaitGroup
+	for i := range mentions {
+		if mentions[i].Chat.UnreadMentions == 0 {
+			continue
+		}
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			ctxClient, cancelClient := context.WithTimeout(ctx, g.requestTimeout)
+			defer cancelClient()
+
+			messages, errM := g.tgClient.GetMentions(ctxClient, &mentions[i].Chat)
+			if errM != nil {
+				log.Error("get chat mentions error", slog.Int64("chat_id", mentions[i].Chat.ID), slog.Any("error", errM))
+				mentions[i].Err = errM
+				return
+			}
+			mentions[i].Messages = messages
+		})
+	}
+	wg.Wait()
+
+	log.Debug("mentions collected", slog.Int("chats", len(mentions)))
+
+	return mentions, nil
+}
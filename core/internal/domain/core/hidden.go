@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// HiddenStore контракт хранилища списка скрытых чатов.
+type HiddenStore interface {
+	LoadHiddenChats(ctx context.Context) ([]model.HiddenChat, error)
+	SaveHiddenChats(ctx context.Context, chats []model.HiddenChat) error
+}
+
+// InitHidden загружает список скрытых чатов из хранилища.
+func (g *Gist) InitHidden(ctx context.Context) error {
+	chats, errL := g.store.LoadHiddenChats(ctx)
+	if errL != nil {
+		return fmt.Errorf("core.InitHidden: %w", errL)
+	}
+
+	g.hiddenMu.Lock()
+	defer g.hiddenMu.Unlock()
+
+	g.hidden = chats
+	return nil
+}
+
+// HideChat добавляет чат в список скрытых. Повторное скрытие не ошибка.
+func (g *Gist) HideChat(ctx context.Context, chatID int64) error {
+	chat, errC := g.GetChatDetail(ctx, chatID)
+	if errC != nil {
+		return fmt.Errorf("core.HideChat: %w", errC)
+	}
+
+	g.hiddenMu.Lock()
+	defer g.hiddenMu.Unlock()
+
+	if slices.ContainsFunc(g.hidden, func(h model.HiddenChat) bool { return h.ChatID == chatID }) {
+		return nil
+	}
+
+	hidden := append(slices.Clone(g.hidden), model.HiddenChat{ChatID: chatID, Title: chat.Title, Hidden: time.Now()})
+	if errS := g.store.SaveHiddenChats(ctx, hidden); errS != nil {
+		return fmt.Errorf("core.HideChat: %w", errS)
+	}
+
+	g.hidden = hidden
+	return nil
+}
+
+// UnhideChat убирает чат из списка скрытых.
+func (g *Gist) UnhideChat(ctx context.Context, chatID int64) error {
+	g.hiddenMu.Lock()
+	defer g.hiddenMu.Unlock()
+
+	i := slices.IndexFunc(g.hidden, func(h model.HiddenChat) bool { return h.ChatID == chatID })
+	if i == -1 {
+		return fmt.Errorf("core.UnhideChat: %w", model.ErrHiddenChatNotFound)
+	}
+
+	hidden := slices.Delete(slices.Clone(g.hidden), i, i+1)
+	if errS := g.store.SaveHiddenChats(ctx, hidden); errS != nil {
+		return fmt.Errorf("core.UnhideChat: %w", errS)
+	}
+
+	g.hidden = hidden
+	return nil
+}
+
+// GetHiddenChats возвращает список скрытых чатов, последние скрытые первыми.
+// Название берется из кэша, если чат там есть, иначе сохраненное при скрытии.
+func (g *Gist) GetHiddenChats(_ context.Context) []model.HiddenChat {
+	g.hiddenMu.RLock()
+	chats := slices.Clone(g.hidden)
+	g.hiddenMu.RUnlock()
+
+	g.mu.RLock()
+	for i := range chats {
+		if chat, ok := g.cache[chats[i].ChatID]; ok {
+			chats[i].Title = chat.Title
+		}
+	}
+	g.mu.RUnlock()
+
+	slices.Reverse(chats)
+	return chats
+}
+
+// isHidden возвращает true, если чат в списке скрытых.
+func (g *Gist) isHidden(chatID int64) bool {
+	g.hiddenMu.RLock()
+	defer g.hiddenMu.RUnlock()
+
+	return slices.ContainsFunc(g.hidden, func(h model.HiddenChat) bool { return h.ChatID == chatID })
+}
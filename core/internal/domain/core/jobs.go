@@ -0,0 +1,463 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// JobNotifier контракт оповещения пользователя о завершении фоновой задачи.
+type JobNotifier interface {
+	JobFinished(ctx context.Context, job model.Job)
+}
+
+// jobQueue очередь фоновых задач с ограничением количества одновременно выполняемых задач.
+type jobQueue struct {
+	mu      sync.Mutex
+	jobs    []*model.Job // Задачи в порядке создания
+	nextID  int64
+	history int // Количество хранимых завершенных задач
+
+	slots chan struct{} // Семафор, ограничивающий параллельность
+
+	ctx    context.Context // Контекст всех задач, отменяется при остановке приложения
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	notifier JobNotifier
+	timeout  time.Duration // Тайм-аут оповещения о завершении задачи
+
+	watchers map[int64][]chan model.Job // Подписки на изменения задач по ID задачи
+}
+
+func newJobQueue(parallelism, history int, timeout time.Duration) *jobQueue {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &jobQueue{
+		jobs:     make([]*model.Job, 0),
+		history:  history,
+		slots:    make(chan struct{}, parallelism),
+		ctx:      ctx,
+		cancel:   cancel,
+		timeout:  timeout,
+		watchers: make(map[int64][]chan model.Job),
+	}
+}
+
+// submit ставит задачу в очередь. run выполняется в отдельной горутине после освобождения слота.
+// Одновременно для чата может выполняться только одна задача каждого типа.
+func (q *jobQueue) submit(job *model.Job, run func(ctx context.Context, job *model.Job) error) (model.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if j := q.duplicate(job); j != nil {
+		return *j, model.ErrJobAlreadyRunning
+	}
+
+	q.nextID++
+	job.ID = q.nextID
+	job.Status = model.JobPending
+	job.Progress = "⏳ В очереди"
+	job.Created = time.Now()
+	q.jobs = append(q.jobs, job)
+
+	q.wg.Go(func() {
+		q.execute(job, run)
+	})
+
+	return *job, nil
+}
+
+// runNested выполняет задачу в горутине родительской задачи, без ожидания слота: слот уже занят родительской задачей.
+// Задача видна в списке задач и участвует в проверке дублей, оповещение о завершении получает только родительская задача.
+func (q *jobQueue) runNested(ctx context.Context, job *model.Job, run func(ctx context.Context, job *model.Job) error) (model.Job, error) {
+	q.mu.Lock()
+	if j := q.duplicate(job); j != nil {
+		existing := *j
+		q.mu.Unlock()
+		return existing, model.ErrJobAlreadyRunning
+	}
+
+	q.nextID++
+	job.ID = q.nextID
+	job.Status = model.JobRunning
+	job.Progress = "⏳ Выполняется"
+	job.Created = time.Now()
+	job.Started = job.Created
+	q.jobs = append(q.jobs, job)
+	q.mu.Unlock()
+
+	errR := safeRun(ctx, job, run)
+
+	snapshot := q.finish(job, errR)
+	return snapshot, errR
+}
+
+// duplicate возвращает активную задачу того же типа для того же чата. Вызывается под блокировкой.
+func (q *jobQueue) duplicate(job *model.Job) *model.Job {
+	for _, j := range q.jobs {
+		if j.Active() && j.Kind == job.Kind && j.ChatID == job.ChatID {
+			return j
+		}
+	}
+	return nil
+}
+
+// safeRun выполняет задачу, паника в задаче возвращается ошибкой.
+func safeRun(ctx context.Context, job *model.Job, run func(ctx context.Context, job *model.Job) error) (errR error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errR = fmt.Errorf("job panic: %v", r)
+		}
+	}()
+	return run(ctx, job)
+}
+
+// execute ожидает свободный слот, выполняет задачу, освобождает слот и оповещает о завершении.
+func (q *jobQueue) execute(job *model.Job, run func(ctx context.Context, job *model.Job) error) {
+	log := slog.With("func", "core.jobQueue.execute", slog.Int64("job_id", job.ID), slog.Int64("chat_id", job.ChatID))
+
+	select {
+	case q.slots <- struct{}{}:
+	case <-q.ctx.Done():
+		q.finish(job, q.ctx.Err())
+		return
+	}
+
+	q.update(job, func(j *model.Job) {
+		j.Status = model.JobRunning
+		j.Started = time.Now()
+	})
+
+	log.Debug("job started")
+
+	errR := safeRun(q.ctx, job, run)
+
+	<-q.slots // Слот освобождается до оповещения: медленная отправка сообщения пользователю не задерживает очередь
+
+	if errR != nil {
+		log.Error("job failed", slog.Any("error", errR))
+	} else {
+		log.Debug("job done", slog.Duration("duration", time.Since(job.Started)))
+	}
+
+	q.finish(job, errR)
+}
+
+// finish фиксирует результат задачи, очищает историю и оповещает пользователя вне блокировки очереди. Возвращает итоговое состояние задачи.
+// О вложенных задачах пользователь не оповещается, их результат входит в результат родительской задачи.
+func (q *jobQueue) finish(job *model.Job, err error) model.Job {
+	q.mu.Lock()
+	job.Finished = time.Now()
+	job.Err = err
+	job.Status = model.JobDone
+	if err != nil {
+		job.Status = model.JobFailed
+	}
+	snapshot := *job
+	notifier := q.notifier
+	for _, ch := range q.watchers[job.ID] { // Последнее состояние остается в канале, подписчик получит его до закрытия
+		publish(ch, snapshot)
+		close(ch)
+	}
+	delete(q.watchers, job.ID)
+	q.trim()
+	q.mu.Unlock()
+
+	if notifier == nil || job.Parent != 0 {
+		return snapshot
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+	defer cancel()
+	notifier.JobFinished(ctx, snapshot)
+
+	return snapshot
+}
+
+// trim удаляет самые старые завершенные задачи сверх лимита истории. Вызывается под блокировкой.
+func (q *jobQueue) trim() {
+	finished := 0
+	for _, j := range q.jobs {
+		if !j.Active() {
+			finished++
+		}
+	}
+
+	q.jobs = slices.DeleteFunc(q.jobs, func(j *model.Job) bool {
+		if finished > q.history && !j.Active() {
+			finished--
+			return true
+		}
+		return false
+	})
+}
+
+// update изменяет задачу под блокировкой и возвращает ее копию.
+func (q *jobQueue) update(job *model.Job, fn func(j *model.Job)) model.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fn(job)
+
+	snapshot := *job
+	for _, ch := range q.watchers[job.ID] {
+		publish(ch, snapshot)
+	}
+	return snapshot
+}
+
+// watch подписывает на изменения задачи id. Канал получает последнее состояние задачи и закрывается после ее завершения.
+// Подписка отменяется вместе с ctx. Для завершенной задачи канал содержит итоговое состояние и уже закрыт.
+func (q *jobQueue) watch(ctx context.Context, id int64) (<-chan model.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := slices.IndexFunc(q.jobs, func(j *model.Job) bool { return j.ID == id })
+	if i == -1 {
+		return nil, model.ErrJobNotFound
+	}
+
+	ch := make(chan model.Job, 1)
+	publish(ch, *q.jobs[i])
+	if !q.jobs[i].Active() {
+		close(ch)
+		return ch, nil
+	}
+	q.watchers[id] = append(q.watchers[id], ch)
+
+	go func() {
+		<-ctx.Done()
+		q.unwatch(id, ch)
+	}()
+
+	return ch, nil
+}
+
+// unwatch отменяет подписку на изменения задачи. Если задача уже завершилась, канал закрыт в finish.
+func (q *jobQueue) unwatch(id int64, ch chan model.Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	watchers := q.watchers[id]
+	i := slices.Index(watchers, ch)
+	if i == -1 {
+		return
+	}
+	close(ch)
+	if len(watchers) == 1 {
+		delete(q.watchers, id)
+		return
+	}
+	q.watchers[id] = slices.Delete(watchers, i, i+1)
+}
+
+// publish кладет состояние задачи в канал подписчика, вытесняя непрочитанное предыдущее: подписчику нужно только последнее.
+// Вызывается под блокировкой q.mu, других отправителей у канала нет.
+func publish(ch chan model.Job, job model.Job) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- job
+}
+
+// get возвращает копию задачи по ID. false - задачи нет в истории.
+func (q *jobQueue) get(id int64) (model.Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, j := range q.jobs {
+		if j.ID == id {
+			return *j, true
+		}
+	}
+	return model.Job{}, false
+}
+
+// active возвращает true, если для чата выполняется или ожидает в очереди задача типа kind.
+func (q *jobQueue) active(kind model.JobKind, chatID int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return slices.ContainsFunc(q.jobs, func(j *model.Job) bool {
+		return j.Active() && j.Kind == kind && j.ChatID == chatID
+	})
+}
+
+// WatchJob подписывает на ход выполнения фоновой задачи. Канал получает последнее состояние задачи
+// и закрывается после ее завершения, последнее полученное состояние - итоговое. Подписка отменяется вместе с ctx,
+// сама задача при этом продолжает выполняться.
+func (g *Gist) WatchJob(ctx context.Context, jobID int64) (<-chan model.Job, error) {
+	ch, errW := g.jobs.watch(ctx, jobID)
+	if errW != nil {
+		return nil, fmt.Errorf("core.WatchJob: %w", errW)
+	}
+	return ch, nil
+}
+
+// list возвращает копии задач, новые первыми.
+func (q *jobQueue) list() []model.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]model.Job, 0, len(q.jobs))
+	for i := len(q.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, *q.jobs[i])
+	}
+	return jobs
+}
+
+// close отменяет выполняемые задачи и ожидает их завершения.
+func (q *jobQueue) close(ctx context.Context) {
+	q.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.With("func", "core.jobQueue.close").Error("Context canceled before jobs stopped", slog.Any("error", ctx.Err()))
+	}
+}
+
+// SetJobNotifier внедрение зависимости, оповещение пользователя о завершении задач.
+func (g *Gist) SetJobNotifier(notifier JobNotifier) {
+	g.jobs.mu.Lock()
+	defer g.jobs.mu.Unlock()
+	g.jobs.notifier = notifier
+}
+
+// GetJobs возвращает список фоновых задач, новые первыми.
+func (g *Gist) GetJobs(_ context.Context) []model.Job {
+	return g.jobs.list()
+}
+
+// StartGistJob ставит в очередь задачу генерации краткого пересказа чата.
+// callback - оповещение о ходе выполнения, получает актуальное состояние задачи.
+func (g *Gist) StartGistJob(ctx context.Context, chatID int64, opts model.GistOptions, callback func(job model.Job)) (model.Job, error) {
+	if errV := opts.Range.Validate(); errV != nil {
+		return model.Job{}, fmt.Errorf("core.StartGistJob: %w", errV)
+	}
+
+	chat, errD := g.GetChatDetail(ctx, chatID)
+	if errD != nil {
+		return model.Job{}, fmt.Errorf("core.StartGistJob: %w", errD)
+	}
+
+	job := &model.Job{
+		Kind:      model.JobGist,
+		ChatID:    chatID,
+		ChatTitle: chat.Title,
+		Range:     opts.Range,
+	}
+
+	return g.jobs.submit(job, func(ctx context.Context, job *model.Job) error {
+		gist, errG := g.GetChatGist(ctx, chatID, opts, g.jobProgress(job, callback))
+		if errG != nil {
+			return errG
+		}
+
+		g.jobs.update(job, func(j *model.Job) {
+			j.Gist = gist
+		})
+		return nil
+	})
+}
+
+// StartAudioJob ставит в очередь задачу генерации аудиопересказа. page - номер батча, 0 - весь чат.
+func (g *Gist) StartAudioJob(ctx context.Context, chatID int64, page int, callback func(job model.Job)) (model.Job, error) {
+	chat, errD := g.GetChatDetail(ctx, chatID)
+	if errD != nil {
+		return model.Job{}, fmt.Errorf("core.StartAudioJob: %w", errD)
+	}
+
+	job := &model.Job{
+		Kind:      model.JobAudio,
+		ChatID:    chatID,
+		ChatTitle: chat.Title,
+		Page:      page,
+	}
+
+	return g.jobs.submit(job, func(ctx context.Context, job *model.Job) error {
+		g.jobProgress(job, callback)("🔊 Генерируем аудиопересказ...", 0, false)
+
+		audio, errA := g.GetAudioGist(ctx, chatID, page)
+		if errA != nil {
+			return errA
+		}
+
+		g.jobs.update(job, func(j *model.Job) {
+			j.Audio = audio
+		})
+		return nil
+	})
+}
+
+// StartQuestionJob ставит в очередь задачу ответа на вопрос пользователя по сообщениям чата.
+func (g *Gist) StartQuestionJob(ctx context.Context, chatID int64, question string, callback func(job model.Job)) (model.Job, error) {
+	chat, errD := g.GetChatDetail(ctx, chatID)
+	if errD != nil {
+		return model.Job{}, fmt.Errorf("core.StartQuestionJob: %w", errD)
+	}
+
+	job := &model.Job{
+		Kind:      model.JobQuestion,
+		ChatID:    chatID,
+		ChatTitle: chat.Title,
+		Question:  question,
+	}
+
+	return g.jobs.submit(job, func(ctx context.Context, job *model.Job) error {
+		g.jobProgress(job, callback)("🔎 Ищем ответ в сообщениях чата...", 0, false)
+
+		answer, errA := g.AskQuestion(ctx, chatID, question)
+		if errA != nil {
+			return errA
+		}
+
+		g.jobs.update(job, func(j *model.Job) {
+			j.Answer = answer
+		})
+		return nil
+	})
+}
+
+// jobProgress возвращает callback, сохраняющий ход выполнения в задаче и передающий его пользователю.
+func (g *Gist) jobProgress(job *model.Job, callback func(job model.Job)) func(message string, part int, llm bool) {
+	return func(message string, part int, llm bool) {
+		snapshot := g.jobs.update(job, func(j *model.Job) {
+			j.Progress = message
+			j.Part = part
+			j.LLM = llm
+		})
+
+		if callback != nil {
+			callback(snapshot)
+		}
+	}
+}
+
+// Close отменяет выполняемые фоновые задачи, ожидает их завершения и сохраняет накопленный расход LLM.
+func (g *Gist) Close(ctx context.Context) {
+	log := slog.With("func", "core.Close")
+	log.Debug("Stopping jobs...")
+
+	g.jobs.close(ctx)
+
+	log.Debug("Jobs stopped")
+
+	g.flushUsage(ctx)
+}
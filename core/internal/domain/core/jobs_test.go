@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// blockingRun возвращает функцию задачи, выполняющуюся до закрытия release.
+func blockingRun(release <-chan struct{}) func(ctx context.Context, job *model.Job) error {
+	return func(ctx context.Context, _ *model.Job) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitFinished ожидает завершения задачи id.
+func waitFinished(t *testing.T, q *jobQueue, id int64) model.Job {
+	t.Helper()
+
+	ch, err := q.watch(context.Background(), id)
+	if err != nil {
+		t.Fatalf("watch job %d: %v", id, err)
+	}
+
+	var last model.Job
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case job, ok := <-ch:
+			if !ok {
+				return last
+			}
+			last = job
+		case <-timeout:
+			t.Fatalf("job %d not finished", id)
+		}
+	}
+}
+
+func TestJobQueueDuplicate(t *testing.T) {
+	tests := []struct {
+		name    string
+		second  model.Job
+		wantDup bool
+	}{
+		{name: "same kind and chat", second: model.Job{Kind: model.JobGist, ChatID: 1}, wantDup: true},
+		{name: "other chat", second: model.Job{Kind: model.JobGist, ChatID: 2}},
+		{name: "other kind", second: model.Job{Kind: model.JobAudio, ChatID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newJobQueue(1, 10, time.Second)
+			release := make(chan struct{})
+			t.Cleanup(func() {
+				close(release)
+				q.close(context.Background())
+			})
+
+			first, err := q.submit(&model.Job{Kind: model.JobGist, ChatID: 1}, blockingRun(release))
+			if err != nil {
+				t.Fatalf("first submit: %v", err)
+			}
+
+			second := tt.second
+			got, err := q.submit(&second, blockingRun(release))
+
+			if !tt.wantDup {
+				if err != nil {
+					t.Fatalf("second submit: unexpected error: %v", err)
+				}
+				if got.ID == first.ID {
+					t.Errorf("second job got the ID of the first one: %d", got.ID)
+				}
+				if n := len(q.list()); n != 2 {
+					t.Errorf("queue holds %d jobs, want 2", n)
+				}
+				return
+			}
+
+			if !errors.Is(err, model.ErrJobAlreadyRunning) {
+				t.Fatalf("second submit: error = %v, want %v", err, model.ErrJobAlreadyRunning)
+			}
+			if got.ID != first.ID {
+				t.Errorf("duplicate returned job %d, want existing job %d", got.ID, first.ID)
+			}
+			if n := len(q.list()); n != 1 {
+				t.Errorf("queue holds %d jobs, want 1", n)
+			}
+		})
+	}
+}
+
+func TestJobQueueResubmitAfterFinish(t *testing.T) {
+	q := newJobQueue(1, 10, time.Second)
+	t.Cleanup(func() { q.close(context.Background()) })
+
+	run := func(context.Context, *model.Job) error { return nil }
+
+	first, err := q.submit(&model.Job{Kind: model.JobGist, ChatID: 1}, run)
+	if err != nil {
+		t.Fatalf("first submit: %v", err)
+	}
+	if job := waitFinished(t, q, first.ID); job.Status != model.JobDone {
+		t.Fatalf("first job status = %v, want %v", job.Status, model.JobDone)
+	}
+
+	second, err := q.submit(&model.Job{Kind: model.JobGist, ChatID: 1}, run)
+	if err != nil {
+		t.Fatalf("submit after finish: unexpected error: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Errorf("resubmitted job got the ID of the finished one: %d", second.ID)
+	}
+	waitFinished(t, q, second.ID)
+}
+
+func TestJobQueueFailedAndPanic(t *testing.T) {
+	errRun := errors.New("run failed")
+
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, job *model.Job) error
+	}{
+		{name: "error", run: func(context.Context, *model.Job) error { return errRun }},
+		{name: "panic", run: func(context.Context, *model.Job) error { panic("boom") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newJobQueue(1, 10, time.Second)
+			t.Cleanup(func() { q.close(context.Background()) })
+
+			job, err := q.submit(&model.Job{Kind: model.JobAudio, ChatID: 1}, tt.run)
+			if err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+
+			got := waitFinished(t, q, job.ID)
+			if got.Status != model.JobFailed || got.Err == nil {
+				t.Fatalf("job status = %v, err = %v, want failed job", got.Status, got.Err)
+			}
+
+			// Упавшая задача не блокирует повторный запуск
+			if _, err := q.submit(&model.Job{Kind: model.JobAudio, ChatID: 1}, func(context.Context, *model.Job) error { return nil }); err != nil {
+				t.Errorf("submit after failure: unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestJobQueueRunNested(t *testing.T) {
+	q := newJobQueue(1, 10, time.Second)
+	release := make(chan struct{})
+	t.Cleanup(func() { q.close(context.Background()) })
+
+	parent, err := q.submit(&model.Job{Kind: model.JobGist, ChatID: 1}, blockingRun(release))
+	if err != nil {
+		t.Fatalf("submit parent: %v", err)
+	}
+
+	// Задача того же типа для того же чата - дубль, даже если запускается вложенной
+	dup, err := q.runNested(context.Background(), &model.Job{Kind: model.JobGist, ChatID: 1, Parent: parent.ID}, blockingRun(release))
+	if !errors.Is(err, model.ErrJobAlreadyRunning) {
+		t.Fatalf("nested duplicate: error = %v, want %v", err, model.ErrJobAlreadyRunning)
+	}
+	if dup.ID != parent.ID {
+		t.Errorf("nested duplicate returned job %d, want %d", dup.ID, parent.ID)
+	}
+
+	// Вложенная задача выполняется сразу, хотя единственный слот занят родительской
+	done := make(chan model.Job, 1)
+	go func() {
+		job, _ := q.runNested(context.Background(), &model.Job{Kind: model.JobGist, ChatID: 2, Parent: parent.ID}, func(context.Context, *model.Job) error { return nil })
+		done <- job
+	}()
+
+	select {
+	case nested := <-done:
+		if nested.Status != model.JobDone || nested.Parent != parent.ID {
+			t.Errorf("nested job = %+v, want done job of parent %d", nested, parent.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested job waits for a slot")
+	}
+
+	close(release)
+	waitFinished(t, q, parent.ID)
+}
+
+// blockingNotifier оповещение о завершении задачи, выполняющееся до закрытия release.
+type blockingNotifier struct {
+	release  chan struct{}
+	notified chan int64
+}
+
+func (n *blockingNotifier) JobFinished(ctx context.Context, job model.Job) {
+	n.notified <- job.ID
+	select {
+	case <-n.release:
+	case <-ctx.Done():
+	}
+}
+
+func TestJobQueueSlowNotifier(t *testing.T) {
+	q := newJobQueue(1, 10, time.Minute)
+	notifier := &blockingNotifier{release: make(chan struct{}), notified: make(chan int64, 2)}
+	q.notifier = notifier
+	t.Cleanup(func() { q.close(context.Background()) })
+	t.Cleanup(func() { close(notifier.release) }) // Выполняется до остановки очереди
+
+	run := func(context.Context, *model.Job) error { return nil }
+
+	first, err := q.submit(&model.Job{Kind: model.JobGist, ChatID: 1}, run)
+	if err != nil {
+		t.Fatalf("submit first: %v", err)
+	}
+	if id := <-notifier.notified; id != first.ID {
+		t.Fatalf("notified job %d, want %d", id, first.ID)
+	}
+
+	// Оповещение о первой задаче еще отправляется, единственный слот уже свободен
+	second, err := q.submit(&model.Job{Kind: model.JobGist, ChatID: 2}, run)
+	if err != nil {
+		t.Fatalf("submit second: %v", err)
+	}
+	if job := waitFinished(t, q, second.ID); job.Status != model.JobDone {
+		t.Errorf("second job status = %v, want %v", job.Status, model.JobDone)
+	}
+}
@@ -6,43 +6,88 @@ import (
 	"log/slog"
 	"os"
 	"slices"
+	"time"
 
 	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // MarkAsRead отметить сообщения чата как прочитанные. Нумерация страниц начинается с 1.
 func (g *Gist) MarkAsRead(ctx context.Context, chatID int64, pageID int) (*model.Chat, error) {
+	ctx, span := tracing.Start(ctx, "core.MarkAsRead", attribute.Int64("chat_id", chatID))
+	defer span.End()
 
-	chat, errD := g.GetChatDetail(ctx, chatID)
+	log := slog.With("func", "core.MarkAsRead")
+
+	chat, errD := g.chat(chatID)
 	if errD != nil {
 		return nil, fmt.Errorf("core.MarkAsRead: %w", errD)
 	}
 
+	g.mu.RLock()
+	target := *chat
+	g.mu.RUnlock()
+
 	lastMessageID := 0 // Если страница не задана == 0, отмечаем прочитанными ВСЕ сообщения чата.
 	if pageID > 0 {    // Иначе отмечаем прочитанными только сообщения до текущего батча с кратким пересказом.
-		lastMessageID = chat.Gist[pageID-1].LastMessageID
+		if pageID > len(target.Gist) {
+			return nil, fmt.Errorf("core.MarkAsRead: page %d exceeds available batches count (%d)", pageID, len(target.Gist))
+		}
+		lastMessageID = target.Gist[pageID-1].LastMessageID
 	}
 
-	errM := g.tgClient.MarkAsRead(ctx, chat, lastMessageID)
+	errM := g.tgClient.MarkAsRead(ctx, &target, lastMessageID)
 	if errM != nil {
 		return nil, fmt.Errorf("core.MarkAsRead: %w", errM)
 	}
 
-	chat.LastReadMessageID = lastMessageID // обновляем ID последнего прочитанного сообщения
+	errS := g.store.DeleteCheckpoints(ctx, chatID, lastMessageID) // Сохраненные батчи прочитанных сообщений больше не нужны
+	if errS != nil {
+		log.Error("delete checkpoints error", slog.Any("error", errS))
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Пока отмечали сообщения в Telegram, задача пересказа или сброс периода могли заменить батчи в кэше
+	if pageID > 0 && (pageID > len(chat.Gist) || chat.Gist[pageID-1].LastMessageID != lastMessageID) {
+		g.lastUpdate = time.Time{} // Счетчики непрочитанных обновятся при следующем запросе списка чатов
+		return nil, fmt.Errorf("core.MarkAsRead: page %d: %w", pageID, model.ErrGistChanged)
+	}
+
+	if !target.Range.IsUnread() { // Пересказ за произвольный период, счетчики непрочитанных обновятся при следующем запросе списка чатов
+		g.lastUpdate = time.Time{}
+		if lastMessageID == 0 {
+			g.resetGist(chat)
+			chat.Range = model.MessageRange{}
+			chat.UnreadCount = 0
+		} else if lastMessageID > chat.LastReadMessageID {
+			chat.LastReadMessageID = lastMessageID
+		}
+
+		detail := *chat
+		return &detail, nil
+	}
 
 	// Удаляем прочитанные сообщения из кэша
-	if len(chat.Messages) > 0 {
-		i := 0
-		for {
-			if chat.Messages[i].ID == lastMessageID {
-				i++ // смещаемся на начало непрочитанного блока сообщений
-				break
-			}
-			i++
+	if lastMessageID == 0 {
+		if len(chat.Messages) > 0 {
+			chat.LastReadMessageID = chat.Messages[len(chat.Messages)-1].ID
+		}
+		chat.Messages = nil
+	} else {
+		chat.LastReadMessageID = lastMessageID // обновляем ID последнего прочитанного сообщения
+		i := slices.IndexFunc(chat.Messages, func(m model.Message) bool {
+			return m.ID > lastMessageID // начало непрочитанного блока сообщений
+		})
+		if i == -1 {
+			chat.Messages = nil
+		} else {
+			messages := make([]model.Message, len(chat.Messages)-i)
+			copy(messages, chat.Messages[i:])
+			chat.Messages = messages
 		}
-		messages := make([]model.Message, len(chat.Messages)-i)
-		copy(messages, chat.Messages[i:])
-		chat.Messages = messages
 	}
 
 	if pageID > 0 {
@@ -52,14 +97,21 @@ func (g *Gist) MarkAsRead(ctx context.Context, chatID int64, pageID int) (*model
 				deleteFile(audio.AudioFile) // удаляем файлы с аудиопересказом, если есть
 			}
 		}
-		chat.Gist = slices.Delete(chat.Gist, 0, pageID) // удаляем батчи с пересказом
+		chat.Gist = slices.Delete(slices.Clone(chat.Gist), 0, pageID) // удаляем батчи с пересказом
+		chat.AboutMe = slices.DeleteFunc(slices.Clone(chat.AboutMe), func(m model.Message) bool {
+			return m.ID <= lastMessageID
+		})
+		if len(chat.Gist) == 0 {
+			chat.GistPartial = false
+		}
 		for _, audio := range chat.Audio {
 			deleteFile(audio.AudioFile) // удаляем файл с полным аудиопересказом, если есть
 		}
 
 		chat.Audio = nil // Обнуляем полный аудиопересказ, т.к. часть пометили прочитанным
 
-		return chat, nil
+		detail := *chat
+		return &detail, nil
 	}
 
 	for i := 0; i < len(chat.Gist); i++ {
@@ -70,6 +122,8 @@ func (g *Gist) MarkAsRead(ctx context.Context, chatID int64, pageID int) (*model
 
 	chat.UnreadCount = 0 // количество непрочитанных сообщений в чате = 0
 	chat.Gist = nil      // удалили все краткие пересказы
+	chat.GistPartial = false
+	chat.AboutMe = nil
 
 	for _, audio := range chat.Audio {
 		deleteFile(audio.AudioFile) // удаляем файл с полным аудиопересказом, если есть
@@ -77,7 +131,8 @@ func (g *Gist) MarkAsRead(ctx context.Context, chatID int64, pageID int) (*model
 
 	chat.Audio = nil
 
-	return chat, nil
+	detail := *chat
+	return &detail, nil
 }
 
 func deleteFile(name string) {
@@ -89,7 +144,7 @@ func deleteFile(name string) {
 
 	errR := os.Remove(name)
 	if errR != nil {
-		log.Error("error removing file", errR, slog.String("name", name))
+		log.Error("error removing file", slog.Any("error", errR), slog.String("name", name))
 	}
 
 }
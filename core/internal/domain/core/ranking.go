@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const topPeersTTL = time.Hour // Рейтинг общения меняется медленно, запрашиваем не чаще раза в час
+
+// ranking данные для оценки важности чатов.
+type ranking struct {
+	mu           sync.Mutex
+	peers        map[int64]float64 // Рейтинг общения пользователя с чатами, нормирован к 0..1
+	peersUpdated time.Time
+	urgency      map[int64]urgencyEstimate // Оценки срочности LLM по ID чата
+	estimating   bool                      // Выполняется фоновая оценка срочности
+}
+
+// urgencyEstimate оценка срочности чата. Действительна, пока не изменились непрочитанные сообщения.
+type urgencyEstimate struct {
+	lastReadMessageID int
+	unreadCount       int
+	urgency           int
+}
+
+// rankChats вычисляет оценку важности чатов и сортирует их по убыванию оценки.
+//
+// Оценка складывается из веса типа чата, упоминаний пользователя, избранного, частоты общения пользователя в чате,
+// количества непрочитанных сообщений и срочности по оценке LLM (если включена). Веса задаются в конфигурации settings.ranking.
+func (g *Gist) rankChats(ctx context.Context, chats []model.Chat) {
+	weights := g.cfg.Live().Ranking
+	peers := g.topPeers(ctx)
+
+	g.ranking.mu.Lock()
+	for i := range chats {
+		chat := &chats[i]
+
+		if estimate, ok := g.ranking.urgency[chat.ID]; ok &&
+			estimate.lastReadMessageID == chat.LastReadMessageID && estimate.unreadCount == chat.UnreadCount {
+			chat.Urgency = estimate.urgency
+		}
+
+		score := 0.0
+		switch chat.Type {
+		case model.ChatPrivate:
+			score += weights.Private
+		case model.ChatGroup:
+			score += weights.Group
+		case model.ChatChannel:
+			score += weights.Channel
+		}
+		if chat.IsFavorite {
+			score += weights.Favorite
+		}
+		score += weights.Mentions * math.Log2(1+float64(chat.UnreadMentions))
+		score += weights.Interaction * peers[chat.ID]
+		score += weights.Unread * math.Log10(1+float64(chat.UnreadCount))
+		score += weights.Urgency * float64(chat.Urgency) / 10
+
+		chat.Importance = math.Round(score*10) / 10
+	}
+	g.ranking.mu.Unlock()
+
+	sort.SliceStable(chats, func(i, j int) bool {
+		if chats[i].Importance != chats[j].Importance {
+			return chats[i].Importance > chats[j].Importance
+		}
+		return chats[i].UnreadCount > chats[j].UnreadCount
+	})
+
+	if weights.LLMUrgency {
+		g.estimateUrgency(chats)
+	}
+}
+
+// topPeers возвращает рейтинг общения пользователя с чатами. При ошибке запроса используется предыдущее значение.
+func (g *Gist) topPeers(ctx context.Context) map[int64]float64 {
+	log := slog.With("func", "core.topPeers")
+
+	g.ranking.mu.Lock()
+	if g.ranking.peers != nil && time.Since(g.ranking.peersUpdated) < topPeersTTL {
+		peers := g.ranking.peers
+		g.ranking.mu.Unlock()
+		return peers
+	}
+	g.ranking.mu.Unlock()
+
+	ratings, err := g.tgClient.GetTopPeers(ctx)
+	if err != nil {
+		log.Error("get top peers", slog.Any("error", err))
+		g.ranking.mu.Lock()
+		defer g.ranking.mu.Unlock()
+		return g.ranking.peers // nil map читается как пустая
+	}
+
+	maxRating := 0.0
+	for _, rating := range ratings {
+		maxRating = max(maxRating, rating)
+	}
+
+	peers := make(map[int64]float64, len(ratings))
+	for id, rating := range ratings {
+		if maxRating > 0 {
+			peers[id] = rating / maxRating
+		}
+	}
+
+	g.ranking.mu.Lock()
+	g.ranking.peers = peers
+	g.ranking.peersUpdated = time.Now()
+	g.ranking.mu.Unlock()
+
+	return peers
+}
+
+// estimateUrgency запускает в фоне оценку срочности UrgencyChats самых важных чатов, у которых нет актуальной оценки.
+// Результат учитывается при следующем запросе списка чатов.
+func (g *Gist) estimateUrgency(chats []model.Chat) {
+	settings := g.cfg.Live().Ranking
+
+	pending := make([]model.Chat, 0, settings.UrgencyChats)
+	for i := range chats {
+		if len(pending) >= settings.UrgencyChats {
+			break
+		}
+		if chats[i].Urgency == 0 {
+			pending = append(pending, chats[i])
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	g.ranking.mu.Lock()
+	if g.ranking.estimating {
+		g.ranking.mu.Unlock()
+		return
+	}
+	g.ranking.estimating = true
+	g.ranking.mu.Unlock()
+
+	g.jobs.wg.Go(func() {
+		defer func() {
+			g.ranking.mu.Lock()
+			g.ranking.estimating = false
+			g.ranking.mu.Unlock()
+		}()
+
+		for i := range pending {
+			if g.jobs.ctx.Err() != nil {
+				return
+			}
+			g.estimateChatUrgency(g.jobs.ctx, &pending[i], settings.UrgencyMessages)
+		}
+	})
+}
+
+// estimateChatUrgency оценивает срочность последних непрочитанных сообщений чата и сохраняет оценку.
+func (g *Gist) estimateChatUrgency(ctx context.Context, chat *model.Chat, limit int) {
+	log := slog.With("func", "core.estimateChatUrgency", slog.Int64("chat_id", chat.ID))
+
+	ctxFetch, cancel := context.WithTimeout(ctx, g.requestTimeout)
+	defer cancel()
+
+	rng := model.MessageRange{Kind: model.RangeLast, Last: max(1, min(limit, chat.UnreadCount))}
+	messages, _, errF := g.tgClient.FetchMessages(ctxFetch, chat, rng, func(string, int, bool) {})
+	if errF != nil {
+		log.Error("fetch messages", slog.Any("error", errF))
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	urgency, errE := g.llmClient.EstimateUrgency(g.llmContext(ctx, chat.ID, model.UsageUrgency), messages)
+	if errE != nil {
+		log.Error("estimate urgency", slog.Any("error", errE))
+		return
+	}
+
+	urgency = min(max(urgency, 1), 10) // 0 - признак отсутствия оценки, чат оценивался бы повторно при каждом запросе
+	log.Debug("urgency estimated", slog.Int("urgency", urgency))
+
+	g.ranking.mu.Lock()
+	g.ranking.urgency[chat.ID] = urgencyEstimate{
+		lastReadMessageID: chat.LastReadMessageID,
+		unreadCount:       chat.UnreadCount,
+		urgency:           urgency,
+	}
+	g.ranking.mu.Unlock()
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+)
+
+// testWeights веса оценки важности, у каждого слагаемого свой вес.
+var testWeights = config.Ranking{
+	Private:     3,
+	Group:       1,
+	Channel:     0.5,
+	Mentions:    1.5,
+	Favorite:    2,
+	Interaction: 4,
+	Unread:      1,
+	Urgency:     2,
+}
+
+func TestRankChatsWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		chat    model.Chat
+		urgency *urgencyEstimate // Сохраненная оценка срочности LLM
+		want    float64
+	}{
+		{name: "private", chat: model.Chat{Type: model.ChatPrivate}, want: 3},
+		{name: "group", chat: model.Chat{Type: model.ChatGroup}, want: 1},
+		{name: "channel", chat: model.Chat{Type: model.ChatChannel}, want: 0.5},
+		{name: "favorite", chat: model.Chat{Type: model.ChatChannel, IsFavorite: true}, want: 2.5},
+		{name: "mentions", chat: model.Chat{Type: model.ChatGroup, UnreadMentions: 3}, want: 1 + 1.5*2},
+		{name: "unread", chat: model.Chat{Type: model.ChatGroup, UnreadCount: 99}, want: 1 + 2},
+		{name: "interaction", chat: model.Chat{ID: 2, Type: model.ChatGroup}, want: 1 + 4*0.5},
+		{
+			name:    "urgency",
+			chat:    model.Chat{Type: model.ChatGroup, UnreadCount: 9, LastReadMessageID: 100},
+			urgency: &urgencyEstimate{lastReadMessageID: 100, unreadCount: 9, urgency: 5},
+			want:    1 + 1 + 2*0.5,
+		},
+		{
+			name:    "stale urgency",
+			chat:    model.Chat{Type: model.ChatGroup, UnreadCount: 9, LastReadMessageID: 100},
+			urgency: &urgencyEstimate{lastReadMessageID: 90, unreadCount: 9, urgency: 5},
+			want:    1 + 1,
+		},
+		{name: "rounded", chat: model.Chat{Type: model.ChatGroup, UnreadCount: 1}, want: 1.3}, // 1 + lg 2
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tg := &fakeTelegram{peers: map[int64]float64{1: 200, 2: 100}}
+			g := newTestGist(tg, &fakeLLM{}, newFakeStore())
+			g.cfg.Settings.Ranking = testWeights
+			if tt.urgency != nil {
+				g.ranking.urgency[tt.chat.ID] = *tt.urgency
+			}
+
+			chats := []model.Chat{tt.chat}
+			g.rankChats(context.Background(), chats)
+
+			if chats[0].Importance != tt.want {
+				t.Errorf("importance = %v, want %v", chats[0].Importance, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankChatsOrder(t *testing.T) {
+	g := newTestGist(&fakeTelegram{}, &fakeLLM{}, newFakeStore())
+	g.cfg.Settings.Ranking = testWeights
+
+	chats := []model.Chat{
+		{ID: 1, Type: model.ChatChannel},                // 0.5
+		{ID: 2, Type: model.ChatGroup, UnreadCount: 11}, // 1 + lg 12 = 2.1
+		{ID: 3, Type: model.ChatPrivate},                // 3
+		{ID: 4, Type: model.ChatGroup, UnreadCount: 5, IsFavorite: true},
+		{ID: 5, Type: model.ChatGroup, UnreadCount: 12}, // 1 + lg 13 = 2.1, при равной оценке выше чат с большим числом непрочитанных
+		{ID: 6, Type: model.ChatChannel, UnreadCount: 1},
+	}
+
+	g.rankChats(context.Background(), chats)
+
+	want := []int64{4, 3, 5, 2, 6, 1}
+	for i := range chats {
+		if chats[i].ID != want[i] {
+			t.Fatalf("order = %v, want %v", chatIDs(chats), want)
+		}
+	}
+}
+
+func TestEstimateChatUrgency(t *testing.T) {
+	tests := []struct {
+		name           string
+		urgency        int
+		fail           bool // LLM не вернула оценку, например ответила NaN
+		wantUrgency    int  // 0 - оценка не сохранена
+		wantImportance float64
+	}{
+		{name: "estimated", urgency: 7, wantUrgency: 7, wantImportance: 3},
+		{name: "zero", urgency: 0, wantUrgency: 1, wantImportance: 1.8},
+		{name: "negative", urgency: -3, wantUrgency: 1, wantImportance: 1.8},
+		{name: "above scale", urgency: 15, wantUrgency: 10, wantImportance: 3.6},
+		{name: "no estimate", fail: true, wantImportance: 1.6}, // Группа и lg 4
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			llm := &fakeLLM{urgency: map[int]int{1: tt.urgency}}
+			if tt.fail {
+				llm.failAfter = -1
+			}
+			g := newTestGist(&fakeTelegram{messages: testMessages(1, 3)}, llm, newFakeStore())
+			g.cfg.Settings.Ranking = testWeights
+
+			chat := model.Chat{ID: testChatID, Type: model.ChatGroup, UnreadCount: 3}
+			g.estimateChatUrgency(ctx, &chat, 10)
+
+			estimate, ok := g.ranking.urgency[testChatID]
+			if ok != (tt.wantUrgency != 0) || estimate.urgency != tt.wantUrgency {
+				t.Fatalf("stored urgency = %d (%v), want %d", estimate.urgency, ok, tt.wantUrgency)
+			}
+
+			// Оценка учитывается при ранжировании, без оценки - только остальные слагаемые
+			chats := []model.Chat{chat}
+			g.rankChats(ctx, chats)
+
+			if chats[0].Urgency != tt.wantUrgency {
+				t.Errorf("chat urgency = %d, want %d", chats[0].Urgency, tt.wantUrgency)
+			}
+			if chats[0].Importance != tt.wantImportance {
+				t.Errorf("importance = %v, want %v", chats[0].Importance, tt.wantImportance)
+			}
+		})
+	}
+}
+
+func chatIDs(chats []model.Chat) []int64 {
+	ids := make([]int64, 0, len(chats))
+	for i := range chats {
+		ids = append(ids, chats[i].ID)
+	}
+	return ids
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
+)
+
+// SettingsStore контракт хранилища настроек пользователя.
+type SettingsStore interface {
+	LoadSettings(ctx context.Context) (model.Settings, bool, error)
+	SaveSettings(ctx context.Context, settings model.Settings) error
+}
+
+// DefaultSettings настройки пользователя по умолчанию, из конфигурации.
+func DefaultSettings(cfg *config.Config) model.Settings {
+	live := cfg.Live()
+
+	return model.Settings{
+		UnreadThreshold: max(live.ChatUnreadThreshold, 1),
+		Provider:        cfg.LLM.DefaultProvider,
+		GistStyle:       model.GistStyleTopics,
+		GistLength:      model.GistLengthMedium,
+		TTSVoice:        live.TTSVoice,
+		TTSLanguage:     live.TTSLanguage,
+		BatchSize:       max(cfg.LLM.MessagesPerBatch, 1),
+		Timezone:        "UTC",
+		NotifyJobs:      true,
+		NotifyAlerts:    true,
+	}
+}
+
+// storedSettings приводит настройки к виду для хранения: порог непрочитанных, голос и язык аудиопересказа,
+// совпадающие со значениями по умолчанию, сохраняются пустыми. Пустые значения следуют за конфигурацией,
+// изменения которой применяются без перезапуска.
+func (g *Gist) storedSettings(settings model.Settings) model.Settings {
+	defaults := DefaultSettings(g.cfg)
+
+	if settings.UnreadThreshold == defaults.UnreadThreshold {
+		settings.UnreadThreshold = 0
+	}
+	if settings.TTSVoice == defaults.TTSVoice {
+		settings.TTSVoice = ""
+	}
+	if settings.TTSLanguage == defaults.TTSLanguage {
+		settings.TTSLanguage = ""
+	}
+	return settings
+}
+
+// liveSettings заполняет пустые значения сохраненных настроек актуальными значениями по умолчанию из конфигурации.
+func (g *Gist) liveSettings(settings model.Settings) model.Settings {
+	defaults := DefaultSettings(g.cfg)
+
+	if settings.UnreadThreshold == 0 {
+		settings.UnreadThreshold = defaults.UnreadThreshold
+	}
+	if settings.TTSVoice == "" {
+		settings.TTSVoice = defaults.TTSVoice
+	}
+	if settings.TTSLanguage == "" {
+		settings.TTSLanguage = defaults.TTSLanguage
+	}
+	return settings
+}
+
+// InitSettings загружает сохраненные настройки пользователя. Если настройки не сохранялись, используются значения по умолчанию.
+// Поврежденные или некорректные сохраненные настройки не мешают запуску: пишется предупреждение и используются значения по умолчанию,
+// файл настроек перезапишется при следующем изменении настроек.
+func (g *Gist) InitSettings(ctx context.Context) error {
+	log := slog.With("func", "core.InitSettings")
+
+	settings, found, errL := g.store.LoadSettings(ctx)
+	if errL != nil {
+		log.Warn("load settings error, use defaults", slog.Any("error", errL))
+		return nil
+	}
+	if !found {
+		return nil
+	}
+
+	live := g.liveSettings(settings)
+	if errV := live.Validate(); errV != nil {
+		log.Warn("invalid stored settings, use defaults", slog.Any("error", errV))
+		return nil
+	}
+
+	g.settingsMu.Lock()
+	g.settings = g.storedSettings(live)
+	g.settingsMu.Unlock()
+
+	return nil
+}
+
+// GetSettings возвращает настройки пользователя. Значения, которые пользователь не менял, берутся из актуальной конфигурации.
+func (g *Gist) GetSettings(_ context.Context) model.Settings {
+	g.settingsMu.RLock()
+	defer g.settingsMu.RUnlock()
+
+	return g.liveSettings(g.settings)
+}
+
+// UpdateSettings проверяет и сохраняет настройки пользователя.
+func (g *Gist) UpdateSettings(ctx context.Context, settings model.Settings) (model.Settings, error) {
+	if errV := settings.Validate(); errV != nil {
+		return g.GetSettings(ctx), fmt.Errorf("core.UpdateSettings: %w", errV)
+	}
+
+	g.settingsMu.Lock()
+	defer g.settingsMu.Unlock()
+
+	stored := g.storedSettings(settings)
+	if errS := g.store.SaveSettings(ctx, stored); errS != nil {
+		return g.liveSettings(g.settings), fmt.Errorf("core.UpdateSettings: %w", errS)
+	}
+	g.settings = stored
+
+	return settings, nil
+}
+
+// ResetSettings возвращает настройки пользователя к значениям по умолчанию.
+func (g *Gist) ResetSettings(ctx context.Context) (model.Settings, error) {
+	return g.UpdateSettings(ctx, DefaultSettings(g.cfg))
+}
+
+// GetProviders возвращает провайдеров LLM, включенных в конфигурации.
+func (g *Gist) GetProviders(_ context.Context) []string {
+	providers := make([]string, 0, 4)
+	if g.cfg.LLM.Ollama.Enabled {
+		providers = append(providers, "Ollama")
+	}
+	if g.cfg.LLM.OpenRouter.Enabled {
+		providers = append(providers, "OpenRouter")
+	}
+	if g.cfg.LLM.Gemini.Enabled {
+		providers = append(providers, "Gemini")
+	}
+	if g.cfg.LLM.OpenAI.Enabled {
+		providers = append(providers, "OpenAI")
+	}
+	return providers
+}
+
+// GetModels возвращает модели, доступные у включенного провайдера LLM.
+func (g *Gist) GetModels(ctx context.Context, provider string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "core.GetModels")
+	defer span.End()
+
+	m, errM := g.checkModel(model.LLMModel{Provider: provider})
+	if errM != nil {
+		return nil, fmt.Errorf("core.GetModels: %w", errM)
+	}
+
+	models, errL := g.llmClient.ListModels(ctx, m.Provider)
+	if errL != nil {
+		return nil, fmt.Errorf("core.GetModels: %w", errL)
+	}
+	return models, nil
+}
+
+// SetChatModel выбирает модель LLM для чата. Пустая модель - чат использует общую модель пользователя.
+func (g *Gist) SetChatModel(ctx context.Context, chatID int64, m model.LLMModel) (model.Settings, error) {
+	settings := g.GetSettings(ctx)
+	settings.ChatModels = maps.Clone(settings.ChatModels) // Карта общая с текущими настройками
+
+	if m.IsZero() {
+		delete(settings.ChatModels, chatID)
+	} else {
+		checked, errM := g.checkModel(m)
+		if errM != nil {
+			return settings, fmt.Errorf("core.SetChatModel: %w", errM)
+		}
+		if settings.ChatModels == nil {
+			settings.ChatModels = make(map[int64]model.LLMModel)
+		}
+		settings.ChatModels[chatID] = checked
+	}
+
+	return g.UpdateSettings(ctx, settings)
+}
+
+// checkModel проверяет, что провайдер модели включен, и приводит имя провайдера к виду из конфигурации.
+func (g *Gist) checkModel(m model.LLMModel) (model.LLMModel, error) {
+	for _, p := range g.GetProviders(context.Background()) {
+		if strings.EqualFold(p, m.Provider) {
+			m.Provider = p
+			return m, nil
+		}
+	}
+	return m, fmt.Errorf("%w: provider %q is not enabled", model.ErrUnknownModel, m.Provider)
+}
+
+// location часовой пояс пользователя.
+func (g *Gist) location(ctx context.Context) *time.Location {
+	settings := g.GetSettings(ctx)
+	return settings.Location()
+}
+
+// llmContext контекст запроса к LLM с настройками пользователя. Расход запроса учитывается по чату chatID и виду запроса kind.
+func (g *Gist) llmContext(ctx context.Context, chatID int64, kind model.UsageKind) context.Context {
+	return model.ContextWithSettings(g.usageContext(ctx, chatID, kind), g.GetSettings(ctx))
+}
+
+// llmChatContext контекст запроса к LLM по чату chatID. Модель запроса override имеет приоритет над моделью чата из настроек.
+func (g *Gist) llmChatContext(ctx context.Context, chatID int64, kind model.UsageKind, override model.LLMModel) context.Context {
+	settings := g.GetSettings(ctx)
+
+	m := settings.TextModel(chatID)
+	if !override.IsZero() {
+		m = override
+	}
+	settings.Provider, settings.Model = m.Provider, m.Name
+
+	return model.ContextWithSettings(g.usageContext(ctx, chatID, kind), settings)
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+const (
+	usageRetention     = 90               // Дней хранения расхода LLM
+	usageFlushInterval = 30 * time.Second // Расход копится в памяти и сохраняется не чаще, чем раз в интервал
+)
+
+// UsageStore контракт хранилища расхода LLM.
+type UsageStore interface {
+	LoadUsage(ctx context.Context) ([]model.UsageRecord, error)
+	SaveUsage(ctx context.Context, records []model.UsageRecord) error
+}
+
+// InitUsage загружает расход LLM из хранилища.
+func (g *Gist) InitUsage(ctx context.Context) error {
+	records, errL := g.store.LoadUsage(ctx)
+	if errL != nil {
+		return fmt.Errorf("core.InitUsage: %w", errL)
+	}
+
+	g.usageMu.Lock()
+	defer g.usageMu.Unlock()
+
+	g.usage = records
+	return nil
+}
+
+// GetUsage возвращает расход LLM за последние days дней, включая сегодняшний, по дням и по чатам.
+func (g *Gist) GetUsage(ctx context.Context, days int) model.UsageReport {
+	loc := g.location(ctx)
+	now := time.Now().In(loc)
+	since := time.Date(now.Year(), now.Month(), now.Day()-max(days, 1)+1, 0, 0, 0, 0, loc)
+
+	g.usageMu.Lock()
+	records := slices.Clone(g.usage)
+	g.usageMu.Unlock()
+
+	report := model.UsageReport{Since: since}
+	byDay := make(map[string]*model.UsageTotal)
+	byChat := make(map[int64]*model.UsageTotal)
+	for _, r := range records {
+		day, errP := time.ParseInLocation(time.DateOnly, r.Day, loc)
+		if errP != nil || day.Before(since) {
+			continue
+		}
+
+		report.Total.Add(r.Usage)
+
+		if byDay[r.Day] == nil {
+			byDay[r.Day] = &model.UsageTotal{Day: day}
+		}
+		byDay[r.Day].Add(r.Usage)
+
+		if byChat[r.ChatID] == nil {
+			byChat[r.ChatID] = &model.UsageTotal{ChatID: r.ChatID}
+		}
+		byChat[r.ChatID].Add(r.Usage)
+	}
+
+	for _, t := range byDay {
+		report.Days = append(report.Days, *t)
+	}
+	slices.SortFunc(report.Days, func(a, b model.UsageTotal) int {
+		return b.Day.Compare(a.Day)
+	})
+
+	g.mu.RLock()
+	for _, t := range byChat {
+		if chat, ok := g.cache[t.ChatID]; ok {
+			t.Title = chat.Title
+		}
+		report.Chats = append(report.Chats, *t)
+	}
+	g.mu.RUnlock()
+	slices.SortFunc(report.Chats, func(a, b model.UsageTotal) int {
+		return cmp.Or(
+			cmp.Compare(b.Cost, a.Cost),
+			cmp.Compare(b.InputTokens+b.OutputTokens, a.InputTokens+a.OutputTokens),
+			cmp.Compare(a.ChatID, b.ChatID),
+		)
+	})
+
+	return report
+}
+
+// usageContext добавляет в контекст запроса к LLM учет расхода по чату chatID и виду запроса kind.
+func (g *Gist) usageContext(ctx context.Context, chatID int64, kind model.UsageKind) context.Context {
+	return model.ContextWithUsage(ctx, func(u model.Usage) {
+		g.recordUsage(context.WithoutCancel(ctx), chatID, kind, u)
+	})
+}
+
+// recordUsage прибавляет расход к итогу дня по чату, модели и виду запроса. Расход сохраняется в хранилище отложенно,
+// см. flushUsage: запись файла на каждый запрос к LLM под блокировкой тормозила бы параллельные задачи.
+// Записи старше usageRetention дней удаляются.
+func (g *Gist) recordUsage(ctx context.Context, chatID int64, kind model.UsageKind, u model.Usage) {
+	log := slog.With("func", "core.recordUsage", slog.Int64("chat_id", chatID))
+
+	now := time.Now().In(g.location(ctx))
+	day := now.Format(time.DateOnly)
+	oldest := now.AddDate(0, 0, -usageRetention).Format(time.DateOnly)
+
+	g.usageMu.Lock()
+	defer g.usageMu.Unlock()
+
+	g.usage = slices.DeleteFunc(g.usage, func(r model.UsageRecord) bool {
+		return r.Day < oldest // Формат даты сравнивается как строка
+	})
+
+	i := slices.IndexFunc(g.usage, func(r model.UsageRecord) bool {
+		return r.Day == day && r.ChatID == chatID && r.Kind == kind && r.Provider == u.Provider && r.Model == u.Model
+	})
+	if i < 0 {
+		g.usage = append(g.usage, model.UsageRecord{Day: day, ChatID: chatID, Kind: kind, Usage: model.Usage{Provider: u.Provider, Model: u.Model}})
+		i = len(g.usage) - 1
+	}
+	g.usage[i].Add(u)
+
+	g.scheduleUsageFlush()
+
+	log.Debug("llm usage recorded",
+		slog.String("kind", string(kind)),
+		slog.String("model", u.Provider+"/"+u.Model),
+		slog.Int("input_tokens", u.InputTokens),
+		slog.Int("output_tokens", u.OutputTokens),
+		slog.Float64("cost", u.Cost))
+}
+
+// scheduleUsageFlush планирует сохранение расхода через usageFlushInterval, если оно еще не запланировано. Вызывается под блокировкой usageMu.
+func (g *Gist) scheduleUsageFlush() {
+	if g.usageFlush != nil {
+		return
+	}
+	g.usageFlush = time.AfterFunc(usageFlushInterval, func() {
+		g.flushUsage(context.Background())
+	})
+}
+
+// flushUsage сохраняет накопленный расход в хранилище, если есть несохраненные изменения.
+// Вызывается по таймеру и при остановке. При ошибке сохранение повторяется через usageFlushInterval.
+func (g *Gist) flushUsage(ctx context.Context) {
+	g.usageSaveMu.Lock() // Сохранения по таймеру и при остановке не должны перезаписать друг друга в обратном порядке
+	defer g.usageSaveMu.Unlock()
+
+	g.usageMu.Lock()
+	if g.usageFlush == nil {
+		g.usageMu.Unlock()
+		return
+	}
+	g.usageFlush.Stop()
+	g.usageFlush = nil
+	records := slices.Clone(g.usage)
+	g.usageMu.Unlock()
+
+	if errS := g.store.SaveUsage(ctx, records); errS != nil {
+		slog.With("func", "core.flushUsage").Error("save usage error", slog.Any("error", errS))
+
+		g.usageMu.Lock()
+		g.scheduleUsageFlush()
+		g.usageMu.Unlock()
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+// AlertNotifier контракт оповещения пользователя о сообщениях из списка наблюдения.
+type AlertNotifier interface {
+	WatchAlert(ctx context.Context, alert model.WatchAlert)
+}
+
+// InitWatchlist загружает список наблюдения из хранилища и подключает оповещения к телеграм клиенту.
+func (g *Gist) InitWatchlist(ctx context.Context, notifier AlertNotifier) error {
+	rules, errL := g.store.LoadWatchRules(ctx)
+	if errL != nil {
+		return fmt.Errorf("core.InitWatchlist: %w", errL)
+	}
+
+	g.watchMu.Lock()
+	defer g.watchMu.Unlock()
+
+	g.watchRules = rules
+	g.alertNotifier = notifier
+
+	g.tgClient.SetAlertHandler(g.watchAlert)
+	if errS := g.tgClient.SetWatchRules(slices.Clone(rules)); errS != nil {
+		return fmt.Errorf("core.InitWatchlist: %w", errS)
+	}
+
+	return nil
+}
+
+// AddWatchRule добавляет правило в список наблюдения.
+func (g *Gist) AddWatchRule(ctx context.Context, rule model.WatchRule) (model.WatchRule, error) {
+	if errV := rule.Validate(); errV != nil {
+		return model.WatchRule{}, fmt.Errorf("core.AddWatchRule: %w", errV)
+	}
+
+	g.watchMu.Lock()
+	defer g.watchMu.Unlock()
+
+	rule.ID = 1
+	for _, r := range g.watchRules {
+		rule.ID = max(rule.ID, r.ID+1)
+	}
+	rule.Created = time.Now()
+
+	rules := append(slices.Clone(g.watchRules), rule)
+	if errS := g.applyWatchRules(ctx, rules); errS != nil {
+		return model.WatchRule{}, fmt.Errorf("core.AddWatchRule: %w", errS)
+	}
+
+	return rule, nil
+}
+
+// RemoveWatchRule удаляет правило из списка наблюдения.
+func (g *Gist) RemoveWatchRule(ctx context.Context, ruleID int64) error {
+	g.watchMu.Lock()
+	defer g.watchMu.Unlock()
+
+	i := slices.IndexFunc(g.watchRules, func(r model.WatchRule) bool { return r.ID == ruleID })
+	if i == -1 {
+		return fmt.Errorf("core.RemoveWatchRule: %w", model.ErrWatchRuleNotFound)
+	}
+
+	rules := slices.Delete(slices.Clone(g.watchRules), i, i+1)
+	if errS := g.applyWatchRules(ctx, rules); errS != nil {
+		return fmt.Errorf("core.RemoveWatchRule: %w", errS)
+	}
+
+	return nil
+}
+
+// GetWatchRules возвращает список наблюдения.
+func (g *Gist) GetWatchRules(_ context.Context) []model.WatchRule {
+	g.watchMu.Lock()
+	defer g.watchMu.Unlock()
+
+	return slices.Clone(g.watchRules)
+}
+
+// applyWatchRules сохраняет правила и передает их телеграм клиенту. Вызывается под блокировкой g.watchMu.
+func (g *Gist) applyWatchRules(ctx context.Context, rules []model.WatchRule) error {
+	if errS := g.store.SaveWatchRules(ctx, rules); errS != nil {
+		return errS
+	}
+	if errW := g.tgClient.SetWatchRules(slices.Clone(rules)); errW != nil {
+		return errW
+	}
+
+	g.watchRules = rules
+	return nil
+}
+
+// watchAlert пересылает оповещение телеграм клиента пользователю.
+func (g *Gist) watchAlert(ctx context.Context, alert model.WatchAlert) {
+	g.watchMu.Lock()
+	notifier := g.alertNotifier
+	g.watchMu.Unlock()
+
+	if notifier == nil {
+		slog.With("func", "core.watchAlert").Warn("alert notifier not set", slog.Int64("rule_id", alert.Rule.ID))
+		return
+	}
+
+	if !g.GetSettings(ctx).NotifyAlerts { // Пользователь отключил оповещения в настройках
+		slog.With("func", "core.watchAlert").Debug("watch alerts disabled", slog.Int64("rule_id", alert.Rule.ID))
+		return
+	}
+
+	if alert.ChatTitle == "" { // В обновлении может не быть информации о чате, берем название из кэша
+		if chat, errD := g.GetChatDetail(ctx, alert.ChatID); errD == nil {
+			alert.ChatTitle = chat.Title
+		}
+	}
+
+	notifier.WatchAlert(ctx, alert)
+}
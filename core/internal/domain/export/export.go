@@ -0,0 +1,173 @@
+// Package export выгружает краткий пересказ чата в документ Markdown или HTML.
+// Используется ботом, HTTP API и утилитой командной строки.
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+	"github.com/arslanovdi/Gist/core/internal/infra/utils"
+)
+
+// Format формат документа.
+type Format int8
+
+// Список форматов
+const (
+	Markdown Format = iota + 1
+	HTML
+)
+
+const timeLayout = "02.01.2006 15:04"
+
+var (
+	errUnknownFormat = errors.New("unknown export format")
+	errEmptyGist     = errors.New("chat has no gist to export")
+
+	unsafeFileName = regexp.MustCompile(`[^\p{L}\p{N}_-]+`)
+)
+
+// ParseFormat разбирает название формата: md, markdown, html.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "md", "markdown":
+		return Markdown, nil
+	case "html", "htm":
+		return HTML, nil
+	default:
+		return 0, fmt.Errorf("export.ParseFormat: %w: %q", errUnknownFormat, s)
+	}
+}
+
+// Extension расширение файла формата, с точкой.
+func (f Format) Extension() string {
+	switch f {
+	case Markdown:
+		return ".md"
+	case HTML:
+		return ".html"
+	default:
+		return ""
+	}
+}
+
+// ContentType MIME тип формата.
+func (f Format) ContentType() string {
+	switch f {
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	case HTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// FileName имя файла документа: название чата и дата первого сообщения пересказа.
+func FileName(chat *model.Chat, format Format) string {
+	name := strings.Trim(unsafeFileName.ReplaceAllString(chat.Title, "_"), "_")
+	if name == "" {
+		name = fmt.Sprintf("chat_%d", chat.ID)
+	}
+	if len(chat.Gist) > 0 {
+		name += "_" + chat.Gist[0].FirstMessageData.UTC().Format("2006-01-02")
+	}
+	return "gist_" + name + format.Extension()
+}
+
+// Write записывает все страницы краткого пересказа чата в документ заданного формата.
+func Write(w io.Writer, chat *model.Chat, format Format) error {
+	if len(chat.Gist) == 0 {
+		return fmt.Errorf("export.Write: %w", errEmptyGist)
+	}
+
+	doc := newDocument(chat)
+
+	var err error
+	switch format {
+	case Markdown:
+		err = writeMarkdown(w, doc)
+	case HTML:
+		err = writeHTML(w, doc)
+	default:
+		err = errUnknownFormat
+	}
+	if err != nil {
+		return fmt.Errorf("export.Write: %w", err)
+	}
+	return nil
+}
+
+// Render возвращает документ с кратким пересказом чата.
+func Render(chat *model.Chat, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Write(&buf, chat, format); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// document данные документа, общие для всех форматов.
+type document struct {
+	Title    string
+	Range    string
+	Partial  bool
+	Total    int // Всего сообщений в пересказе
+	Created  string
+	AboutMe  []mention
+	Sections []section
+}
+
+type mention struct {
+	Time      string
+	ID        int
+	ReplyToMe bool
+	Text      string
+}
+
+type section struct {
+	Number   int
+	From     string
+	To       string
+	Duration string
+	Count    int
+	Gist     string
+}
+
+func newDocument(chat *model.Chat) document {
+	doc := document{
+		Title:   chat.Title,
+		Range:   chat.Range.String(),
+		Partial: chat.GistPartial,
+		Created: time.Now().UTC().Format(timeLayout),
+	}
+
+	for _, m := range chat.AboutMe {
+		doc.AboutMe = append(doc.AboutMe, mention{
+			Time:      m.Timestamp.UTC().Format(timeLayout),
+			ID:        m.ID,
+			ReplyToMe: m.ReplyToMe,
+			Text:      strings.Join(strings.Fields(m.Text), " "),
+		})
+	}
+
+	for i, b := range chat.Gist {
+		doc.Total += b.MessageCount
+		doc.Sections = append(doc.Sections, section{
+			Number:   i + 1,
+			From:     b.FirstMessageData.UTC().Format(timeLayout),
+			To:       b.LastMessageData.UTC().Format(timeLayout),
+			Duration: utils.FormatDurationShort(b.LastMessageData.Sub(b.FirstMessageData)),
+			Count:    b.MessageCount,
+			Gist:     strings.TrimSpace(b.Gist),
+		})
+	}
+
+	return doc
+}
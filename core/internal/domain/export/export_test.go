@@ -0,0 +1,182 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arslanovdi/Gist/core/internal/domain/model"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные документы в testdata")
+
+// testChat чат со служебными символами Markdown и HTML в названии, упоминаниях и пересказе.
+func testChat() *model.Chat {
+	start := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	return &model.Chat{
+		ID:          42,
+		Title:       `Dev <ops> & *team* #1`,
+		Range:       model.MessageRange{Kind: model.RangeLast, Last: 30},
+		GistPartial: true,
+		AboutMe: []model.Message{
+			{ID: 7, Timestamp: start.Add(5 * time.Minute), Mentioned: true, Text: "@ivan   check\n[this](http://x) <script>alert(1)</script>"},
+			{ID: 12, Timestamp: start.Add(time.Hour), ReplyToMe: true, Text: "agree_with `you`"},
+		},
+		Gist: []model.BatchGist{
+			{
+				FirstMessageID:   1,
+				LastMessageID:    20,
+				MessageCount:     20,
+				FirstMessageData: start,
+				LastMessageData:  start.Add(90 * time.Minute),
+				Gist:             "  **Релиз** перенесли.\n- <b>v1.2</b> & \"hotfix\"\n",
+			},
+			{
+				FirstMessageID:   21,
+				LastMessageID:    30,
+				MessageCount:     10,
+				FirstMessageData: start.Add(2 * time.Hour),
+				LastMessageData:  start.Add(26 * time.Hour),
+				Gist:             "Обсуждали <i>ревью</i>.",
+			},
+		},
+	}
+}
+
+func TestWriteGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w io.Writer, doc document) error
+	}{
+		{name: "gist.md", write: writeMarkdown},
+		{name: "gist.html", write: writeHTML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newDocument(testChat())
+			doc.Created = "01.03.2025 12:00" // Время выгрузки фиксировано для сравнения с эталоном
+
+			var buf bytes.Buffer
+			if err := tt.write(&buf, doc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("document differs from %s, run go test -update to review changes\ngot:\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format   Format
+		contains []string
+		excludes []string
+	}{
+		{
+			format:   Markdown,
+			contains: []string{`# Dev \<ops\> & \*team\* \#1`, `\[this\](http://x) \<script\>`, "agree\\_with \\`you\\`", "**Релиз** перенесли."},
+			excludes: []string{"<script>"},
+		},
+		{
+			format:   HTML,
+			contains: []string{"<h1>Dev &lt;ops&gt; &amp; *team* #1</h1>", "&lt;script&gt;alert(1)&lt;/script&gt;", "&lt;b&gt;v1.2&lt;/b&gt; &amp; &#34;hotfix&#34;"},
+			excludes: []string{"<script>", "<b>v1.2</b>", "<i>ревью</i>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.Extension(), func(t *testing.T) {
+			data, err := Render(testChat(), tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			doc := string(data)
+			for _, s := range tt.contains {
+				if !strings.Contains(doc, s) {
+					t.Errorf("document does not contain %q", s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(doc, s) {
+					t.Errorf("document contains unescaped %q", s)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := Render(&model.Chat{ID: 1}, Markdown); !errors.Is(err, errEmptyGist) {
+		t.Errorf("render chat without gist: error = %v, want %v", err, errEmptyGist)
+	}
+	if _, err := Render(testChat(), Format(0)); !errors.Is(err, errUnknownFormat) {
+		t.Errorf("render unknown format: error = %v, want %v", err, errUnknownFormat)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "md", want: Markdown},
+		{in: "Markdown", want: Markdown},
+		{in: "HTML", want: HTML},
+		{in: "htm", want: HTML},
+		{in: "pdf", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseFormat(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseFormat(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		name   string
+		chat   *model.Chat
+		format Format
+		want   string
+	}{
+		{name: "title and date", chat: testChat(), format: Markdown, want: "gist_Dev_ops_team_1_2025-03-01.md"},
+		{name: "cyrillic title", chat: &model.Chat{ID: 1, Title: "Команда / backend"}, format: HTML, want: "gist_Команда_backend.html"},
+		{name: "title without letters", chat: &model.Chat{ID: 5, Title: "🔥🔥"}, format: HTML, want: "gist_chat_5.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FileName(tt.chat, tt.format); got != tt.want {
+				t.Errorf("FileName = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
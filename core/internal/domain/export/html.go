@@ -0,0 +1,55 @@
+package export
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlTemplate самодостаточный HTML документ, стили встроены, внешних ресурсов нет.
+var htmlTemplate = template.Must(template.New("gist").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} — краткий пересказ</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; max-width: 860px; margin: 2em auto; padding: 0 1em; line-height: 1.5; color: #222; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1.5em; }
+.meta { color: #666; font-size: 0.9em; }
+.about-me { background: #fff7e0; border-left: 4px solid #f0b400; padding: 0.5em 1em; }
+.about-me li { margin: 0.3em 0; }
+section { margin-top: 2em; }
+section h2 { font-size: 1.2em; margin-bottom: 0.2em; }
+.gist { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p class="meta">Период: {{.Range}}<br>Сообщений: {{.Total}}, страниц: {{len .Sections}}<br>Выгружено: {{.Created}} (UTC+0){{if .Partial}}<br>⏸ Пересказ неполный{{end}}</p>
+</header>
+{{- if .AboutMe}}
+<div class="about-me">
+<h2>🎯 Касается вас</h2>
+<ul>
+{{- range .AboutMe}}
+<li>{{if .ReplyToMe}}↩️{{else}}📣{{end}} {{.Time}} #{{.ID}}: {{.Text}}</li>
+{{- end}}
+</ul>
+</div>
+{{- end}}
+{{- range .Sections}}
+<section>
+<h2>Страница {{.Number}}: {{.From}} — {{.To}}</h2>
+<p class="meta">Сообщений: {{.Count}}, за {{.Duration}} (UTC+0)</p>
+<div class="gist">{{.Gist}}</div>
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTML документ HTML. Текст пересказа экранируется и выводится с сохранением переносов строк.
+func writeHTML(w io.Writer, doc document) error {
+	return htmlTemplate.Execute(w, doc)
+}
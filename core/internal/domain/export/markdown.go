@@ -0,0 +1,50 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeMarkdown документ Markdown. Текст пересказа выводится как есть, LLM и так отвечает в Markdown.
+func writeMarkdown(w io.Writer, doc document) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s\n\n", escapeMarkdown(doc.Title))
+	fmt.Fprintf(&sb, "- Период: %s\n", doc.Range)
+	fmt.Fprintf(&sb, "- Сообщений: %d, страниц: %d\n", doc.Total, len(doc.Sections))
+	fmt.Fprintf(&sb, "- Выгружено: %s (UTC+0)\n", doc.Created)
+	if doc.Partial {
+		sb.WriteString("- ⏸ Пересказ неполный\n")
+	}
+
+	if len(doc.AboutMe) > 0 {
+		sb.WriteString("\n## 🎯 Касается вас\n\n")
+		for _, m := range doc.AboutMe {
+			icon := "📣"
+			if m.ReplyToMe {
+				icon = "↩️"
+			}
+			fmt.Fprintf(&sb, "- %s %s #%d: %s\n", icon, m.Time, m.ID, escapeMarkdown(m.Text))
+		}
+	}
+
+	for _, s := range doc.Sections {
+		fmt.Fprintf(&sb, "\n## Страница %d: %s — %s\n\n", s.Number, s.From, s.To)
+		fmt.Fprintf(&sb, "_Сообщений: %d, за %s (UTC+0)_\n\n", s.Count, s.Duration)
+		sb.WriteString(s.Gist)
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`,
+)
+
+// escapeMarkdown экранирует служебные символы Markdown в названиях и сообщениях пользователей.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Answer ответ LLM на вопрос пользователя по сообщениям чата.
+type Answer struct {
+	Text      string     // Текст ответа, ссылки на сообщения в виде [#ID]
+	Citations []Citation // Сообщения, на которые ссылается ответ, в порядке упоминания
+}
+
+// Citation ссылка ответа на сообщение чата.
+type Citation struct {
+	MessageID int
+	Timestamp time.Time
+}
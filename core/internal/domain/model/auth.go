@@ -0,0 +1,9 @@
+package model
+
+import "context"
+
+// Authenticator ввод данных для входа в аккаунт Telegram при подключении пользователя.
+type Authenticator interface {
+	Code(ctx context.Context) (string, error)     // Код подтверждения, отправленный Telegram
+	Password(ctx context.Context) (string, error) // Пароль двухэтапной аутентификации
+}
@@ -0,0 +1,17 @@
+package model
+
+// BriefingChat чат в сводке по непрочитанным чатам.
+type BriefingChat struct {
+	ChatID      int64
+	Title       string
+	UnreadCount int
+	Summary     string // Абзац сводки: пересказ непрочитанных сообщений всех батчей, сведенный LLM в один абзац
+	Pages       int    // Количество батчей пересказа. Прочитанными отмечаются сообщения до последнего батча, новые остаются непрочитанными
+	Err         error  // Ошибка пересказа чата, на остальные чаты сводки не влияет
+}
+
+// Briefing сводка по непрочитанным чатам выше порога, в порядке важности.
+type Briefing struct {
+	Chats   []BriefingChat
+	Skipped int // Чаты выше порога, не вошедшие в сводку из-за ограничения settings.briefing_chats
+}
@@ -13,3 +13,45 @@ var ErrResourceExhausted = errors.New("resource exhausted")
 
 // ErrGeminiTTSQuotaExceeded достигнут суточный лимит api вызовов к gemini-tts. С текущим пулом api ключей.
 var ErrGeminiTTSQuotaExceeded = errors.New("daily limit of API calls to gemini-tts has been reached")
+
+// ErrJobAlreadyRunning задача такого же типа для этого чата уже выполняется.
+var ErrJobAlreadyRunning = errors.New("job already running")
+
+// ErrInvalidRange некорректный диапазон сообщений для пересказа.
+var ErrInvalidRange = errors.New("invalid message range")
+
+// ErrInvalidWatchRule некорректное правило списка наблюдения.
+var ErrInvalidWatchRule = errors.New("invalid watch rule")
+
+// ErrWatchRuleNotFound правило списка наблюдения не найдено.
+var ErrWatchRuleNotFound = errors.New("watch rule not found")
+
+// ErrUserNotAllowed пользователю бота не разрешено подключать аккаунт Telegram.
+var ErrUserNotAllowed = errors.New("user not allowed")
+
+// ErrLoginInProgress подключение аккаунта Telegram пользователя уже выполняется.
+var ErrLoginInProgress = errors.New("login already in progress")
+
+// ErrSessionExpired сохраненная сессия Telegram клиента недействительна, нужно заново подключить аккаунт.
+var ErrSessionExpired = errors.New("telegram session expired")
+
+// ErrInvalidSettings некорректное значение настроек пользователя.
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// ErrUnknownModel провайдер LLM не включен в конфигурации или модель не задана.
+var ErrUnknownModel = errors.New("unknown llm model")
+
+// ErrBriefingNotFound сводки нет в истории задач, устарела.
+var ErrBriefingNotFound = errors.New("briefing not found")
+
+// ErrHiddenChatNotFound чат отсутствует в списке скрытых.
+var ErrHiddenChatNotFound = errors.New("hidden chat not found")
+
+// ErrJobNotFound задачи нет в истории фоновых задач.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrCustomRangeGist в кэше чата пересказ за произвольный период, сводка по непрочитанным его не сбрасывает.
+var ErrCustomRangeGist = errors.New("chat has gist for custom range")
+
+// ErrGistChanged пересказ чата изменился после того, как его показали пользователю: перезапущен или сброшен.
+var ErrGistChanged = errors.New("chat gist changed")
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// HiddenChat чат из списка скрытых. Скрытые чаты не попадают в список непрочитанных, сводку и упоминания.
+type HiddenChat struct {
+	ChatID int64     `json:"chat_id"`
+	Title  string    `json:"title"` // Название на момент скрытия, чат может отсутствовать в кэше
+	Hidden time.Time `json:"hidden"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// JobKind тип фоновой задачи.
+type JobKind int8
+
+// Список типов фоновых задач
+const (
+	JobGist     JobKind = iota + 1 // Генерация краткого пересказа чата
+	JobAudio                       // Генерация аудиопересказа
+	JobBriefing                    // Сводка по всем непрочитанным чатам
+	JobQuestion                    // Ответ на вопрос по сообщениям чата
+)
+
+// JobStatus состояние фоновой задачи.
+type JobStatus int8
+
+// Список состояний фоновой задачи
+const (
+	JobPending JobStatus = iota + 1 // В очереди, ожидает свободного слота
+	JobRunning                      // Выполняется
+	JobDone                         // Успешно завершена
+	JobFailed                       // Завершена с ошибкой
+)
+
+// Job фоновая задача генерации пересказа или аудиопересказа.
+type Job struct {
+	ID        int64
+	Kind      JobKind
+	ChatID    int64
+	ChatTitle string
+	Page      int          // Номер батча для аудиопересказа, 0 - весь чат.
+	Range     MessageRange // Диапазон сообщений для пересказа
+	Question  string       // Вопрос пользователя для JobQuestion
+	Parent    int64        // ID родительской задачи для вложенной задачи, например пересказа чата в сводке. 0 - самостоятельная задача
+
+	Status   JobStatus
+	Progress string // Последнее сообщение о ходе выполнения
+	Part     int    // Количество загруженных сообщений, либо процент выполнения на этапе генерации LLM
+	LLM      bool   // Идет этап генерации LLM
+	Err      error
+
+	Created  time.Time
+	Started  time.Time
+	Finished time.Time
+
+	Gist     []BatchGist // Результат задачи JobGist
+	Audio    []AudioGist // Результат задачи JobAudio
+	Briefing *Briefing   // Результат задачи JobBriefing
+	Answer   *Answer     // Результат задачи JobQuestion
+}
+
+// Active задача еще не завершена.
+func (j *Job) Active() bool {
+	return j.Status == JobPending || j.Status == JobRunning
+}
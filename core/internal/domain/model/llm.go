@@ -0,0 +1,34 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LLMModel модель LLM провайдера.
+type LLMModel struct {
+	Provider string `json:"provider"`       // Провайдер LLM: Ollama, OpenRouter, Gemini, OpenAI
+	Name     string `json:"name,omitempty"` // Модель провайдера, пусто - модель из конфигурации провайдера
+}
+
+// IsZero модель не выбрана.
+func (m LLMModel) IsZero() bool {
+	return m.Provider == ""
+}
+
+func (m LLMModel) String() string {
+	if m.Name == "" {
+		return m.Provider
+	}
+	return m.Provider + "/" + m.Name
+}
+
+// ParseLLMModel разбирает модель из строки "провайдер/модель", без модели - модель из конфигурации провайдера.
+// Имя модели может содержать "/": OpenRouter/meta-llama/llama-3.3-70b-instruct.
+func ParseLLMModel(s string) (LLMModel, error) {
+	provider, name, _ := strings.Cut(strings.TrimSpace(s), "/")
+	if provider == "" {
+		return LLMModel{}, fmt.Errorf("%w: %q", ErrUnknownModel, s)
+	}
+	return LLMModel{Provider: provider, Name: name}, nil
+}
@@ -14,6 +14,13 @@ type Message struct {
 	IsEdited     bool      `json:"is_edited"`       // Было ли сообщение отредактировано
 	ReplyToMsgID int       `json:"reply_to_msg_id"` // ID сообщения, на которое отвечают (0, если не ответ)
 	IsForwarded  bool      `json:"is_forwarded"`    // Является ли сообщение пересланным
+	Mentioned    bool      `json:"mentioned"`       // Упомянут пользователь
+	ReplyToMe    bool      `json:"reply_to_me"`     // Ответ на сообщение пользователя
+}
+
+// AboutMe сообщение касается пользователя: упоминание или ответ на его сообщение.
+func (m *Message) AboutMe() bool {
+	return m.Mentioned || m.ReplyToMe
 }
 
 // FormatForAnalysis возвращает отформатированное сообщение для анализа
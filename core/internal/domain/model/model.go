@@ -7,29 +7,67 @@ import (
 	"github.com/gotd/td/tg"
 )
 
+// ChatType тип телеграмм чата.
+type ChatType int8
+
+// Список типов чатов
+const (
+	ChatPrivate ChatType = iota + 1 // Личный чат
+	ChatGroup                       // Группа или супергруппа
+	ChatChannel                     // Канал
+)
+
+// ChatOrder порядок сортировки списка чатов.
+type ChatOrder int8
+
+// Список вариантов сортировки
+const (
+	OrderByUnread     ChatOrder = iota // По количеству непрочитанных сообщений
+	OrderByImportance                  // По оценке важности
+)
+
 // Chat структура телеграмм чата
 type Chat struct {
-	Title             string      // From Chats.Title
-	ID                int64       // From Chats.ID
-	UnreadCount       int         // From Dialogs.UnreadCount
-	Skipped           int         // Кол-во пропущенных сообщений (сообщения без текста фото и т.п.)
-	IsFavorite        bool        // TODO Поле Временно, вынести настройки в БД
-	Gist              []BatchGist // Краткий пересказ каждого батча сообщений, батчи формируются в соответствии с контекстным окном LLM.
-	Audio             []AudioGist // Описание файла(ов) с аудиопересказом всех батчей. Файлов может быть несколько, если размер превышает максимально разрешенный.
+	Title             string       // From Chats.Title
+	ID                int64        // From Chats.ID
+	Type              ChatType     // Личный чат, группа или канал
+	UnreadCount       int          // From Dialogs.UnreadCount
+	UnreadMentions    int          // From Dialogs.UnreadMentionsCount, включая ответы на сообщения пользователя
+	UnreadReactions   int          // From Dialogs.UnreadReactionsCount
+	Skipped           int          // Кол-во пропущенных сообщений (сообщения без текста фото и т.п.)
+	IsFavorite        bool         // TODO Поле Временно, вынести настройки в БД
+	IsHidden          bool         // Чат в списке скрытых, заполняется при выдаче из кэша
+	Importance        float64      // Оценка важности чата, заполняется при сортировке OrderByImportance
+	Urgency           int          // Срочность непрочитанных сообщений по оценке LLM 1..10, 0 - не оценена
+	Gist              []BatchGist  // Краткий пересказ каждого батча сообщений, батчи формируются в соответствии с контекстным окном LLM.
+	Range             MessageRange // Диапазон сообщений, по которому загружены Messages и сгенерирован Gist
+	GistPartial       bool         // Пересказ сгенерирован не полностью, генерация выполняется или прервана. Продолжится с первого несделанного батча.
+	AboutMe           []Message    // Сообщения, касающиеся пользователя (блок "Касается вас" в начале пересказа). Заполняется при GistOptions.MentionsBlock
+	Audio             []AudioGist  // Описание файла(ов) с аудиопересказом всех батчей. Файлов может быть несколько, если размер превышает максимально разрешенный.
 	Peer              tg.InputPeerClass
 	LastReadMessageID int
 
+	Messages      []Message
+	RepliesMarked bool // В Messages отмечены ответы на сообщения пользователя, отметка нужна только для блока "Касается вас"
+}
+
+// ChatMentions непрочитанные упоминания пользователя и ответы на его сообщения в чате.
+type ChatMentions struct {
+	Chat     Chat
 	Messages []Message
+	Err      error // Ошибка загрузки упоминаний чата, на остальные чаты не влияет
 }
 
 // BatchGist структура хранит краткий пересказ батча сообщений
 type BatchGist struct {
+	FirstMessageID   int       // ID первого сообщения, в данном батче
 	FirstMessageData time.Time // Метка времени первого сообщения
 	LastMessageID    int       // ID последнего сообщения, в данном батче
 	LastMessageData  time.Time // Метка времени последнего сообщения
 	MessageCount     int
 	Gist             string      // Краткий пересказ
 	Audio            []AudioGist // Предполагается, что аудиопересказ одного батча хранится в одном файле. Вероятность того, что аудиопересказ будет больше 50 Мб есть, но стремится к нулю.
+	Usage            Usage       // Расход LLM на пересказ батча
 }
 
 type AudioGist struct {
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// RangeKind способ выбора сообщений чата для пересказа.
+type RangeKind int8
+
+// Список способов выбора сообщений
+const (
+	RangeUnread  RangeKind = iota // Непрочитанные сообщения, значение по умолчанию
+	RangeSince                    // Сообщения начиная с момента времени Since
+	RangeLast                     // Последние Last сообщений
+	RangeBetween                  // Сообщения с FromID по ToID включительно
+)
+
+// MessageRange диапазон сообщений чата, по которому строится пересказ.
+type MessageRange struct {
+	Kind   RangeKind
+	Since  time.Time // Для RangeSince
+	Preset string    // Для RangeSince, заданного относительно текущего времени, например "24h". Диапазоны с одним пресетом равны
+	Last   int       // Для RangeLast
+	FromID int       // Для RangeBetween, ID первого сообщения
+	ToID   int       // Для RangeBetween, ID последнего сообщения, 0 - до последнего сообщения чата
+}
+
+// GistOptions параметры генерации краткого пересказа чата.
+type GistOptions struct {
+	Range         MessageRange // Диапазон сообщений, по умолчанию непрочитанные
+	MentionsBlock bool         // Вынести упоминания и ответы пользователю в отдельный блок "Касается вас" в начале пересказа
+	Model         LLMModel     // Модель LLM для этого запроса, по умолчанию из настроек пользователя
+}
+
+// IsUnread диапазон - непрочитанные сообщения.
+func (r MessageRange) IsUnread() bool {
+	return r.Kind == RangeUnread
+}
+
+// Equal диапазоны выбирают одни и те же сообщения.
+// Относительные периоды сравниваются по пресету: Since пересчитывается от текущего времени при каждом выборе.
+func (r MessageRange) Equal(other MessageRange) bool {
+	if r.Kind == RangeSince && r.Preset != "" && other.Preset != "" {
+		return other.Kind == RangeSince && r.Preset == other.Preset
+	}
+	return r.Kind == other.Kind && r.Since.Equal(other.Since) && r.Preset == other.Preset && r.Last == other.Last &&
+		r.FromID == other.FromID && r.ToID == other.ToID
+}
+
+// Validate проверяет параметры диапазона.
+func (r MessageRange) Validate() error {
+	switch r.Kind {
+	case RangeUnread:
+		return nil
+	case RangeSince:
+		if r.Since.IsZero() || r.Since.After(time.Now()) {
+			return fmt.Errorf("%w: since %v", ErrInvalidRange, r.Since)
+		}
+	case RangeLast:
+		if r.Last <= 0 {
+			return fmt.Errorf("%w: last %d", ErrInvalidRange, r.Last)
+		}
+	case RangeBetween:
+		if r.FromID <= 0 || (r.ToID != 0 && r.ToID < r.FromID) {
+			return fmt.Errorf("%w: messages %d-%d", ErrInvalidRange, r.FromID, r.ToID)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %d", ErrInvalidRange, r.Kind)
+	}
+	return nil
+}
+
+// String описание диапазона для вывода пользователю.
+func (r MessageRange) String() string {
+	return r.Format(time.UTC)
+}
+
+// Format описание диапазона для пользователя, время в часовом поясе loc.
+func (r MessageRange) Format(loc *time.Location) string {
+	switch r.Kind {
+	case RangeSince:
+		return "с " + r.Since.In(loc).Format("02.01.2006 15:04 (UTC-07:00)")
+	case RangeLast:
+		return fmt.Sprintf("последние %d сообщений", r.Last)
+	case RangeBetween:
+		if r.ToID == 0 {
+			return fmt.Sprintf("сообщения начиная с #%d", r.FromID)
+		}
+		return fmt.Sprintf("сообщения #%d–#%d", r.FromID, r.ToID)
+	default:
+		return "непрочитанные сообщения"
+	}
+}
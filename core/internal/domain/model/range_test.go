@@ -0,0 +1,93 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMessageRangeEqual(t *testing.T) {
+	since := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		a, b MessageRange
+		want bool
+	}{
+		{name: "unread", a: MessageRange{}, b: MessageRange{Kind: RangeUnread}, want: true},
+		{name: "unread and last", a: MessageRange{}, b: MessageRange{Kind: RangeLast, Last: 10}},
+		{name: "same since", a: MessageRange{Kind: RangeSince, Since: since}, b: MessageRange{Kind: RangeSince, Since: since.In(time.Local)}, want: true},
+		{name: "different since", a: MessageRange{Kind: RangeSince, Since: since}, b: MessageRange{Kind: RangeSince, Since: since.Add(time.Second)}},
+		{
+			name: "preset repeated later",
+			a:    MessageRange{Kind: RangeSince, Since: since, Preset: "24h"},
+			b:    MessageRange{Kind: RangeSince, Since: since.Add(time.Hour), Preset: "24h"},
+			want: true,
+		},
+		{
+			name: "different presets",
+			a:    MessageRange{Kind: RangeSince, Since: since, Preset: "24h"},
+			b:    MessageRange{Kind: RangeSince, Since: since, Preset: "7d"},
+		},
+		{
+			name: "preset and explicit since",
+			a:    MessageRange{Kind: RangeSince, Since: since, Preset: "24h"},
+			b:    MessageRange{Kind: RangeSince, Since: since},
+		},
+		{name: "same last", a: MessageRange{Kind: RangeLast, Last: 50}, b: MessageRange{Kind: RangeLast, Last: 50}, want: true},
+		{name: "different last", a: MessageRange{Kind: RangeLast, Last: 50}, b: MessageRange{Kind: RangeLast, Last: 100}},
+		{name: "same between", a: MessageRange{Kind: RangeBetween, FromID: 10, ToID: 20}, b: MessageRange{Kind: RangeBetween, FromID: 10, ToID: 20}, want: true},
+		{name: "open and closed between", a: MessageRange{Kind: RangeBetween, FromID: 10}, b: MessageRange{Kind: RangeBetween, FromID: 10, ToID: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Errorf("%+v.Equal(%+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := tt.b.Equal(tt.a); got != tt.want { // Сравнение симметрично
+				t.Errorf("%+v.Equal(%+v) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageRangeValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		rng     MessageRange
+		wantErr bool
+	}{
+		{name: "unread", rng: MessageRange{}},
+		{name: "since in the past", rng: MessageRange{Kind: RangeSince, Since: now.Add(-time.Hour)}},
+		{name: "since empty", rng: MessageRange{Kind: RangeSince}, wantErr: true},
+		{name: "since in the future", rng: MessageRange{Kind: RangeSince, Since: now.Add(time.Hour)}, wantErr: true},
+		{name: "last", rng: MessageRange{Kind: RangeLast, Last: 1}},
+		{name: "last zero", rng: MessageRange{Kind: RangeLast}, wantErr: true},
+		{name: "last negative", rng: MessageRange{Kind: RangeLast, Last: -5}, wantErr: true},
+		{name: "between", rng: MessageRange{Kind: RangeBetween, FromID: 10, ToID: 20}},
+		{name: "between single message", rng: MessageRange{Kind: RangeBetween, FromID: 10, ToID: 10}},
+		{name: "between up to last message", rng: MessageRange{Kind: RangeBetween, FromID: 10}},
+		{name: "between empty", rng: MessageRange{Kind: RangeBetween}, wantErr: true},
+		{name: "between reversed", rng: MessageRange{Kind: RangeBetween, FromID: 20, ToID: 10}, wantErr: true},
+		{name: "between negative", rng: MessageRange{Kind: RangeBetween, FromID: -1, ToID: 10}, wantErr: true},
+		{name: "unknown kind", rng: MessageRange{Kind: 42}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rng.Validate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRange) {
+					t.Errorf("Validate(%+v) = %v, want %v", tt.rng, err, ErrInvalidRange)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate(%+v): unexpected error: %v", tt.rng, err)
+			}
+		})
+	}
+}
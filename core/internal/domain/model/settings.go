@@ -0,0 +1,135 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	_ "time/tzdata" // Часовые пояса пользователей не зависят от наличия tzdata в системе (образ scratch/distroless)
+)
+
+// GistStyle стиль краткого пересказа.
+type GistStyle string
+
+// Список стилей пересказа
+const (
+	GistStyleTopics    GistStyle = "topics"    // По темам, абзац на тему
+	GistStyleBullets   GistStyle = "bullets"   // По темам, короткими пунктами
+	GistStyleNarrative GistStyle = "narrative" // Связный текст без разбивки на темы
+)
+
+// GistLength объем краткого пересказа.
+type GistLength string
+
+// Список вариантов объема пересказа
+const (
+	GistLengthShort  GistLength = "short"
+	GistLengthMedium GistLength = "medium"
+	GistLengthLong   GistLength = "long"
+)
+
+// Settings настройки пользователя бота. Значения по умолчанию берутся из конфигурации.
+// Порог непрочитанных, голос и язык аудиопересказа в сохраненных настройках пусты, пока пользователь их не изменил,
+// и следуют за конфигурацией, изменяемой без перезапуска.
+type Settings struct {
+	UnreadThreshold int        `json:"unread_threshold"` // Минимальное количество непрочитанных сообщений, чтобы чат попал в список непрочитанных
+	Provider        string     `json:"provider"`         // Провайдер LLM: Ollama, OpenRouter, Gemini, OpenAI
+	Model           string     `json:"model"`            // Модель провайдера, пусто - модель из конфигурации провайдера
+	GistStyle       GistStyle  `json:"gist_style"`
+	GistLength      GistLength `json:"gist_length"`
+	TTSVoice        string     `json:"tts_voice"`     // Голос аудиопересказа
+	TTSLanguage     string     `json:"tts_language"`  // Язык аудиопересказа, BCP-47: ru-RU, en-US
+	BatchSize       int        `json:"batch_size"`    // Максимальное количество сообщений в одном запросе к LLM
+	Timezone        string     `json:"timezone"`      // Часовой пояс IANA для вывода времени: Europe/Moscow
+	NotifyJobs      bool       `json:"notify_jobs"`   // Оповещение о завершении фоновых задач со звуком, иначе без звука
+	NotifyAlerts    bool       `json:"notify_alerts"` // Оповещения о сообщениях из списка наблюдения
+
+	SkipMentionsBlock bool `json:"skip_mentions_block,omitempty"` // Не выносить упоминания и ответы пользователю в блок "Касается вас" в начале пересказа
+
+	ChatModels map[int64]LLMModel `json:"chat_models,omitempty"` // Модели LLM, выбранные для отдельных чатов
+}
+
+// Validate проверяет значения настроек.
+func (s *Settings) Validate() error {
+	switch {
+	case s.UnreadThreshold < 1:
+		return fmt.Errorf("%w: unread threshold %d", ErrInvalidSettings, s.UnreadThreshold)
+	case s.BatchSize < 1:
+		return fmt.Errorf("%w: batch size %d", ErrInvalidSettings, s.BatchSize)
+	case s.Provider == "":
+		return fmt.Errorf("%w: empty llm provider", ErrInvalidSettings)
+	}
+
+	switch s.GistStyle {
+	case GistStyleTopics, GistStyleBullets, GistStyleNarrative:
+	default:
+		return fmt.Errorf("%w: gist style %q", ErrInvalidSettings, s.GistStyle)
+	}
+
+	switch s.GistLength {
+	case GistLengthShort, GistLengthMedium, GistLengthLong:
+	default:
+		return fmt.Errorf("%w: gist length %q", ErrInvalidSettings, s.GistLength)
+	}
+
+	if _, err := loadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("%w: timezone %q", ErrInvalidSettings, s.Timezone)
+	}
+
+	for chatID, m := range s.ChatModels {
+		if m.IsZero() {
+			return fmt.Errorf("%w: empty llm provider for chat %d", ErrInvalidSettings, chatID)
+		}
+	}
+
+	return nil
+}
+
+// TextModel модель LLM для пересказа и вопросов по чату chatID: выбранная для чата, иначе общая модель пользователя.
+func (s *Settings) TextModel(chatID int64) LLMModel {
+	if m, ok := s.ChatModels[chatID]; ok {
+		return m
+	}
+	return LLMModel{Provider: s.Provider, Name: s.Model}
+}
+
+// Location часовой пояс пользователя, при ошибке UTC.
+func (s *Settings) Location() *time.Location {
+	loc, err := loadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// locations загруженные часовые пояса по имени. time.LoadLocation читает и разбирает базу tzdata на каждый вызов,
+// а часовой пояс нужен при выводе каждого экрана бота.
+var locations sync.Map
+
+// loadLocation возвращает часовой пояс по имени IANA, загруженный однажды.
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// settingsKey ключ настроек пользователя в контексте запроса.
+type settingsKey struct{}
+
+// ContextWithSettings добавляет настройки пользователя в контекст. LLM общий для всех пользователей и берет настройки запроса из контекста.
+func ContextWithSettings(ctx context.Context, s Settings) context.Context {
+	return context.WithValue(ctx, settingsKey{}, s)
+}
+
+// SettingsFromContext возвращает настройки пользователя из контекста, false - настройки не заданы.
+func SettingsFromContext(ctx context.Context) (Settings, bool) {
+	s, ok := ctx.Value(settingsKey{}).(Settings)
+	return s, ok
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// UsageKind вид запроса к LLM.
+type UsageKind string
+
+// Виды запросов к LLM
+const (
+	UsageGist     UsageKind = "gist"     // Пересказ чата
+	UsageAnswer   UsageKind = "answer"   // Ответ на вопрос по чату
+	UsageUrgency  UsageKind = "urgency"  // Оценка срочности сообщений
+	UsageBriefing UsageKind = "briefing" // Абзац сводки по непрочитанным чатам
+	UsageAudio    UsageKind = "audio"    // Аудиопересказ
+)
+
+// Usage расход LLM по одному или нескольким запросам.
+type Usage struct {
+	Provider     string // Плагин genkit: googleai, openrouter, ollama, openai
+	Model        string
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	AudioSeconds float64 // Длительность синтезированной речи
+	Cost         float64 // Стоимость в USD по таблице цен из конфигурации
+}
+
+// Add прибавляет расход o. Провайдер и модель не меняются.
+func (u *Usage) Add(o Usage) {
+	u.Requests += o.Requests
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.AudioSeconds += o.AudioSeconds
+	u.Cost += o.Cost
+}
+
+// IsZero расхода нет.
+func (u Usage) IsZero() bool {
+	return u.Requests == 0 && u.InputTokens == 0 && u.OutputTokens == 0 && u.AudioSeconds == 0 && u.Cost == 0
+}
+
+// UsageRecord расход за день по чату, модели и виду запроса.
+// Хранится в агрегированном виде, чтобы данные не росли с каждым запросом.
+type UsageRecord struct {
+	Day    string // Дата в часовом поясе пользователя, формат time.DateOnly
+	ChatID int64  // 0 - запрос не относится к одному чату
+	Kind   UsageKind
+	Usage
+}
+
+// UsageTotal итог расхода за день или по чату.
+type UsageTotal struct {
+	Day    time.Time // Для итогов по дням
+	ChatID int64     // Для итогов по чатам
+	Title  string    // Название чата
+	Usage
+}
+
+// UsageReport отчет о расходе LLM за период.
+type UsageReport struct {
+	Since time.Time
+	Total Usage
+	Days  []UsageTotal // По дням, последние первыми
+	Chats []UsageTotal // По чатам, самые затратные первыми
+}
+
+// usageKey ключ обработчика расхода LLM в контексте запроса.
+type usageKey struct{}
+
+// ContextWithUsage добавляет в контекст обработчик расхода LLM. LLM общий для всех пользователей и возвращает расход запроса через контекст.
+func ContextWithUsage(ctx context.Context, record func(Usage)) context.Context {
+	return context.WithValue(ctx, usageKey{}, record)
+}
+
+// RecordUsage передает расход запроса к LLM обработчику из контекста. Без обработчика расход не учитывается.
+func RecordUsage(ctx context.Context, u Usage) {
+	if record, ok := ctx.Value(usageKey{}).(func(Usage)); ok {
+		record(u)
+	}
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// WatchKind тип правила списка наблюдения.
+type WatchKind int8
+
+// Список типов правил
+const (
+	WatchKeyword WatchKind = iota + 1 // Ключевое слово или фраза, без учета регистра
+	WatchRegex                        // Регулярное выражение
+	WatchMention                      // Упоминание @username, пустой шаблон - упоминание пользователя бота
+)
+
+// WatchRule правило списка наблюдения. Входящие сообщения, подходящие под правило, пересылаются пользователю оповещением.
+type WatchRule struct {
+	ID      int64     `json:"id"`
+	Kind    WatchKind `json:"kind"`
+	Pattern string    `json:"pattern"`
+	ChatID  int64     `json:"chat_id,omitempty"` // Чат, в котором действует правило, 0 - во всех чатах
+	Created time.Time `json:"created"`
+}
+
+// Validate проверяет и нормализует правило.
+func (r *WatchRule) Validate() error {
+	r.Pattern = strings.TrimSpace(r.Pattern)
+
+	switch r.Kind {
+	case WatchKeyword:
+		if r.Pattern == "" {
+			return fmt.Errorf("%w: empty keyword", ErrInvalidWatchRule)
+		}
+	case WatchRegex:
+		if r.Pattern == "" {
+			return fmt.Errorf("%w: empty regex", ErrInvalidWatchRule)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidWatchRule, err)
+		}
+	case WatchMention:
+		if r.Pattern != "" && !strings.HasPrefix(r.Pattern, "@") {
+			r.Pattern = "@" + r.Pattern
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %d", ErrInvalidWatchRule, r.Kind)
+	}
+
+	return nil
+}
+
+// String описание правила для вывода пользователю.
+func (r WatchRule) String() string {
+	switch r.Kind {
+	case WatchRegex:
+		return fmt.Sprintf("regex /%s/", r.Pattern)
+	case WatchMention:
+		if r.Pattern == "" {
+			return "упоминание меня"
+		}
+		return "упоминание " + r.Pattern
+	default:
+		return fmt.Sprintf("слово «%s»", r.Pattern)
+	}
+}
+
+// WatchAlert оповещение о сообщении, подходящем под правило списка наблюдения.
+type WatchAlert struct {
+	Rule      WatchRule
+	ChatID    int64
+	ChatTitle string
+	MessageID int
+	Snippet   string // Фрагмент текста сообщения
+	Link      string // Ссылка на сообщение t.me, пусто если для чата ссылку построить нельзя
+	Timestamp time.Time
+}
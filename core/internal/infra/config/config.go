@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/viper"
@@ -20,26 +21,63 @@ type Config struct {
 		ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
 		TTL             time.Duration `yaml:"ttl"` // Время хранения чатов в кэше
 		AudioPath       string        `mapstructure:"audio_path"`
+		DataPath        string        `mapstructure:"data_path"` // Каталог хранения данных приложения
 	} `yaml:"project"`
 
 	Bot struct {
-		Token             string        `yaml:"token"`                       // env BOT_TOKEN
-		NgrokAuthToken    string        `mapstructure:"ngrok_auth_token"`    // env BOT_NGROK_AUTH_TOKEN
+		Token             Secret        `yaml:"token"`                       // env BOT_TOKEN
+		Mode              string        `mapstructure:"mode"`                // env BOT_MODE. Получение обновлений: ngrok (по умолчанию), webhook, polling
+		NgrokAuthToken    Secret        `mapstructure:"ngrok_auth_token"`    // env BOT_NGROK_AUTH_TOKEN
 		NgrokDomain       string        `mapstructure:"ngrok_domain"`        // env BOT_NGROK_DOMAIN
 		ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // env BOT_READ_HEADER_TIMEOUT
+		WebhookURL        string        `mapstructure:"webhook_url"`         // env BOT_WEBHOOK_URL. Публичный адрес вебхука для режима webhook, https://example.com
+		ListenAddress     string        `mapstructure:"listen_address"`      // env BOT_LISTEN_ADDRESS. Адрес HTTP-сервера вебхука, например :8443
+		TLSCertFile       string        `mapstructure:"tls_cert_file"`       // env BOT_TLS_CERT_FILE. Без сертификата сервер работает по HTTP за reverse proxy
+		TLSKeyFile        string        `mapstructure:"tls_key_file"`        // env BOT_TLS_KEY_FILE
+		UploadCertificate bool          `mapstructure:"upload_certificate"`  // env BOT_UPLOAD_CERTIFICATE. Самоподписанный сертификат передается Telegram
+		PollingTimeout    time.Duration `mapstructure:"polling_timeout"`     // env BOT_POLLING_TIMEOUT. Тайм-аут long polling запроса getUpdates
 	} `yaml:"bot"`
 
+	API struct {
+		Enabled           bool          `mapstructure:"enabled"`             // env API_ENABLED
+		ListenAddress     string        `mapstructure:"listen_address"`      // env API_LISTEN_ADDRESS
+		ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // env API_READ_HEADER_TIMEOUT
+		Tokens            []APIToken    `mapstructure:"tokens"`              // Токены доступа, у каждого пользователя свой
+	} `mapstructure:"api"`
+
+	Metrics struct {
+		Enabled           bool          `mapstructure:"enabled"`             // env METRICS_ENABLED
+		ListenAddress     string        `mapstructure:"listen_address"`      // env METRICS_LISTEN_ADDRESS
+		ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // env METRICS_READ_HEADER_TIMEOUT
+	} `mapstructure:"metrics"`
+
+	Tracing struct {
+		Enabled     bool    `mapstructure:"enabled"`      // env TRACING_ENABLED
+		Endpoint    string  `mapstructure:"endpoint"`     // env TRACING_ENDPOINT	host:port OTLP коллектора
+		Protocol    string  `mapstructure:"protocol"`     // env TRACING_PROTOCOL	grpc или http
+		Insecure    bool    `mapstructure:"insecure"`     // env TRACING_INSECURE	без TLS
+		ServiceName string  `mapstructure:"service_name"` // env TRACING_SERVICE_NAME
+		SampleRatio float64 `mapstructure:"sample_ratio"` // env TRACING_SAMPLE_RATIO	доля записываемых трасс от 0 до 1
+	} `mapstructure:"tracing"`
+
 	Client struct {
 		AppID          int           `mapstructure:"app_id"`   // env CLIENT_APP_ID	// mapstructure вместо yaml, viper некорректно парсит yaml тэги со знаком "_"
-		AppHash        string        `mapstructure:"app_hash"` // env CLIENT_APP_HASH
+		AppHash        Secret        `mapstructure:"app_hash"` // env CLIENT_APP_HASH
 		UserID         int64         `mapstructure:"user_id"`  // env CLIENT_USER_ID
 		Phone          string        `yaml:"phone"`            // env CLIENT_PHONE
 		SessionTTL     time.Duration `yaml:"sessionTTL"`
 		RequestTimeout time.Duration `yaml:"requestTimeout"`
+		AllowedUsers   []int64       `mapstructure:"allowed_users"` // Дополнительные пользователи бота, подключают свой аккаунт Telegram командой /login
 	} `yaml:"client"`
 
 	Settings struct {
 		ChatUnreadThreshold int `mapstructure:"chat_unread_threshold"`
+		JobParallelism      int `mapstructure:"job_parallelism"` // Количество одновременно выполняемых фоновых задач (пересказ, аудиопересказ)
+		JobHistory          int `mapstructure:"job_history"`     // Количество хранимых завершенных задач
+		QAMaxMessages       int `mapstructure:"qa_max_messages"` // Максимальное количество сообщений, передаваемых LLM для ответа на вопрос по чату
+		BriefingChats       int `mapstructure:"briefing_chats"`  // Максимальное количество чатов в сводке по непрочитанным чатам
+
+		Ranking Ranking `mapstructure:"ranking"` // Оценка важности непрочитанных чатов
 	} `yaml:"settings"`
 
 	LLM struct {
@@ -63,19 +101,21 @@ type Config struct {
 			Enabled       bool   `mapstructure:"enabled"`
 			Model         string `yaml:"model"`
 			ContextWindow int    `mapstructure:"context_window"`
+			ApiKey        Secret `mapstructure:"api_key"` // По умолчанию env:OPENROUTER_API_KEY
 		} `yaml:"OpenRouter"`
 
 		Gemini struct {
 			Enabled       bool     `mapstructure:"enabled"`
 			Model         string   `yaml:"model"`
 			ContextWindow int      `mapstructure:"context_window"`
-			ApiKeys       []string `mapstructure:"api_keys"`
+			ApiKeys       []Secret `mapstructure:"api_keys"`
 		} `yaml:"Gemini"`
 
 		OpenAI struct {
 			Enabled       bool   `mapstructure:"enabled"`
 			Model         string `yaml:"model"`
 			ContextWindow int    `mapstructure:"context_window"`
+			ApiKey        Secret `mapstructure:"api_key"` // По умолчанию env:OPENAI_API_KEY
 		} `yaml:"OpenAI"`
 
 		TTS struct {
@@ -86,14 +126,50 @@ type Config struct {
 				VoiceName    string `mapstructure:"voice_name"`
 			} `yaml:"Gemini"`
 		} `mapstructure:"tts"`
+
+		Prices []LLMPrice `mapstructure:"prices"` // Цены моделей для учета расхода, модели без цены считаются бесплатными
 	} `yaml:"llm"`
+
+	v    *viper.Viper               // Источник конфигурации, отслеживается в Watch
+	live atomic.Pointer[Reloadable] // Параметры, измененные без перезапуска, nil - параметры из загруженной конфигурации
 }
 
-// LoadConfig загружает конфигурацию приложения из YAML-файла.
+// Ranking веса оценки важности непрочитанных чатов.
+type Ranking struct {
+	Private         float64 `mapstructure:"private"`          // Вес личного чата
+	Group           float64 `mapstructure:"group"`            // Вес группы
+	Channel         float64 `mapstructure:"channel"`          // Вес канала
+	Mentions        float64 `mapstructure:"mentions"`         // Вес упоминаний и ответов пользователю
+	Favorite        float64 `mapstructure:"favorite"`         // Вес избранного чата
+	Interaction     float64 `mapstructure:"interaction"`      // Вес частоты общения пользователя в чате (рейтинг Telegram top peers)
+	Unread          float64 `mapstructure:"unread"`           // Вес количества непрочитанных сообщений
+	Urgency         float64 `mapstructure:"urgency"`          // Вес срочности, оцененной LLM
+	LLMUrgency      bool    `mapstructure:"llm_urgency"`      // Оценивать срочность с помощью LLM
+	UrgencyMessages int     `mapstructure:"urgency_messages"` // Количество последних сообщений чата для оценки срочности
+	UrgencyChats    int     `mapstructure:"urgency_chats"`    // Количество самых важных чатов, для которых оценивается срочность
+}
+
+// APIToken токен доступа к HTTP API от имени пользователя бота.
+type APIToken struct {
+	Token  Secret `mapstructure:"token"`
+	UserID int64  `mapstructure:"user_id"` // Пользователь бота, с аккаунтом Telegram которого работает API
+}
+
+// LLMPrice цена модели LLM в USD.
+type LLMPrice struct {
+	Model       string  `mapstructure:"model"`        // Имя модели genkit "провайдер/модель", "*" в конце - все модели с таким префиксом
+	Input       float64 `mapstructure:"input"`        // За 1 млн входящих токенов
+	Output      float64 `mapstructure:"output"`       // За 1 млн исходящих токенов
+	AudioMinute float64 `mapstructure:"audio_minute"` // За минуту синтезированной речи, дополнительно к токенам
+}
+
+// LoadConfig загружает конфигурацию приложения из YAML-файла и проверяет ее, см. Validate.
 //
 // - Путь к конфигурационному файлу получает из переменной окружения CONFIG_FILE, если она задана.
 //
 // - Поддерживается переопределение любых параметров через переменные окружения (например, SERVICE_HOST заменит значение service.host из конфига)
+//
+// - Секреты (токены, ключи API) можно задать ссылкой: file:/run/secrets/имя или env:ИМЯ_ПЕРЕМЕННОЙ
 func LoadConfig() (*Config, error) {
 
 	v := viper.New()
@@ -112,14 +188,32 @@ func LoadConfig() (*Config, error) {
 	v.SetConfigName(filepath.Base(configFile))
 	v.AddConfigPath(filepath.Dir(configFile))
 
+	// Ключи OpenRouter и OpenAI раньше задавались только переменными окружения
+	v.SetDefault("llm.openrouter.api_key", secretEnvPrefix+"OPENROUTER_API_KEY")
+	v.SetDefault("llm.openai.api_key", secretEnvPrefix+"OPENAI_API_KEY")
+
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("fatal error config file: %w", err)
 	}
 
+	cfg, err := decode(v)
+	if err != nil {
+		return nil, err
+	}
+	cfg.v = v
+
+	return cfg, nil
+}
+
+// decode разбирает конфигурацию, подставляет значения секретов по ссылкам file: и env: и проверяет результат.
+func decode(v *viper.Viper) (*Config, error) {
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("unable to decode config: %w", err)
 	}
 
+	if err := cfg.validate(cfg.resolveSecrets()); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reloadable параметры, которые применяются без перезапуска приложения при изменении файла конфигурации.
+// Остальные параметры (адреса, токены, провайдеры LLM) применяются только после перезапуска.
+type Reloadable struct {
+	Debug               bool          // project.debug, уровень логирования
+	ChatUnreadThreshold int           // settings.chat_unread_threshold, значение по умолчанию для пользователей, не изменявших порог
+	QAMaxMessages       int           // settings.qa_max_messages
+	BriefingChats       int           // settings.briefing_chats
+	Ranking             Ranking       // settings.ranking
+	FlowTimeout         time.Duration // llm.flow_timeout
+	PromptTimeout       time.Duration // llm.prompt_timeout
+	TTSVoice            string        // llm.tts.Gemini.voice_name, голос для пользователей, не изменявших голос
+	TTSLanguage         string        // llm.tts.Gemini.language_code, язык для пользователей, не изменявших язык
+}
+
+// Live возвращает актуальные значения параметров, изменяемых без перезапуска.
+func (c *Config) Live() Reloadable {
+	if live := c.live.Load(); live != nil {
+		return *live
+	}
+	return c.reloadable()
+}
+
+// reloadable параметры, изменяемые без перезапуска, из загруженной конфигурации.
+func (c *Config) reloadable() Reloadable {
+	return Reloadable{
+		Debug:               c.Project.Debug,
+		ChatUnreadThreshold: c.Settings.ChatUnreadThreshold,
+		QAMaxMessages:       c.Settings.QAMaxMessages,
+		BriefingChats:       c.Settings.BriefingChats,
+		Ranking:             c.Settings.Ranking,
+		FlowTimeout:         c.LLM.FlowTimeout,
+		PromptTimeout:       c.LLM.PromptTimeout,
+		TTSVoice:            c.LLM.TTS.Gemini.VoiceName,
+		TTSLanguage:         c.LLM.TTS.Gemini.LanguageCode,
+	}
+}
+
+// Watch отслеживает изменения файла конфигурации. Измененная конфигурация проверяется целиком,
+// при ошибке продолжают действовать прежние значения. onChange вызывается после применения новых значений.
+func (c *Config) Watch(onChange func(live Reloadable)) {
+	log := slog.With("func", "config.Watch")
+
+	if c.v == nil {
+		log.Warn("config is not loaded from file, watch disabled")
+		return
+	}
+
+	c.v.OnConfigChange(func(e fsnotify.Event) {
+		next, errD := decode(c.v)
+		if errD != nil {
+			log.Error("config reload: keep previous config", slog.String("file", e.Name), slog.Any("error", errD))
+			return
+		}
+
+		live := next.reloadable()
+		c.live.Store(&live)
+		log.Info("config reloaded, parameters other than thresholds, timeouts, tts voice and log level require restart", slog.String("file", e.Name))
+
+		if onChange != nil {
+			onChange(live)
+		}
+	})
+	c.v.WatchConfig()
+}
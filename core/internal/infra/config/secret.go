@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Префиксы ссылок на секреты в значениях конфигурации
+const (
+	secretFilePrefix = "file:" // file:/run/secrets/bot_token - содержимое файла, без пробелов и переводов строки по краям
+	secretEnvPrefix  = "env:"  // env:BOT_TOKEN - значение переменной окружения
+)
+
+const secretMask = "********"
+
+// Secret секретное значение конфигурации: токен, ключ API. В логах и при форматировании через fmt значение скрыто,
+// исходное значение возвращает Value.
+type Secret string
+
+// Value исходное значение секрета.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String скрывает значение секрета.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// GoString скрывает значение секрета при форматировании %#v.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// LogValue скрывает значение секрета в slog.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// resolveSecret заменяет ссылку file: или env: значением секрета. Значение без префикса возвращается как есть.
+func resolveSecret(key string, s Secret) (Secret, error) {
+	ref := s.Value()
+
+	switch {
+	case strings.HasPrefix(ref, secretFilePrefix):
+		path := strings.TrimPrefix(ref, secretFilePrefix)
+		data, errR := os.ReadFile(path) //nolint:gosec // путь к файлу секрета задается в конфигурации
+		if errR != nil {
+			return "", fmt.Errorf("  %s: read secret file %s: %w", key, path, errR)
+		}
+		return Secret(strings.TrimSpace(string(data))), nil
+	case strings.HasPrefix(ref, secretEnvPrefix):
+		name := strings.TrimPrefix(ref, secretEnvPrefix)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("  %s: env %s is not set", key, name)
+		}
+		return Secret(value), nil
+	default:
+		return s, nil
+	}
+}
+
+// resolveSecrets заменяет ссылки на секреты значениями во всех секретных параметрах конфигурации.
+func (c *Config) resolveSecrets() []error {
+	var errs []error
+	resolve := func(key string, s *Secret) {
+		value, errS := resolveSecret(key, *s)
+		if errS != nil {
+			errs = append(errs, errS)
+			return
+		}
+		*s = value
+	}
+
+	resolve("bot.token", &c.Bot.Token)
+	resolve("bot.ngrok_auth_token", &c.Bot.NgrokAuthToken)
+	resolve("client.app_hash", &c.Client.AppHash)
+	for i := range c.API.Tokens {
+		resolve(fmt.Sprintf("api.tokens[%d].token", i), &c.API.Tokens[i].Token)
+	}
+	// Ключи отключенных провайдеров не нужны, ссылка по умолчанию env: на них может указывать на незаданную переменную
+	if c.LLM.OpenRouter.Enabled {
+		resolve("llm.OpenRouter.api_key", &c.LLM.OpenRouter.ApiKey)
+	}
+	if c.LLM.Gemini.Enabled {
+		for i := range c.LLM.Gemini.ApiKeys {
+			resolve(fmt.Sprintf("llm.Gemini.api_keys[%d]", i), &c.LLM.Gemini.ApiKeys[i])
+		}
+	}
+	if c.LLM.OpenAI.Enabled {
+		resolve("llm.OpenAI.api_key", &c.LLM.OpenAI.ApiKey)
+	}
+
+	return errs
+}
+
+// Secrets значения всех заданных секретов конфигурации, для маскирования в логах.
+// Ссылки file: и env: отключенных провайдеров LLM не разрешаются, но и секретом не являются.
+func (c *Config) Secrets() []string {
+	secrets := []Secret{c.Bot.Token, c.Bot.NgrokAuthToken, c.Client.AppHash, c.LLM.OpenRouter.ApiKey, c.LLM.OpenAI.ApiKey}
+	for _, t := range c.API.Tokens {
+		secrets = append(secrets, t.Token)
+	}
+	secrets = append(secrets, c.LLM.Gemini.ApiKeys...)
+
+	values := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		if s != "" {
+			values = append(values, s.Value())
+		}
+	}
+	return values
+}
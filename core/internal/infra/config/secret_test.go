@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "bot_token")
+	if err := os.WriteFile(secretFile, []byte("  file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIST_TEST_SECRET", "env-secret")
+	t.Setenv("GIST_TEST_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		in      Secret
+		want    Secret
+		wantErr string // Часть текста ошибки, пусто - без ошибки
+	}{
+		{name: "plain value", in: "plain-secret", want: "plain-secret"},
+		{name: "empty value", in: "", want: ""},
+		{name: "file trimmed", in: Secret("file:" + secretFile), want: "file-secret"},
+		{name: "missing file", in: Secret("file:" + filepath.Join(dir, "missing")), wantErr: "read secret file"},
+		{name: "env", in: "env:GIST_TEST_SECRET", want: "env-secret"},
+		{name: "env set empty", in: "env:GIST_TEST_EMPTY", want: ""},
+		{name: "env not set", in: "env:GIST_TEST_NOT_SET", wantErr: "env GIST_TEST_NOT_SET is not set"},
+		{name: "prefix in the middle", in: "token-env:GIST_TEST_SECRET", want: "token-env:GIST_TEST_SECRET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSecret("bot.token", tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), "bot.token") {
+					t.Errorf("error does not mention the key: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveSecret(%q) = %q, want %q", tt.in.Value(), got.Value(), tt.want.Value())
+			}
+		})
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("GIST_TEST_BOT_TOKEN", "bot-secret")
+
+	c := validConfig()
+	c.Bot.Token = "env:GIST_TEST_BOT_TOKEN"
+	c.Client.AppHash = "env:GIST_TEST_NOT_SET"
+	c.LLM.OpenAI.ApiKey = "env:GIST_TEST_NOT_SET" // Провайдер отключен, ссылка не разрешается
+
+	errs := c.resolveSecrets()
+
+	if c.Bot.Token != "bot-secret" {
+		t.Errorf("bot token = %q, want resolved value", c.Bot.Token.Value())
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "client.app_hash") {
+		t.Errorf("errors = %v, want one error for client.app_hash", errs)
+	}
+	if c.LLM.OpenAI.ApiKey != "env:GIST_TEST_NOT_SET" {
+		t.Errorf("disabled provider key resolved: %q", c.LLM.OpenAI.ApiKey.Value())
+	}
+}
+
+func TestSecretFormatting(t *testing.T) {
+	s := Secret("top-secret")
+
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{"String", s.String()},
+		{"Sprint", fmt.Sprint(s)},
+		{"Sprintf %v", fmt.Sprintf("%v", s)},
+		{"Sprintf %s", fmt.Sprintf("%s", s)},
+		{"Sprintf %#v", fmt.Sprintf("%#v", s)},
+		{"Sprintf struct %+v", fmt.Sprintf("%+v", struct{ Token Secret }{s})},
+		{"slog", slog.AnyValue(s).Resolve().String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if strings.Contains(tt.got, "top-secret") {
+				t.Errorf("secret leaked: %q", tt.got)
+			}
+			if !strings.Contains(tt.got, secretMask) {
+				t.Errorf("secret is not masked: %q", tt.got)
+			}
+		})
+	}
+
+	if s.Value() != "top-secret" {
+		t.Errorf("Value() = %q, want original value", s.Value())
+	}
+	if Secret("").String() != "" {
+		t.Errorf("empty secret must format as empty string")
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	c := validConfig()
+	c.API.Tokens = []APIToken{{Token: "api-token", UserID: c.Client.UserID}}
+	c.LLM.Gemini.ApiKeys = []Secret{"gemini-1", "gemini-2"}
+
+	got := c.Secrets()
+	for _, want := range []string{"bot-token", "app-hash", "api-token", "gemini-1", "gemini-2"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("Secrets() = %v, missing %q", got, want)
+		}
+	}
+	if slices.Contains(got, "") {
+		t.Errorf("Secrets() contains empty value: %v", got)
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Providers провайдеры LLM, которые можно указать в llm.default_provider.
+var Providers = []string{"Ollama", "OpenRouter", "Gemini", "OpenAI"}
+
+// Validate проверяет конфигурацию целиком и возвращает все найденные ошибки сразу, по одной на строку.
+func (c *Config) Validate() error {
+	return c.validate(nil)
+}
+
+// validate проверяет конфигурацию, errs - ошибки, найденные до проверки, например при чтении секретов.
+func (c *Config) validate(errs []error) error {
+	v := &validator{errs: errs}
+
+	c.validateProject(v)
+	c.validateBot(v)
+	c.validateServers(v)
+	c.validateClient(v)
+	c.validateSettings(v)
+	c.validateLLM(v)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(v.errs...))
+}
+
+// validator собирает ошибки проверки конфигурации.
+type validator struct {
+	errs []error
+}
+
+// check добавляет ошибку параметра key, если условие ok не выполнено.
+func (v *validator) check(ok bool, key, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("  %s: %s", key, fmt.Sprintf(format, args...)))
+	}
+}
+
+func (c *Config) validateProject(v *validator) {
+	v.check(c.Project.ShutdownTimeout > 0, "project.shutdownTimeout", "must be positive, got %s", c.Project.ShutdownTimeout)
+	v.check(c.Project.TTL > 0, "project.ttl", "must be positive, got %s", c.Project.TTL)
+	v.check(c.Project.AudioPath != "", "project.audio_path", "must be set")
+	v.check(c.Project.DataPath != "", "project.data_path", "must be set")
+}
+
+func (c *Config) validateBot(v *validator) {
+	v.check(c.Bot.Token != "", "bot.token", "must be set (env BOT_TOKEN)")
+
+	switch c.Bot.Mode {
+	case "", "ngrok":
+		v.check(c.Bot.NgrokAuthToken != "", "bot.ngrok_auth_token", "must be set in ngrok mode (env BOT_NGROK_AUTH_TOKEN)")
+	case "webhook":
+		u, errU := url.Parse(c.Bot.WebhookURL)
+		v.check(c.Bot.WebhookURL != "" && errU == nil && u.Scheme == "https" && u.Host != "", "bot.webhook_url",
+			"must be an https URL in webhook mode, got %q", c.Bot.WebhookURL)
+		v.check(c.Bot.ListenAddress != "", "bot.listen_address", "must be set in webhook mode")
+		v.check((c.Bot.TLSCertFile == "") == (c.Bot.TLSKeyFile == ""), "bot.tls_cert_file", "tls_cert_file and tls_key_file must be set together")
+		v.check(!c.Bot.UploadCertificate || c.Bot.TLSCertFile != "", "bot.upload_certificate", "requires tls_cert_file")
+	case "polling":
+		v.check(c.Bot.PollingTimeout >= 0, "bot.polling_timeout", "must not be negative, got %s", c.Bot.PollingTimeout)
+	default:
+		v.check(false, "bot.mode", "must be one of ngrok, webhook, polling, got %q", c.Bot.Mode)
+	}
+}
+
+func (c *Config) validateServers(v *validator) {
+	if c.API.Enabled {
+		v.check(c.API.ListenAddress != "", "api.listen_address", "must be set when api is enabled")
+		v.check(len(c.API.Tokens) > 0, "api.tokens", "at least one token is required when api is enabled")
+		for i, t := range c.API.Tokens {
+			v.check(t.Token != "", fmt.Sprintf("api.tokens[%d].token", i), "must be set")
+			v.check(t.UserID == c.Client.UserID || slices.Contains(c.Client.AllowedUsers, t.UserID),
+				fmt.Sprintf("api.tokens[%d].user_id", i), "%d is neither client.user_id nor one of client.allowed_users", t.UserID)
+		}
+	}
+
+	if c.Metrics.Enabled {
+		v.check(c.Metrics.ListenAddress != "", "metrics.listen_address", "must be set when metrics are enabled")
+	}
+
+	if c.Tracing.Enabled {
+		v.check(c.Tracing.Endpoint != "", "tracing.endpoint", "must be set when tracing is enabled")
+		v.check(c.Tracing.Protocol == "grpc" || c.Tracing.Protocol == "http", "tracing.protocol", "must be grpc or http, got %q", c.Tracing.Protocol)
+		v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+}
+
+func (c *Config) validateClient(v *validator) {
+	v.check(c.Client.AppID > 0, "client.app_id", "must be set (env CLIENT_APP_ID)")
+	v.check(c.Client.AppHash != "", "client.app_hash", "must be set (env CLIENT_APP_HASH)")
+	v.check(c.Client.UserID != 0, "client.user_id", "must be set (env CLIENT_USER_ID)")
+	v.check(c.Client.RequestTimeout > 0, "client.requestTimeout", "must be positive, got %s", c.Client.RequestTimeout)
+}
+
+func (c *Config) validateSettings(v *validator) {
+	s := c.Settings
+	v.check(s.ChatUnreadThreshold >= 0, "settings.chat_unread_threshold", "must not be negative, got %d", s.ChatUnreadThreshold)
+	v.check(s.JobParallelism > 0, "settings.job_parallelism", "must be positive, got %d", s.JobParallelism)
+	v.check(s.JobHistory >= 0, "settings.job_history", "must not be negative, got %d", s.JobHistory)
+	v.check(s.QAMaxMessages > 0, "settings.qa_max_messages", "must be positive, got %d", s.QAMaxMessages)
+	v.check(s.BriefingChats > 0, "settings.briefing_chats", "must be positive, got %d", s.BriefingChats)
+
+	r := s.Ranking
+	for _, w := range []struct {
+		key    string
+		weight float64
+	}{
+		{"private", r.Private}, {"group", r.Group}, {"channel", r.Channel}, {"mentions", r.Mentions},
+		{"favorite", r.Favorite}, {"interaction", r.Interaction}, {"unread", r.Unread}, {"urgency", r.Urgency},
+	} {
+		v.check(w.weight >= 0, "settings.ranking."+w.key, "must not be negative, got %v", w.weight)
+	}
+	v.check(r.UrgencyMessages >= 0, "settings.ranking.urgency_messages", "must not be negative, got %d", r.UrgencyMessages)
+	v.check(r.UrgencyChats >= 0, "settings.ranking.urgency_chats", "must not be negative, got %d", r.UrgencyChats)
+}
+
+func (c *Config) validateLLM(v *validator) {
+	l := c.LLM
+	v.check(l.FlowTimeout > 0, "llm.flow_timeout", "must be positive, got %s", l.FlowTimeout)
+	v.check(l.PromptTimeout > 0, "llm.prompt_timeout", "must be positive, got %s", l.PromptTimeout)
+	v.check(l.DriftPercent >= 0 && l.DriftPercent < 100, "llm.drift_percent", "must be between 0 and 99, got %d", l.DriftPercent)
+	v.check(l.SymbolPerToken > 0, "llm.symbol_per_token", "must be positive, got %d", l.SymbolPerToken)
+	v.check(l.MessagesPerBatch > 0, "llm.messages_per_batch", "must be positive, got %d", l.MessagesPerBatch)
+
+	enabled := map[string]bool{
+		"Ollama":     l.Ollama.Enabled,
+		"OpenRouter": l.OpenRouter.Enabled,
+		"Gemini":     l.Gemini.Enabled,
+		"OpenAI":     l.OpenAI.Enabled,
+	}
+	if slices.Contains(Providers, l.DefaultProvider) {
+		v.check(enabled[l.DefaultProvider], "llm.default_provider", "provider %s is not enabled", l.DefaultProvider)
+	} else {
+		v.check(false, "llm.default_provider", "must be one of %s, got %q", strings.Join(Providers, ", "), l.DefaultProvider)
+	}
+
+	if l.Ollama.Enabled {
+		v.check(l.Ollama.ServerAddress != "", "llm.Ollama.server_address", "must be set")
+		v.check(l.Ollama.Timeout > 0, "llm.Ollama.timeout", "must be positive, got %d", l.Ollama.Timeout)
+		v.check(l.Ollama.ContextWindow > 0, "llm.Ollama.context_window", "must be positive, got %d", l.Ollama.ContextWindow)
+	}
+	if l.OpenRouter.Enabled {
+		v.check(l.OpenRouter.Model != "", "llm.OpenRouter.model", "must be set")
+		v.check(l.OpenRouter.ContextWindow > 0, "llm.OpenRouter.context_window", "must be positive, got %d", l.OpenRouter.ContextWindow)
+		v.check(l.OpenRouter.ApiKey != "", "llm.OpenRouter.api_key", "must be set")
+	}
+	if l.Gemini.Enabled {
+		v.check(l.Gemini.Model != "", "llm.Gemini.model", "must be set")
+		v.check(l.Gemini.ContextWindow > 0, "llm.Gemini.context_window", "must be positive, got %d", l.Gemini.ContextWindow)
+		v.check(len(l.Gemini.ApiKeys) > 0, "llm.Gemini.api_keys", "at least one key is required")
+		for i, key := range l.Gemini.ApiKeys {
+			v.check(key != "", fmt.Sprintf("llm.Gemini.api_keys[%d]", i), "must not be empty")
+		}
+	}
+	if l.OpenAI.Enabled {
+		v.check(l.OpenAI.Model != "", "llm.OpenAI.model", "must be set")
+		v.check(l.OpenAI.ContextWindow > 0, "llm.OpenAI.context_window", "must be positive, got %d", l.OpenAI.ContextWindow)
+		v.check(l.OpenAI.ApiKey != "", "llm.OpenAI.api_key", "must be set")
+	}
+
+	v.check(l.TTS.MaxAudioFileSize > 0 && l.TTS.MaxAudioFileSize <= 50, "llm.tts.max_audio_file_size", "must be between 1 and 50 Mb, got %d", l.TTS.MaxAudioFileSize)
+	v.check(l.TTS.Gemini.Model != "", "llm.tts.Gemini.model", "must be set")
+	v.check(l.TTS.Gemini.VoiceName != "", "llm.tts.Gemini.voice_name", "must be set")
+	v.check(l.TTS.Gemini.LanguageCode != "", "llm.tts.Gemini.language_code", "must be set")
+
+	for i, p := range l.Prices {
+		key := fmt.Sprintf("llm.prices[%d]", i)
+		v.check(p.Model != "", key+".model", "must be set")
+		v.check(p.Input >= 0 && p.Output >= 0 && p.AudioMinute >= 0, key, "prices must not be negative")
+	}
+}
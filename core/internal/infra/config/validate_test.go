@@ -0,0 +1,209 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig минимальная корректная конфигурация: бот в режиме polling, провайдер LLM Ollama.
+func validConfig() *Config {
+	c := &Config{}
+
+	c.Project.ShutdownTimeout = 10 * time.Second
+	c.Project.TTL = time.Hour
+	c.Project.AudioPath = "audio"
+	c.Project.DataPath = "data"
+
+	c.Bot.Token = "bot-token"
+	c.Bot.Mode = "polling"
+
+	c.Client.AppID = 1
+	c.Client.AppHash = "app-hash"
+	c.Client.UserID = 100
+	c.Client.RequestTimeout = 30 * time.Second
+
+	c.Settings.JobParallelism = 1
+	c.Settings.QAMaxMessages = 100
+	c.Settings.BriefingChats = 10
+
+	c.LLM.FlowTimeout = time.Minute
+	c.LLM.PromptTimeout = time.Minute
+	c.LLM.SymbolPerToken = 3
+	c.LLM.MessagesPerBatch = 100
+	c.LLM.DefaultProvider = "Ollama"
+	c.LLM.Ollama.Enabled = true
+	c.LLM.Ollama.ServerAddress = "http://localhost:11434"
+	c.LLM.Ollama.Timeout = 60
+	c.LLM.Ollama.ContextWindow = 8192
+	c.LLM.TTS.MaxAudioFileSize = 50
+	c.LLM.TTS.Gemini.Model = "tts"
+	c.LLM.TTS.Gemini.VoiceName = "Leda"
+	c.LLM.TTS.Gemini.LanguageCode = "ru-RU"
+
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr []string // Ключи параметров в тексте ошибки, пусто - конфигурация корректна
+	}{
+		{
+			name:   "valid",
+			modify: func(*Config) {},
+		},
+		{
+			name:    "missing bot token",
+			modify:  func(c *Config) { c.Bot.Token = "" },
+			wantErr: []string{"bot.token"},
+		},
+		{
+			name:    "unknown bot mode",
+			modify:  func(c *Config) { c.Bot.Mode = "push" },
+			wantErr: []string{"bot.mode"},
+		},
+		{
+			name:    "ngrok without auth token",
+			modify:  func(c *Config) { c.Bot.Mode = "" },
+			wantErr: []string{"bot.ngrok_auth_token"},
+		},
+		{
+			name: "webhook with http url",
+			modify: func(c *Config) {
+				c.Bot.Mode = "webhook"
+				c.Bot.WebhookURL = "http://example.com"
+				c.Bot.ListenAddress = ":8443"
+			},
+			wantErr: []string{"bot.webhook_url"},
+		},
+		{
+			name: "webhook with cert but no key",
+			modify: func(c *Config) {
+				c.Bot.Mode = "webhook"
+				c.Bot.WebhookURL = "https://example.com"
+				c.Bot.ListenAddress = ":8443"
+				c.Bot.TLSCertFile = "cert.pem"
+			},
+			wantErr: []string{"bot.tls_cert_file"},
+		},
+		{
+			name: "valid webhook",
+			modify: func(c *Config) {
+				c.Bot.Mode = "webhook"
+				c.Bot.WebhookURL = "https://example.com"
+				c.Bot.ListenAddress = ":8443"
+			},
+		},
+		{
+			name: "api token of unknown user",
+			modify: func(c *Config) {
+				c.API.Enabled = true
+				c.API.ListenAddress = ":8080"
+				c.API.Tokens = []APIToken{{Token: "api-token", UserID: 200}}
+			},
+			wantErr: []string{"api.tokens[0].user_id"},
+		},
+		{
+			name: "api token of allowed user",
+			modify: func(c *Config) {
+				c.API.Enabled = true
+				c.API.ListenAddress = ":8080"
+				c.Client.AllowedUsers = []int64{200}
+				c.API.Tokens = []APIToken{{Token: "api-token", UserID: 200}}
+			},
+		},
+		{
+			name: "api enabled without tokens",
+			modify: func(c *Config) {
+				c.API.Enabled = true
+				c.API.ListenAddress = ":8080"
+			},
+			wantErr: []string{"api.tokens"},
+		},
+		{
+			name: "tracing sample ratio out of range",
+			modify: func(c *Config) {
+				c.Tracing.Enabled = true
+				c.Tracing.Endpoint = "localhost:4317"
+				c.Tracing.Protocol = "grpc"
+				c.Tracing.SampleRatio = 1.5
+			},
+			wantErr: []string{"tracing.sample_ratio"},
+		},
+		{
+			name:    "negative unread threshold",
+			modify:  func(c *Config) { c.Settings.ChatUnreadThreshold = -1 },
+			wantErr: []string{"settings.chat_unread_threshold"},
+		},
+		{
+			name:    "negative ranking weight",
+			modify:  func(c *Config) { c.Settings.Ranking.Urgency = -0.5 },
+			wantErr: []string{"settings.ranking.urgency"},
+		},
+		{
+			name:    "default provider disabled",
+			modify:  func(c *Config) { c.LLM.DefaultProvider = "Gemini" },
+			wantErr: []string{"llm.default_provider"},
+		},
+		{
+			name:    "unknown default provider",
+			modify:  func(c *Config) { c.LLM.DefaultProvider = "Claude" },
+			wantErr: []string{"llm.default_provider"},
+		},
+		{
+			name: "gemini with empty key",
+			modify: func(c *Config) {
+				c.LLM.Gemini.Enabled = true
+				c.LLM.Gemini.Model = "gemini"
+				c.LLM.Gemini.ContextWindow = 1000
+				c.LLM.Gemini.ApiKeys = []Secret{"key", ""}
+			},
+			wantErr: []string{"llm.Gemini.api_keys[1]"},
+		},
+		{
+			name:    "audio file too large",
+			modify:  func(c *Config) { c.LLM.TTS.MaxAudioFileSize = 51 },
+			wantErr: []string{"llm.tts.max_audio_file_size"},
+		},
+		{
+			name:    "negative price",
+			modify:  func(c *Config) { c.LLM.Prices = []LLMPrice{{Model: "openai/*", Input: -1}} },
+			wantErr: []string{"llm.prices[0]"},
+		},
+		{
+			name: "all errors at once",
+			modify: func(c *Config) {
+				c.Project.TTL = 0
+				c.Client.AppID = 0
+				c.LLM.FlowTimeout = 0
+			},
+			wantErr: []string{"project.ttl", "client.app_id", "llm.flow_timeout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error for %v, got nil", tt.wantErr)
+			}
+			for _, key := range tt.wantErr {
+				if !strings.Contains(err.Error(), "  "+key+":") {
+					t.Errorf("error does not mention %s:\n%v", key, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// Check проверяет, что ffmpeg и ffprobe установлены и запускаются.
+func Check(ctx context.Context) error {
+	for _, name := range []string{"ffmpeg", "ffprobe"} {
+		if errR := exec.CommandContext(ctx, name, "-version").Run(); errR != nil {
+			return fmt.Errorf("%s: %w", name, errR)
+		}
+	}
+	return nil
+}
@@ -1,10 +1,10 @@
 package ffmpeg
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -13,7 +13,7 @@ import (
 
 // TODO Если пересказ одного батча будет больше 45 Мб, то возникнет неразрешимая ситуация при создании общего файла
 // ConcatMP3 объединяет несколько аудиофайлов в один
-func ConcatMP3(files []model.AudioGist, out string) error {
+func ConcatMP3(ctx context.Context, files []model.AudioGist, out string) error {
 
 	log := slog.With("func", "ffmpeg.ConcatMP3")
 
@@ -31,13 +31,13 @@ func ConcatMP3(files []model.AudioGist, out string) error {
 	defer func() {
 		errR := os.Remove(f.Name())
 		if errR != nil {
-			log.Error("error removing temp file", errR)
+			log.Error("error removing temp file", slog.Any("error", errR))
 		}
 	}()
 	defer func() {
 		errC := f.Close()
 		if errC != nil {
-			log.Error("error closing temp file", errC)
+			log.Error("error closing temp file", slog.Any("error", errC))
 		}
 	}()
 
@@ -60,7 +60,7 @@ func ConcatMP3(files []model.AudioGist, out string) error {
 	}
 
 	// ffmpeg -f concat -safe 0 -i list.txt -c copy output.mp3
-	cmd := exec.Command("ffmpeg",
+	_, errR := run(ctx, "ffmpeg.ConcatMP3", "ffmpeg",
 		"-f", "concat",
 		"-safe", "0",
 		"-i", f.Name(),
@@ -68,5 +68,5 @@ func ConcatMP3(files []model.AudioGist, out string) error {
 		absOut,
 	)
 
-	return cmd.Run()
+	return errR
 }
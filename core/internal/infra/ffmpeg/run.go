@@ -0,0 +1,19 @@
+package ffmpeg
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/arslanovdi/Gist/core/internal/infra/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// run запускает процесс ffmpeg или ffprobe в спане трассировки span. Возвращает stdout процесса.
+func run(ctx context.Context, span string, name string, args ...string) ([]byte, error) {
+	ctx, sp := tracing.Start(ctx, span, attribute.String("process.command_line", name+" "+strings.Join(args, " ")))
+
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	tracing.End(sp, err)
+	return out, err
+}
@@ -1,10 +1,10 @@
 package ffmpeg
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -13,7 +13,7 @@ import (
 )
 
 // SplitMP3 разбивает аудиофайл на части.
-func SplitMP3(fileName string, maxSize int64) ([]model.AudioGist, error) {
+func SplitMP3(ctx context.Context, fileName string, maxSize int64) ([]model.AudioGist, error) {
 	log := slog.With("func", "ffmpeg.SplitMP3")
 
 	absIn, errA := filepath.Abs(fileName)
@@ -36,7 +36,7 @@ func SplitMP3(fileName string, maxSize int64) ([]model.AudioGist, error) {
 	log.Debug("output pattern", slog.String("pattern", outPattern))
 
 	// Получаем битрейт для расчета segment_time
-	bitrate, errB := getBitrate(absIn)
+	bitrate, errB := getBitrate(ctx, absIn)
 	if errB != nil {
 		return nil, fmt.Errorf("get bitrate error: %w", errB)
 	}
@@ -59,7 +59,7 @@ func SplitMP3(fileName string, maxSize int64) ([]model.AudioGist, error) {
 	}()
 
 	// вызов FFmpeg создаёт все части сразу
-	cmd := exec.Command("ffmpeg",
+	args := []string{
 		"-i", absIn,
 		"-f", "segment",
 		"-segment_time", fmt.Sprintf("%.3f", segmentTime),
@@ -68,10 +68,10 @@ func SplitMP3(fileName string, maxSize int64) ([]model.AudioGist, error) {
 		"-reset_timestamps", "1",
 		"-y",
 		absOutPattern,
-	)
+	}
 
-	if errR := cmd.Run(); errR != nil {
-		return nil, fmt.Errorf("ffmpeg split error: %w (args: %v)", errR, cmd.Args)
+	if _, errR := run(ctx, "ffmpeg.SplitMP3", "ffmpeg", args...); errR != nil {
+		return nil, fmt.Errorf("ffmpeg split error: %w (args: %v)", errR, args)
 	}
 
 	// Собираем созданные файлы
@@ -110,15 +110,14 @@ func SplitMP3(fileName string, maxSize int64) ([]model.AudioGist, error) {
 }
 
 // getBitrate функция получения битрейта из файла
-func getBitrate(file string) (int64, error) {
-	cmd := exec.Command("ffprobe",
+func getBitrate(ctx context.Context, file string) (int64, error) {
+	out, err := run(ctx, "ffmpeg.getBitrate", "ffprobe",
 		"-v", "quiet",
 		"-select_streams", "a:0", // первый аудиопоток
 		"-show_entries", "stream=bit_rate",
 		"-of", "csv=p=0",
 		file,
 	)
-	out, err := cmd.Output()
 	if err != nil {
 		return 128000, fmt.Errorf("ffprobe bitrate failed: %w", err)
 	}
@@ -1,14 +1,15 @@
 package ffmpeg
 
-import "os/exec"
+import "context"
 
-func ConvertWavToMp3(inputPath, outputPath string) error {
-	cmd := exec.Command("ffmpeg",
+// ConvertWavToMp3 конвертирует WAV файл в mp3.
+func ConvertWavToMp3(ctx context.Context, inputPath, outputPath string) error {
+	_, err := run(ctx, "ffmpeg.ConvertWavToMp3", "ffmpeg",
 		"-i", inputPath,
 		"-q:a", "0", // лучшее качество
 		"-map", "a",
 		outputPath,
 	)
 
-	return cmd.Run()
+	return err
 }
@@ -0,0 +1,193 @@
+// Package health пробы работоспособности (/healthz) и готовности (/readyz) приложения.
+// /healthz проверяет только, что процесс отвечает. Проверки зависимостей выполняются в /readyz, результат кэшируется.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const checkTimeout = 5 * time.Second // Тайм-аут одной проверки, медленный компонент не должен блокировать пробу
+
+// Статусы проверок и итогового отчета
+const (
+	StatusOK       = "ok"       // Все проверки прошли
+	StatusDegraded = "degraded" // Не прошли только некритичные проверки
+	StatusFail     = "fail"     // Не прошла критичная проверка
+)
+
+// CheckFunc проверка компонента, nil - компонент работает.
+type CheckFunc func(ctx context.Context) error
+
+// check зарегистрированная проверка и ее последний результат.
+type check struct {
+	name     string
+	critical bool          // Ошибка критичной проверки переводит приложение в статус fail
+	ttl      time.Duration // Время, в течение которого используется последний результат. 0 - проверка выполняется на каждый запрос
+	fn       CheckFunc
+
+	mu       sync.Mutex
+	checked  time.Time
+	result   error
+	duration time.Duration
+}
+
+// CheckResult результат проверки в отчете.
+type CheckResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report отчет о состоянии приложения.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Checker набор проверок компонентов приложения.
+type Checker struct {
+	checks []*check
+
+	started  atomic.Bool // Приложение запущено
+	shutdown atomic.Bool // Приложение останавливается
+}
+
+// New создает пустой набор проверок.
+func New() *Checker {
+	return &Checker{}
+}
+
+// Add регистрирует проверку. Результат проверки кэшируется на ttl, чтобы частые пробы не нагружали внешние API.
+// Регистрировать проверки нужно до запуска HTTP сервера.
+func (c *Checker) Add(name string, critical bool, ttl time.Duration, fn CheckFunc) {
+	c.checks = append(c.checks, &check{name: name, critical: critical, ttl: ttl, fn: fn})
+}
+
+// Started отмечает, что запуск приложения завершен.
+func (c *Checker) Started() {
+	c.started.Store(true)
+}
+
+// Shutdown отмечает начало остановки приложения, /readyz начинает отвечать 503.
+func (c *Checker) Shutdown() {
+	c.shutdown.Store(true)
+}
+
+// Check выполняет все проверки параллельно и собирает отчет.
+func (c *Checker) Check(ctx context.Context) Report {
+	results := make([]CheckResult, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, ch := range c.checks {
+		wg.Go(func() {
+			results[i] = ch.run(ctx)
+		})
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, r := range results {
+		if r.Status == StatusOK {
+			continue
+		}
+		if r.Critical {
+			report.Status = StatusFail
+			break
+		}
+		report.Status = StatusDegraded
+	}
+
+	return report
+}
+
+// run выполняет проверку или возвращает закэшированный результат.
+func (ch *check) run(ctx context.Context) CheckResult {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.checked.IsZero() || time.Since(ch.checked) >= ch.ttl {
+		ctxCheck, cancel := context.WithTimeout(ctx, checkTimeout)
+		start := time.Now()
+		ch.result = ch.fn(ctxCheck)
+		ch.duration = time.Since(start)
+		ch.checked = time.Now()
+		cancel()
+	}
+
+	result := CheckResult{
+		Name:       ch.name,
+		Status:     StatusOK,
+		Critical:   ch.critical,
+		DurationMs: ch.duration.Milliseconds(),
+	}
+	if ch.result != nil {
+		result.Status = StatusFail
+		result.Error = ch.result.Error()
+	}
+	return result
+}
+
+// Healthz обработчик /healthz: проба работоспособности процесса, всегда 200. Зависимости не проверяет,
+// чтобы недоступность внешнего API не приводила к перезапуску приложения.
+func (c *Checker) Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK, Checks: []CheckResult{}})
+}
+
+// Readyz обработчик /readyz: проверки зависимостей, 503 - если не прошла критичная проверка,
+// а также до завершения запуска и во время остановки приложения.
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case c.shutdown.Load():
+		writeReport(w, http.StatusServiceUnavailable, Report{Status: "shutting_down", Checks: []CheckResult{}})
+		return
+	case !c.started.Load():
+		writeReport(w, http.StatusServiceUnavailable, Report{Status: "starting", Checks: []CheckResult{}})
+		return
+	}
+
+	report := c.Check(r.Context())
+
+	code := http.StatusOK
+	if report.Status == StatusFail {
+		code = http.StatusServiceUnavailable
+	}
+	writeReport(w, code, report)
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if errE := json.NewEncoder(w).Encode(report); errE != nil {
+		slog.With("func", "health.writeReport").Error("encode report", slog.Any("error", errE))
+	}
+}
+
+// WritableDir проверка, что в каталоге dir можно создавать файлы.
+func WritableDir(dir string) CheckFunc {
+	return func(_ context.Context) error {
+		if errM := os.MkdirAll(dir, 0o750); errM != nil {
+			return fmt.Errorf("create dir: %w", errM)
+		}
+
+		f, errC := os.CreateTemp(dir, ".healthz-*")
+		if errC != nil {
+			return fmt.Errorf("create file: %w", errC)
+		}
+		_ = f.Close()
+
+		if errR := os.Remove(f.Name()); errR != nil {
+			return fmt.Errorf("remove file: %w", errR)
+		}
+		return nil
+	}
+}
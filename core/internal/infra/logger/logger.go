@@ -5,6 +5,10 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel/trace"
 )
@@ -13,15 +17,42 @@ var (
 	options     *slog.HandlerOptions
 	loglevel    *slog.LevelVar
 	serviceName string
+
+	secretsMu sync.Mutex
+	secrets   []string                         // Значения секретов, которые маскируются в логах
+	redactor  atomic.Pointer[strings.Replacer] // Замена секретов маской, nil - секреты не заданы
 )
 
+const secretMask = "********"
+
+// minSecretLength секреты короче не маскируются в тексте, иначе маскировались бы случайные совпадения
+const minSecretLength = 6
+
+// secretKeys атрибуты лога, значения которых всегда скрываются
+var secretKeys = map[string]bool{
+	"password":     true,
+	"token":        true,
+	"api_key":      true,
+	"app_hash":     true,
+	"secret_token": true,
+}
+
 // contextHandler - обертка над стандартным slog.Handler, которая добавляет
 // информацию о трассировке (trace_id и span_id) в логи, если они присутствуют в контексте и сэмплированы.
 type contextHandler struct {
 	slog.Handler
 }
 
+// WithAttrs маскирует секреты в атрибутах slog.With: базовый обработчик форматирует их сразу, в Handle они уже не попадают.
+// Маскируются секреты, добавленные AddSecrets до создания логгера.
 func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if rep := redactor.Load(); rep != nil {
+		redacted := make([]slog.Attr, 0, len(attrs))
+		for _, a := range attrs {
+			redacted = append(redacted, redactAttr(rep, a))
+		}
+		attrs = redacted
+	}
 	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
 }
 func (h *contextHandler) WithGroup(name string) slog.Handler {
@@ -38,6 +69,10 @@ func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
 		}
 	}
 
+	if rep := redactor.Load(); rep != nil {
+		r = redactRecord(rep, r)
+	}
+
 	span := trace.SpanFromContext(ctx)
 
 	sCtx := span.SpanContext()
@@ -52,9 +87,9 @@ func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
 
 // InitializeLogger initializes the slog logger
 func InitializeLogger(level slog.Level, service string) {
-	hidePassword := func(_ []string, a slog.Attr) slog.Attr {
-		if a.Key == "password" {
-			return slog.String("password", "********")
+	hideSecrets := func(_ []string, a slog.Attr) slog.Attr {
+		if secretKeys[a.Key] {
+			return slog.String(a.Key, secretMask)
 		}
 		return a
 	}
@@ -63,7 +98,7 @@ func InitializeLogger(level slog.Level, service string) {
 
 	options = &slog.HandlerOptions{
 		AddSource:   false,
-		ReplaceAttr: hidePassword,
+		ReplaceAttr: hideSecrets,
 		Level:       loglevel,
 	}
 
@@ -92,3 +127,58 @@ func SetLogLevel(level slog.Level) {
 	loglevel.Set(level)
 	log.Info("Set logger level", slog.String("level", level.Level().String()))
 }
+
+// AddSecrets добавляет значения секретов (токены, ключи API), которые маскируются в тексте и атрибутах всех сообщений лога.
+func AddSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, v := range values {
+		if len(v) >= minSecretLength && !slices.Contains(secrets, v) {
+			secrets = append(secrets, v)
+		}
+	}
+	if len(secrets) == 0 {
+		return
+	}
+
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, v := range secrets {
+		pairs = append(pairs, v, secretMask)
+	}
+	redactor.Store(strings.NewReplacer(pairs...))
+}
+
+// redactRecord маскирует секреты в сообщении и атрибутах записи лога.
+func redactRecord(rep *strings.Replacer, r slog.Record) slog.Record {
+	redacted := slog.NewRecord(r.Time, r.Level, rep.Replace(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(rep, a))
+		return true
+	})
+	return redacted
+}
+
+func redactAttr(rep *strings.Replacer, a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(rep.Replace(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, 0, len(group))
+		for _, g := range group {
+			attrs = append(attrs, redactAttr(rep, g))
+		}
+		a.Value = slog.GroupValue(attrs...)
+	case slog.KindAny: // Ошибки запросов к API могут содержать токен, например в URL
+		if err, ok := a.Value.Any().(error); ok {
+			if text := err.Error(); rep.Replace(text) != text {
+				a.Value = slog.StringValue(rep.Replace(text))
+			}
+		}
+	default:
+	}
+	return a
+}
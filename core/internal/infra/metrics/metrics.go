@@ -0,0 +1,171 @@
+// Package metrics метрики приложения в формате Prometheus
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "gist"
+
+var (
+	llmPromptDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "prompt_duration_seconds",
+		Help:      "Время выполнения одного запроса к LLM, включая неудачные попытки.",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300, 600, 900},
+	}, []string{"provider", "model", "prompt", "status"})
+
+	llmRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "retries_total",
+		Help:      "Повторы запросов к LLM по категории ошибки.",
+	}, []string{"category"})
+
+	llmTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "tokens_total",
+		Help:      "Токены запросов (in) и ответов (out) LLM по данным провайдера.",
+	}, []string{"provider", "model", "direction"})
+
+	geminiKeyRotations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "gemini_key_rotations_total",
+		Help:      "Переключения на следующий Gemini API ключ из пула.",
+	})
+
+	ttsDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tts",
+		Name:      "generation_duration_seconds",
+		Help:      "Время генерации аудиопересказа одного батча: синтез речи и конвертация в mp3.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 120, 300},
+	})
+
+	ttsAudioSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tts",
+		Name:      "audio_size_bytes",
+		Help:      "Размер mp3 файла аудиопересказа.",
+		Buckets:   prometheus.ExponentialBuckets(16*1024, 2, 10), // 16 КБ .. 8 МБ
+	})
+
+	telegramFloodWaits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "flood_waits_total",
+		Help:      "Ошибки FLOOD_WAIT Telegram API.",
+	})
+
+	telegramFloodWaitSeconds = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "flood_wait_seconds_total",
+		Help:      "Суммарное время ожидания по FLOOD_WAIT.",
+	})
+
+	telegramFetchedMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "fetched_messages_total",
+		Help:      "Сообщения, загруженные из чатов для пересказа.",
+	})
+
+	telegramFetchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "fetch_duration_seconds",
+		Help:      "Время загрузки сообщений чата для пересказа.",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 30, 60, 120, 300, 600},
+	})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "core",
+		Name:      "cache_requests_total",
+		Help:      "Обращения к кэшу: список чатов (chats), загруженные сообщения чата (messages).",
+	}, []string{"cache", "result"})
+
+	botCallbackDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "callback_duration_seconds",
+		Help:      "Время обработки нажатия кнопки бота по обработчику.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler", "status"})
+)
+
+// ObserveLLMPrompt учитывает запрос к LLM. modelName - имя модели genkit "провайдер/модель", status - ok или error.
+func ObserveLLMPrompt(modelName, prompt, status string, d time.Duration) {
+	provider, name := splitModel(modelName)
+	llmPromptDuration.WithLabelValues(provider, name, prompt, status).Observe(d.Seconds())
+}
+
+// AddLLMTokens учитывает токены запроса и ответа LLM.
+func AddLLMTokens(modelName string, in, out int) {
+	provider, name := splitModel(modelName)
+	llmTokens.WithLabelValues(provider, name, "in").Add(float64(in))
+	llmTokens.WithLabelValues(provider, name, "out").Add(float64(out))
+}
+
+// IncLLMRetry учитывает повтор запроса к LLM.
+func IncLLMRetry(category string) {
+	llmRetries.WithLabelValues(category).Inc()
+}
+
+// IncGeminiKeyRotation учитывает переключение Gemini API ключа.
+func IncGeminiKeyRotation() {
+	geminiKeyRotations.Inc()
+}
+
+// ObserveTTS учитывает генерацию аудиопересказа и размер mp3 файла.
+func ObserveTTS(d time.Duration, size int64) {
+	ttsDuration.Observe(d.Seconds())
+	ttsAudioSize.Observe(float64(size))
+}
+
+// ObserveFloodWait учитывает ожидание по FLOOD_WAIT Telegram API.
+func ObserveFloodWait(d time.Duration) {
+	telegramFloodWaits.Inc()
+	telegramFloodWaitSeconds.Add(d.Seconds())
+}
+
+// ObserveFetch учитывает загрузку сообщений чата.
+func ObserveFetch(messages int, d time.Duration) {
+	telegramFetchedMessages.Add(float64(messages))
+	telegramFetchDuration.Observe(d.Seconds())
+}
+
+// IncCache учитывает обращение к кэшу: hit - данные взяты из кэша.
+func IncCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveCallback учитывает обработку нажатия кнопки бота.
+func ObserveCallback(handler string, err error, d time.Duration) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	botCallbackDuration.WithLabelValues(handler, status).Observe(d.Seconds())
+}
+
+// splitModel разделяет имя модели genkit на провайдера (плагин) и модель.
+func splitModel(modelName string) (string, string) {
+	provider, name, found := strings.Cut(modelName, "/")
+	if !found {
+		return "unknown", modelName
+	}
+	return provider, name
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server HTTP сервер метрик, Prometheus забирает метрики с /metrics.
+type Server struct {
+	srv  *http.Server
+	mux  *http.ServeMux
+	addr string
+
+	wg *sync.WaitGroup
+}
+
+// New создает HTTP сервер метрик.
+func New(cfg *config.Config) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	return &Server{
+		srv: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: cfg.Metrics.ReadHeaderTimeout,
+		},
+		mux:  mux,
+		addr: cfg.Metrics.ListenAddress,
+		wg:   &sync.WaitGroup{},
+	}
+}
+
+// Handle добавляет обработчик на сервер метрик, например пробы /healthz и /readyz. Вызывается до Run.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Run запускает HTTP сервер в отдельной горутине. Ошибку запуска отправляет в serverErr.
+func (s *Server) Run(_ context.Context, serverErr chan error) {
+	log := slog.With("func", "metrics.Run")
+
+	listener, errL := net.Listen("tcp", s.addr)
+	if errL != nil {
+		serverErr <- fmt.Errorf("[metrics.Run] listen failed: %w", errL)
+		return
+	}
+
+	s.wg.Go(func() {
+		errS := s.srv.Serve(listener)
+		if errS != nil && !errors.Is(errS, http.ErrServerClosed) {
+			serverErr <- fmt.Errorf("[metrics.Run] serve failed: %w", errS)
+		}
+	})
+
+	log.Info("metrics server started", slog.String("address", s.addr))
+}
+
+// Close останавливает HTTP сервер.
+func (s *Server) Close(ctx context.Context) {
+	log := slog.With("func", "metrics.Close")
+
+	if errS := s.srv.Shutdown(ctx); errS != nil {
+		log.Error("Error shutting down metrics server", slog.Any("error", errS))
+	}
+
+	s.wg.Wait()
+	log.Info("metrics server stopped")
+}
@@ -0,0 +1,94 @@
+// Package tracing трассировка OpenTelemetry с экспортом по OTLP
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/arslanovdi/Gist/core/internal/infra/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/arslanovdi/Gist/core"
+
+const (
+	protocolGRPC = "grpc"
+	protocolHTTP = "http"
+)
+
+// Init настраивает глобальный TracerProvider с экспортом спанов в OTLP коллектор.
+// Вызывается до инициализации genkit: genkit пишет спаны сценариев и промптов в глобальный TracerProvider.
+// Возвращает функцию остановки, которая отправляет накопленные спаны. Если трассировка отключена, ничего не настраивает.
+func Init(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	log := slog.With("func", "tracing.Init")
+
+	if !cfg.Tracing.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var errE error
+	switch cfg.Tracing.Protocol {
+	case protocolGRPC, "":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Tracing.Endpoint)}
+		if cfg.Tracing.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, errE = otlptracegrpc.New(ctx, opts...)
+	case protocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Tracing.Endpoint)}
+		if cfg.Tracing.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, errE = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("tracing.Init: unknown otlp protocol %q", cfg.Tracing.Protocol)
+	}
+	if errE != nil {
+		return nil, fmt.Errorf("tracing.Init: create otlp exporter: %w", errE)
+	}
+
+	res, errR := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.Tracing.ServiceName)))
+	if errR != nil {
+		return nil, fmt.Errorf("tracing.Init: create resource: %w", errR)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	log.Info("tracing enabled",
+		slog.String("endpoint", cfg.Tracing.Endpoint),
+		slog.String("protocol", cfg.Tracing.Protocol),
+		slog.Float64("sample_ratio", cfg.Tracing.SampleRatio))
+
+	return provider.Shutdown, nil
+}
+
+// Start начинает спан name, дочерний к спану из контекста.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End завершает спан, ошибка записывается в спан и помечает его статусом Error.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}